@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"crypto-wallet-backend/internal/database"
+	"crypto-wallet-backend/internal/services"
+)
+
+// runCommand runs a maintenance subcommand instead of the server and returns
+// the process exit code
+func runCommand(db *database.Database, args []string) int {
+	switch args[0] {
+	case "verify-audit":
+		return verifyAudit(db)
+	case "migrate":
+		return migrate(db, args[1:])
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n%s", args[0], usage)
+	return 2
+}
+
+const usage = `usage:
+  server verify-audit           check the audit log hash chain
+  server migrate [up]           apply pending migrations
+  server migrate down [steps]   roll back the latest migrations (default 1)
+  server migrate status         list migrations and whether they are applied
+`
+
+// migrate applies, rolls back or lists schema migrations
+func migrate(db *database.Database, args []string) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	action := "up"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "up":
+		applied, err := db.Migrate(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Migration failed: %v\n", err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		return 0
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "steps must be a positive number, got %q\n", args[1])
+				return 2
+			}
+			steps = n
+		}
+		rolledBack, err := db.Rollback(ctx, steps)
+		for _, m := range rolledBack {
+			fmt.Printf("rolled back %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Rollback failed: %v\n", err)
+			return 1
+		}
+		if len(rolledBack) == 0 {
+			fmt.Println("no migrations are applied")
+		}
+		return 0
+
+	case "status":
+		statuses, err := db.MigrationStatus(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read migrations: %v\n", err)
+			return 1
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			switch {
+			case s.Modified:
+				state += " (modified since)"
+			case s.Missing:
+				state += " (no migration file)"
+			}
+			fmt.Printf("%04d_%-32s %s\n", s.Version, s.Name, state)
+		}
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown migrate action %q\n%s", action, usage)
+	return 2
+}
+
+// verifyAudit walks the audit log, printing the result as JSON. It exits 1
+// if the log is broken.
+func verifyAudit(db *database.Database) int {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	result, err := services.NewAuditService(db).Verify(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to verify audit log: %v\n", err)
+		return 1
+	}
+
+	out, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(out))
+	if !result.Valid {
+		fmt.Fprintf(os.Stderr, "Audit log broken at entry %d: %s\n", result.FirstBreak.Sequence, result.FirstBreak.Reason)
+		return 1
+	}
+	return 0
+}
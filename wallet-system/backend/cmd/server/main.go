@@ -1,53 +1,227 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"crypto-wallet-backend/internal/api"
 	"crypto-wallet-backend/internal/blockchain"
 	"crypto-wallet-backend/internal/database"
+	"crypto-wallet-backend/internal/metrics"
+	"crypto-wallet-backend/internal/utils"
 	"crypto-wallet-backend/pkg/config"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	// Load configuration
-	cfg := config.LoadConfig()
+	os.Exit(run())
+}
+
+// run starts the application and returns its exit code once it has shut
+// down. Everything it opens is closed by its deferred calls, which os.Exit
+// would skip.
+func run() int {
+	// Flush the log last, after everything else has logged its shutdown
+	defer os.Stderr.Sync()
+
+	// Load configuration; until it is loaded, errors are logged as JSON
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fail(utils.NewLogger(os.Stderr, "info", "json"), "Invalid configuration", err)
+	}
+
+	// Structured logs on stderr; the standard library's log package and
+	// the blockchain package write through the same logger
+	logger := utils.NewLogger(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	slog.SetDefault(logger)
 
 	// Initialize database
-	db, err := database.NewDatabase(cfg.DatabaseURL)
+	db, err := database.NewDatabase(cfg.Database.URL, database.PoolOptions{
+		MaxOpenConns:    cfg.Database.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.MaxIdleConns,
+		ConnMaxLifetime: time.Duration(cfg.Database.ConnMaxLifetime),
+		ConnMaxIdleTime: time.Duration(cfg.Database.ConnMaxIdleTime),
+	})
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		return fail(logger, "Failed to connect to database", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			logger.Error("Failed to close database", "error", err)
+		}
+	}()
+
+	// Subcommands run against the database and exit instead of serving
+	if len(os.Args) > 1 {
+		return runCommand(db, os.Args[1:])
+	}
+
+	// Bring the schema up to date; instances starting together wait on each
+	// other's migrations
+	if cfg.Database.AutoMigrate {
+		migrateCtx, cancelMigrate := context.WithTimeout(context.Background(), 5*time.Minute)
+		applied, err := db.Migrate(migrateCtx)
+		cancelMigrate()
+		for _, m := range applied {
+			logger.Info("Applied migration", "version", m.Version, "name", m.Name)
+		}
+		if err != nil {
+			return fail(logger, "Failed to migrate database", err)
+		}
 	}
-	defer db.Close()
 
 	// Initialize blockchain
-	bc := blockchain.NewBlockchain()
+	bc := blockchain.NewBlockchainWithParams(blockchain.Params{
+		Difficulty:      cfg.Chain.Difficulty,
+		TargetBlockTime: time.Duration(cfg.Chain.TargetBlockTime),
+	})
+
+	// Metrics read from the database and chain on each scrape
+	m := metrics.New()
+	m.WatchDatabase(db.Stats)
+	m.WatchChain(bc)
+	m.WatchMempool(func(ctx context.Context) (int64, error) {
+		return db.CountTransactionsByStatus(ctx, "pending")
+	})
 
 	// Create handler
-	handler := api.NewHandler(db, bc, cfg.JWTSecret)
+	handler, err := api.NewHandler(db, bc, cfg, logger, m)
+	if err != nil {
+		return fail(logger, "Failed to create handler", err)
+	}
+
+	// Promote the configured initial admin on a fresh deployment
+	bootstrapCtx, cancelBootstrap := context.WithTimeout(context.Background(), 10*time.Second)
+	if promoted, err := handler.BootstrapAdmin(bootstrapCtx, cfg.Auth.InitialAdminEmail); err != nil {
+		logger.Error("Failed to bootstrap initial admin", "error", err)
+	} else if promoted {
+		logger.Info("Promoted initial admin", "email", cfg.Auth.InitialAdminEmail)
+	}
+	cancelBootstrap()
+
+	if cfg.Auth.JWTSecret == config.DevJWTSecret {
+		logger.Warn("JWT_SECRET not set; tokens are signed with the development default, refused in production")
+	}
+	if cfg.Zakat.SigningKey == "" {
+		logger.Warn("ZAKAT_SIGNING_KEY not set; zakat transactions are signed with an ephemeral key")
+	}
+	if cfg.Auth.TwoFactorKey == "" {
+		logger.Warn("TWO_FACTOR_KEY not set; authenticator secrets are encrypted with JWT_SECRET")
+	}
+	if cfg.Mail.SMTPHost == "" {
+		logger.Warn("SMTP_HOST not set; emails are written to MAIL_FILE or the log instead of sent")
+	}
 
 	// Set Gin mode
-	if cfg.NodeEnv == "production" {
+	if cfg.IsProduction() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	// Create router
-	router := gin.Default()
+	// Create router; requests are logged by RequestLogMiddleware
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	// Client addresses key the rate limits, so X-Forwarded-For is only
+	// believed from known proxies
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return fail(logger, "Invalid TRUSTED_PROXIES", err)
+	}
+	if len(cfg.Server.TrustedProxies) == 0 {
+		logger.Warn("TRUSTED_PROXIES not set; behind a proxy all clients share one address for rate limiting")
+	}
 
 	// Apply middleware
-	router.Use(api.CORSMiddleware(cfg.CORSAllowedOrigins))
+	router.Use(api.RequestContextMiddleware())
+	router.Use(api.RequestLogMiddleware(logger))
+	router.Use(api.MetricsMiddleware(m))
+	router.Use(api.CORSMiddleware(cfg.CORS.AllowedOrigins))
 
 	// Setup routes
 	api.SetupRoutes(router, handler)
 
+	// Stop on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Start background jobs; they stop when ctx is cancelled
+	location, err := time.LoadLocation(cfg.Server.SchedulerTimezone)
+	if err != nil {
+		return fail(logger, "Invalid SCHEDULER_TIMEZONE", err)
+	}
+	scheduler := utils.NewScheduler(db, location, logger)
+	if err := handler.RegisterTasks(scheduler, cfg.Zakat.Schedule); err != nil {
+		return fail(logger, "Failed to register background jobs", err)
+	}
+	schedulerDone := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(schedulerDone)
+	}()
+
 	// Start server: bind explicitly to 0.0.0.0 so PaaS like Render can detect the open port
-	port := cfg.Port
-	addr := fmt.Sprintf("0.0.0.0:%s", port)
-	fmt.Printf("Starting server on %s\n", addr)
-	if err := router.Run(addr); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	server := &http.Server{
+		Addr:              fmt.Sprintf("0.0.0.0:%s", cfg.Server.Port),
+		Handler:           router,
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadTimeout),
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout),
+		WriteTimeout:      time.Duration(cfg.Server.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.Server.IdleTimeout),
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+	serverErr := make(chan error, 1)
+	logger.Info("Starting server", "addr", server.Addr)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	code := 0
+	select {
+	case <-ctx.Done():
+		logger.Info("Shutting down", "timeout", cfg.Server.ShutdownTimeout.String())
+	case err := <-serverErr:
+		logger.Error("Server stopped", "error", err)
+		code = 1
 	}
+	// A second signal kills the process instead of waiting for the drain
+	stop()
+
+	shutdown(logger, time.Duration(cfg.Server.ShutdownTimeout), handler, server, schedulerDone)
+	return code
+}
+
+// shutdown stops the application within timeout, in order: mining is
+// abandoned and readiness fails, the server stops accepting connections and
+// drains requests in flight, then running jobs, already told to stop, are
+// waited for. The database is closed by the caller afterwards.
+func shutdown(logger *slog.Logger, timeout time.Duration, handler *api.Handler, server *http.Server, schedulerDone <-chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	handler.Shutdown()
+
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Warn("Requests did not finish in time; closing connections", "error", err)
+		server.Close()
+	}
+
+	logger.Info("Waiting for background jobs")
+	select {
+	case <-schedulerDone:
+	case <-ctx.Done():
+		logger.Warn("Background jobs did not stop in time")
+	}
+	logger.Info("Drained; closing database")
+}
+
+// fail logs err and returns the exit code of a failed start
+func fail(logger *slog.Logger, msg string, err error) int {
+	logger.Error(msg, "error", err)
+	return 1
 }
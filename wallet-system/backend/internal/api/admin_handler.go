@@ -0,0 +1,490 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"crypto-wallet-backend/internal/database"
+	"crypto-wallet-backend/internal/services"
+	"crypto-wallet-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminJobTimeout bounds a job run triggered through the admin API
+const adminJobTimeout = 5 * time.Minute
+
+// maintenanceJobs are the jobs admins may run on demand by name
+var maintenanceJobs = map[string]bool{
+	jobReconcileBalances:  true,
+	jobScheduledTransfers: true,
+	jobMultisigRecovery:   true,
+	jobRunsCleanup:        true,
+	jobSessionsCleanup:    true,
+	jobUnlockTokens:       true,
+	jobLoginFailures:      true,
+	jobAuditAnchor:        true,
+}
+
+// ProcessZakatRequest represents a request to run zakat for a month
+type ProcessZakatRequest struct {
+	MonthYear string `json:"month_year"`
+	DryRun    bool   `json:"dry_run"`
+}
+
+// ProcessZakatHandler runs zakat deduction for a month (YYYY-MM, default the
+// current month) on an admin's request. With dry_run it only reports what
+// each wallet would be charged.
+func (h *Handler) ProcessZakatHandler(c *gin.Context) {
+	var req ProcessZakatRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+			return
+		}
+	}
+	if req.MonthYear == "" {
+		req.MonthYear = time.Now().Format("2006-01")
+	}
+	if _, err := time.Parse("2006-01", req.MonthYear); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "month_year must be YYYY-MM", Code: "INVALID_MONTH"})
+		return
+	}
+
+	h.runAdminJob(c, jobMonthlyZakat, database.JobTriggerManual, time.Now(), "",
+		jobParams{MonthYear: req.MonthYear, DryRun: req.DryRun})
+}
+
+// RunMaintenanceRequest represents a request to run a maintenance task
+type RunMaintenanceRequest struct {
+	DryRun bool `json:"dry_run"`
+}
+
+// RunMaintenanceHandler runs a maintenance job such as reconcile-balances
+// on an admin's request
+func (h *Handler) RunMaintenanceHandler(c *gin.Context) {
+	task := c.Param("task")
+	if !maintenanceJobs[task] {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Unknown maintenance task", Code: "NOT_FOUND"})
+		return
+	}
+
+	var req RunMaintenanceRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+			return
+		}
+	}
+
+	h.runAdminJob(c, task, database.JobTriggerManual, time.Now(), "", jobParams{DryRun: req.DryRun})
+}
+
+// GetJobRunsHandler lists recent job runs, optionally filtered by ?job= and
+// ?status=
+func (h *Handler) GetJobRunsHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "limit must be between 1 and 500", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	runs, err := h.db.GetJobRuns(ctx, c.Query("job"), c.Query("status"), limit)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to get job runs", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get job runs", Code: "DATABASE_ERROR"})
+		return
+	}
+	if runs == nil {
+		runs = []*database.JobRun{}
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Job runs retrieved",
+		Data: gin.H{
+			"runs":  runs,
+			"count": len(runs),
+		},
+	})
+}
+
+// GetJobRunHandler returns one job run with its per-wallet outcomes
+func (h *Handler) GetJobRunHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	run, err := h.db.GetJobRun(ctx, c.Param("id"))
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to get job run", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get job run", Code: "DATABASE_ERROR"})
+		return
+	}
+	if run == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Job run not found", Code: "NOT_FOUND"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Job run retrieved",
+		Data: gin.H{
+			"run": run,
+		},
+	})
+}
+
+// RerunJobHandler runs a failed job run again with the same parameters
+func (h *Handler) RerunJobHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	run, err := h.db.GetJobRun(ctx, c.Param("id"))
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to get job run", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get job run", Code: "DATABASE_ERROR"})
+		return
+	}
+	if run == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Job run not found", Code: "NOT_FOUND"})
+		return
+	}
+	if run.Status != utils.JobFailed {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Only failed job runs can be rerun", Code: "JOB_NOT_FAILED"})
+		return
+	}
+
+	var params jobParams
+	if len(run.Params) > 0 {
+		if err := json.Unmarshal(run.Params, &params); err != nil {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "Job run parameters are unreadable", Code: "INVALID_JOB_PARAMS"})
+			return
+		}
+	}
+	if run.JobName == jobMonthlyZakat && params.MonthYear == "" {
+		params.MonthYear = run.ScheduledFor.Format("2006-01")
+	}
+
+	h.runAdminJob(c, run.JobName, database.JobTriggerRerun, run.ScheduledFor, run.ID, params)
+}
+
+// runAdminJob executes a job for an admin request and writes the response.
+// A job that ran but failed is reported with its run and partial result.
+func (h *Handler) runAdminJob(c *gin.Context, name, trigger string, scheduledFor time.Time, rerunOf string, params jobParams) {
+	ctx, cancel := context.WithTimeout(requestContext(c), adminJobTimeout)
+	defer cancel()
+
+	run, result, err := h.executeJob(ctx, name, trigger, scheduledFor, rerunOf, params)
+	switch {
+	case errors.Is(err, errJobRunning):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Job is already running", Code: "JOB_RUNNING"})
+		return
+	case err != nil:
+		h.logger.ErrorContext(ctx, "Failed to run job", "job", name, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to run job", Code: "JOB_ERROR"})
+		return
+	}
+
+	code, status, message := http.StatusOK, "success", "Job completed"
+	if run.Status == utils.JobFailed {
+		code, status, message = http.StatusInternalServerError, "error", "Job failed: "+run.Error
+	}
+	if params.DryRun {
+		message += " (dry run)"
+	}
+
+	c.JSON(code, SuccessResponse{
+		Status:  status,
+		Message: message,
+		Data: gin.H{
+			"run":    run,
+			"result": result,
+		},
+	})
+}
+
+// adminUser is the view of a user shown to admins, without key material
+type adminUser struct {
+	ID          string    `json:"id"`
+	Email       string    `json:"email"`
+	FullName    string    `json:"full_name"`
+	WalletID    string    `json:"wallet_id"`
+	Role        string    `json:"role"`
+	Tier        string    `json:"tier"`
+	IsVerified  bool      `json:"is_verified"`
+	CustodyMode string    `json:"custody_mode"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func newAdminUser(u *database.User) adminUser {
+	return adminUser{
+		ID:          u.ID,
+		Email:       u.Email,
+		FullName:    u.FullName,
+		WalletID:    u.WalletID,
+		Role:        u.Role,
+		Tier:        u.Tier,
+		IsVerified:  u.IsVerified,
+		CustodyMode: u.CustodyMode,
+		CreatedAt:   u.CreatedAt,
+	}
+}
+
+// GetUsersHandler lists users, optionally only those with ?role=
+func (h *Handler) GetUsersHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "limit must be between 1 and 500", Code: "INVALID_REQUEST"})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "offset must not be negative", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	users, err := h.db.GetUsers(ctx, c.Query("role"), limit, offset)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to get users", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get users", Code: "DATABASE_ERROR"})
+		return
+	}
+
+	views := make([]adminUser, 0, len(users))
+	for _, u := range users {
+		views = append(views, newAdminUser(u))
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Users retrieved",
+		Data: gin.H{
+			"users":  views,
+			"limit":  limit,
+			"offset": offset,
+			"count":  len(views),
+		},
+	})
+}
+
+// SetUserRoleRequest represents a role change
+type SetUserRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// SetUserRoleHandler promotes or demotes a user
+func (h *Handler) SetUserRoleHandler(c *gin.Context) {
+	var req SetUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	user, err := h.adminService.SetRole(ctx, c.GetString("user_id"), c.Param("id"), req.Role)
+	if err != nil {
+		h.adminError(c, "Failed to change role", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Role updated",
+		Data: gin.H{
+			"user": newAdminUser(user),
+		},
+	})
+}
+
+// SetUserTierRequest represents a limit tier change
+type SetUserTierRequest struct {
+	Tier string `json:"tier" binding:"required"`
+}
+
+// SetUserTierHandler moves a user to another transfer limit tier
+func (h *Handler) SetUserTierHandler(c *gin.Context) {
+	var req SetUserTierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	user, err := h.adminService.SetTier(ctx, c.GetString("user_id"), c.Param("id"), req.Tier)
+	if err != nil {
+		h.adminError(c, "Failed to change tier", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Tier updated",
+		Data: gin.H{
+			"user": newAdminUser(user),
+		},
+	})
+}
+
+// GetTransferLimitsHandler lists the transfer limits of every tier
+func (h *Handler) GetTransferLimitsHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	limits, err := h.db.GetTransferLimits(ctx)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to get transfer limits", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get transfer limits", Code: "DATABASE_ERROR"})
+		return
+	}
+	if limits == nil {
+		limits = []*database.TransferLimit{}
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Transfer limits retrieved",
+		Data: gin.H{
+			"limits": limits,
+		},
+	})
+}
+
+// SetTransferLimitsRequest represents a tier's limits; 0 means no limit
+type SetTransferLimitsRequest struct {
+	PerTransaction float64 `json:"per_transaction"`
+	Daily          float64 `json:"daily"`
+	Monthly        float64 `json:"monthly"`
+}
+
+// SetTransferLimitsHandler creates or replaces a tier's transfer limits
+func (h *Handler) SetTransferLimitsHandler(c *gin.Context) {
+	var req SetTransferLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	limits := &database.TransferLimit{
+		Tier:           utils.SanitizeInput(c.Param("tier")),
+		PerTransaction: req.PerTransaction,
+		Daily:          req.Daily,
+		Monthly:        req.Monthly,
+	}
+	if err := h.adminService.SaveLimits(ctx, c.GetString("user_id"), limits); err != nil {
+		h.adminError(c, "Failed to save transfer limits", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Transfer limits saved",
+		Data: gin.H{
+			"limits": limits,
+		},
+	})
+}
+
+// WalletStatusRequest carries the reason for freezing or unfreezing a wallet
+type WalletStatusRequest struct {
+	Reason string `json:"reason"`
+}
+
+// FreezeWalletHandler stops a wallet from sending funds
+func (h *Handler) FreezeWalletHandler(c *gin.Context) {
+	h.setWalletStatus(c, h.adminService.FreezeWallet, "Wallet frozen")
+}
+
+// UnfreezeWalletHandler lets a frozen wallet send funds again
+func (h *Handler) UnfreezeWalletHandler(c *gin.Context) {
+	h.setWalletStatus(c, h.adminService.UnfreezeWallet, "Wallet unfrozen")
+}
+
+// CloseWalletHandler permanently stops a wallet from sending or receiving
+func (h *Handler) CloseWalletHandler(c *gin.Context) {
+	h.setWalletStatus(c, h.adminService.CloseWallet, "Wallet closed")
+}
+
+func (h *Handler) setWalletStatus(c *gin.Context, set func(ctx context.Context, actorID, walletAddress, reason string) (*database.Wallet, error), message string) {
+	var req WalletStatusRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+			return
+		}
+	}
+
+	walletAddress := c.Param("address")
+	if !utils.ValidateWalletAddress(walletAddress) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid wallet address format", Code: "INVALID_WALLET"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	wallet, err := set(ctx, c.GetString("user_id"), walletAddress, utils.SanitizeInput(req.Reason))
+	if err != nil {
+		h.adminError(c, "Failed to update wallet status", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: message,
+		Data: gin.H{
+			"wallet": wallet,
+		},
+	})
+}
+
+// adminError maps admin service errors to API responses
+func (h *Handler) adminError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "INVALID_ROLE"})
+	case errors.Is(err, services.ErrOwnRole):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error(), Code: "OWN_ROLE"})
+	case errors.Is(err, services.ErrReasonRequired):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "REASON_REQUIRED"})
+	case errors.Is(err, services.ErrWalletClosed):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error(), Code: "WALLET_CLOSED"})
+	case errors.Is(err, services.ErrUnknownTier):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "UNKNOWN_TIER"})
+	case errors.Is(err, services.ErrInvalidLimit):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "INVALID_LIMIT"})
+	case errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrWalletNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error(), Code: "NOT_FOUND"})
+	default:
+		h.logger.ErrorContext(c.Request.Context(), message, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: message, Code: "ADMIN_ERROR"})
+	}
+}
+
+// GetConfigHandler returns the configuration the server is running with,
+// secrets redacted
+func (h *Handler) GetConfigHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Configuration retrieved",
+		Data: gin.H{
+			"config": h.config.Redacted(),
+		},
+	})
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"crypto-wallet-backend/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// isAdmin reports whether the authenticated caller holds the admin role. Like
+// RequireRole it confirms the token's role claim against the stored role, so
+// a demoted admin loses access before their token expires.
+func (h *Handler) isAdmin(ctx context.Context, c *gin.Context) (bool, error) {
+	if c.GetString("role") != database.RoleAdmin {
+		return false, nil
+	}
+
+	user, err := h.db.GetUserByID(ctx, c.GetString("user_id"))
+	if err != nil {
+		return false, err
+	}
+	return user != nil && user.Role == database.RoleAdmin, nil
+}
+
+// RequireRole allows only callers holding one of roles. It must run after
+// AuthMiddleware. The token's role claim is checked first and then confirmed
+// against the stored role, so a demotion takes effect before the token
+// expires.
+func (h *Handler) RequireRole(roles ...string) gin.HandlerFunc {
+	allowed := func(role string) bool {
+		for _, r := range roles {
+			if role == r {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(c *gin.Context) {
+		if !allowed(c.GetString("role")) {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Error: "Insufficient role", Code: "FORBIDDEN"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(requestContext(c), 5*time.Second)
+		defer cancel()
+
+		user, err := h.db.GetUserByID(ctx, c.GetString("user_id"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorResponse{Error: "Database error", Code: "DB_ERROR"})
+			return
+		}
+		if user == nil || !allowed(user.Role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Error: "Insufficient role", Code: "FORBIDDEN"})
+			return
+		}
+
+		c.Set("role", user.Role)
+		c.Next()
+	}
+}
+
+// authorizeWallet checks that the authenticated user owns walletAddress or
+// co-signs it as a multisig signer, and returns the wallet. Admins may access
+// any existing wallet. On failure the response is written and ok is
+// false.
+func (h *Handler) authorizeWallet(ctx context.Context, c *gin.Context, walletAddress string) (wallet *database.Wallet, ok bool) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Unauthorized", Code: "UNAUTHORIZED"})
+		return nil, false
+	}
+
+	admin, err := h.isAdmin(ctx, c)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to get user role", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Database error", Code: "DB_ERROR"})
+		return nil, false
+	}
+	if admin {
+		wallet, err := h.db.GetWalletByAddress(ctx, walletAddress)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "Failed to get wallet", "error", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Database error", Code: "DB_ERROR"})
+			return nil, false
+		}
+		if wallet == nil {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Wallet not found", Code: "NOT_FOUND"})
+			return nil, false
+		}
+		setRequestWallet(c, walletAddress)
+		return wallet, true
+	}
+
+	// Co-signers of a multisig wallet have the same access as its owner
+	wallet, err = h.db.GetWalletForUser(ctx, walletAddress, userID)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to get wallet", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Database error", Code: "DB_ERROR"})
+		return nil, false
+	}
+	if wallet != nil {
+		setRequestWallet(c, walletAddress)
+		return wallet, true
+	}
+
+	c.JSON(http.StatusForbidden, ErrorResponse{Error: "You do not have access to this wallet", Code: "FORBIDDEN"})
+	return nil, false
+}
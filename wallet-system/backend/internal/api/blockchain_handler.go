@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -9,6 +10,7 @@ import (
 
 	"crypto-wallet-backend/internal/blockchain"
 	"crypto-wallet-backend/internal/database"
+	"crypto-wallet-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -35,12 +37,12 @@ func (h *Handler) GetBlocksHandler(c *gin.Context) {
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
 	defer cancel()
 
 	blocks, err := h.db.GetBlocks(ctx, limit, offset)
 	if err != nil {
-		h.logger.Error("Failed to get blocks: %v", err)
+		h.logger.ErrorContext(ctx, "Failed to get blocks", "error", err)
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error(), Code: "DB_ERROR"})
 		return
 	}
@@ -86,12 +88,12 @@ func (h *Handler) GetBlockByHashHandler(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
 	defer cancel()
 
 	block, err := h.db.GetBlockByHash(ctx, hash)
 	if err != nil {
-		h.logger.Error("Failed to get block: %v", err)
+		h.logger.ErrorContext(ctx, "Failed to get block", "error", err)
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error(), Code: "DB_ERROR"})
 		return
 	}
@@ -137,13 +139,13 @@ func (h *Handler) MineBlockHandler(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second) // 2 min timeout for mining
+	ctx, cancel := context.WithTimeout(requestContext(c), time.Duration(h.config.Mining.Timeout))
 	defer cancel()
 
 	// Get pending transactions
-	pendingTxns, err := h.db.GetTransactionsByStatus(ctx, "pending", 10)
+	pendingTxns, err := h.db.GetTransactionsByStatus(ctx, "pending", h.config.Mining.MaxBlockTransactions)
 	if err != nil {
-		h.logger.Error("Failed to get pending transactions: %v", err)
+		h.logger.ErrorContext(ctx, "Failed to get pending transactions", "error", err)
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get transactions", Code: "TX_ERROR"})
 		return
 	}
@@ -169,26 +171,48 @@ func (h *Handler) MineBlockHandler(c *gin.Context) {
 		MinedBy:      req.MinerAddress,
 	}
 
-	// Add transactions to block (use ID as hash)
+	// Add transactions to block (use ID as hash), leaving time-locked ones in
+	// the pool until their lock time passes
 	txHashes := make([]string, 0)
+	var includedTxns []*database.Transaction
 	for _, tx := range pendingTxns {
-		newBlock.Transactions = append(newBlock.Transactions, blockchain.Transaction{
+		blockTx := blockchain.Transaction{
 			ID:             tx.TransactionHash,
 			SenderWallet:   tx.SenderWallet,
 			ReceiverWallet: tx.ReceiverWallet,
 			Amount:         tx.Amount,
 			Fee:            tx.Fee,
-		})
+			LockTime:       tx.LockTime,
+			LockUntil:      tx.LockUntil,
+		}
+		if !blockTx.IsFinal(newBlock.Index, newBlock.Timestamp) {
+			continue
+		}
+		newBlock.Transactions = append(newBlock.Transactions, blockTx)
 		txHashes = append(txHashes, tx.TransactionHash)
+		includedTxns = append(includedTxns, tx)
+	}
+
+	if len(includedTxns) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "All pending transactions are time-locked", Code: "NO_TRANSACTIONS"})
+		return
 	}
 
 	// Calculate merkle root
 	merkleRoot := blockchain.CalculateMerkleRoot(txHashes)
 	newBlock.MerkleRoot = merkleRoot
 
-	// Perform proof of work - call Mine() directly
-	pow := blockchain.NewProofOfWork(newBlock)
-	pow.Mine() // This sets block.Hash and block.Nonce
+	// Perform proof of work; this sets block.Hash and block.Nonce
+	pow, err := h.miningService.Mine(ctx, newBlock)
+	switch {
+	case errors.Is(err, services.ErrMiningStopped):
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "Server is shutting down", Message: err.Error(), Code: "SHUTTING_DOWN"})
+		return
+	case err != nil:
+		h.logger.ErrorContext(ctx, "Mining failed", "index", newBlock.Index, "error", err)
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "Mining timed out", Code: "MINING_TIMEOUT"})
+		return
+	}
 
 	// Save to database
 	dbBlock := &database.Block{
@@ -203,31 +227,50 @@ func (h *Handler) MineBlockHandler(c *gin.Context) {
 	}
 
 	if err := h.db.CreateBlock(ctx, dbBlock); err != nil {
-		h.logger.Error("Failed to save block: %v", err)
+		h.logger.ErrorContext(ctx, "Failed to save block", "error", err)
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to save block", Code: "DB_ERROR"})
 		return
 	}
 
 	// Update transactions to confirmed
-	for _, tx := range pendingTxns {
+	for _, tx := range includedTxns {
 		if err := h.db.UpdateTransactionStatus(ctx, tx.TransactionHash, "confirmed", newBlock.Hash); err != nil {
-			h.logger.Error("Failed to update transaction status: %v", err)
+			h.logger.ErrorContext(ctx, "Failed to update transaction status", "error", err)
 		}
 	}
 
 	// Add block to blockchain
-	h.bc.AddBlock(newBlock)
+	if err := h.bc.AddBlock(newBlock); err != nil {
+		h.logger.ErrorContext(ctx, "Failed to add block to chain", "error", err)
+	}
+	h.metrics.ObserveBlock("transactions", newBlock, latestBlock, pow)
 
 	// Mine reward: add UTXO to miner wallet
 	rewardUTXO := &database.UTXO{
 		TransactionHash: fmt.Sprintf("reward-%s-%d", newBlock.Hash, time.Now().Unix()),
 		OutputIndex:     0,
 		WalletAddress:   req.MinerAddress,
-		Amount:          5.0, // Mining reward
+		Amount:          h.config.Mining.Reward,
 		IsSpent:         false,
 	}
 	h.db.CreateUTXO(ctx, rewardUTXO)
 
+	_ = h.auditService.Record(ctx, services.AuditEvent{
+		Type:          database.LogBlockMined,
+		Message:       fmt.Sprintf("Block %d (%s) mined by %s with %d transactions", newBlock.Index, newBlock.Hash, newBlock.MinedBy, len(newBlock.Transactions)),
+		WalletAddress: req.MinerAddress,
+		Before:        gin.H{"index": latestBlock.Index, "hash": latestBlock.Hash},
+		After: gin.H{
+			"index":        newBlock.Index,
+			"hash":         newBlock.Hash,
+			"nonce":        newBlock.Nonce,
+			"difficulty":   newBlock.Difficulty,
+			"merkle_root":  newBlock.MerkleRoot,
+			"transactions": txHashes,
+			"reward":       rewardUTXO.Amount,
+		},
+	})
+
 	c.JSON(http.StatusOK, SuccessResponse{
 		Status:  "success",
 		Message: "Block mined successfully",
@@ -2,56 +2,177 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"crypto-wallet-backend/internal/blockchain"
 	"crypto-wallet-backend/internal/crypto"
 	"crypto-wallet-backend/internal/database"
+	"crypto-wallet-backend/internal/metrics"
 	"crypto-wallet-backend/internal/services"
 	"crypto-wallet-backend/internal/utils"
+	"crypto-wallet-backend/pkg/config"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 // Handler holds handler dependencies
 type Handler struct {
-	db                *database.Database
-	bc                *blockchain.Blockchain
-	walletService     *services.WalletService
-	zakatService      *services.ZakatService
-	miningService     *services.MiningService
-	transactionService *services.TransactionService
-	logger            *utils.Logger
-	jwtSecret         string
+	db                       *database.Database
+	bc                       *blockchain.Blockchain
+	walletService            *services.WalletService
+	zakatService             *services.ZakatService
+	miningService            *services.MiningService
+	transactionService       *services.TransactionService
+	signingService           *services.SigningService
+	challengeService         *services.ChallengeService
+	multisigService          *services.MultisigService
+	scheduledTransferService *services.ScheduledTransferService
+	adminService             *services.AdminService
+	kycService               *services.KYCService
+	twoFactorService         *services.TwoFactorService
+	twoFactorPolicy          services.TwoFactorPolicy
+	logger                   *slog.Logger
+	sessionService           *services.SessionService
+	loginGuard               *services.LoginGuard
+	auditService             *services.AuditService
+	rateLimits               rateLimits
+	metrics                  *metrics.Metrics
+	metricsToken             string
+	scheduler                *utils.Scheduler // set by RegisterTasks
+	shuttingDown             atomic.Bool
+	config                   *config.Config
 }
 
-// NewHandler creates a new handler
+// NewHandler creates a new handler. logger and m are shared with the
+// services.
 func NewHandler(
 	db *database.Database,
 	bc *blockchain.Blockchain,
-	jwtSecret string,
-) *Handler {
-	return &Handler{
-		db:                 db,
-		bc:                 bc,
-		walletService:      services.NewWalletService(db, bc),
-		zakatService:       services.NewZakatService(db),
-		miningService:      services.NewMiningService(db, bc),
-		transactionService: services.NewTransactionService(db),
-		logger:             utils.NewLogger("info"),
-		jwtSecret:          jwtSecret,
+	cfg *config.Config,
+	logger *slog.Logger,
+	m *metrics.Metrics,
+) (*Handler, error) {
+	transactionService := services.NewTransactionService(db, bc, logger, m)
+	signingService := services.NewSigningService(db)
+	nisab, err := services.NewNisabProvider(cfg.Zakat.NisabSource, cfg.Zakat.NisabAmount, cfg.Zakat.PriceFile)
+	if err != nil {
+		return nil, err
+	}
+	zakatService, err := services.NewZakatService(db, bc, transactionService, nisab, cfg.Zakat.PoolWallet, cfg.Zakat.Percentage, cfg.Zakat.SigningKey, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionService, err := services.NewSessionService(db, cfg.Auth.JWTKeys, cfg.Auth.JWTActiveKeyID,
+		time.Duration(cfg.Auth.AccessTokenTTL), time.Duration(cfg.Auth.RefreshTokenTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	var mailer utils.Mailer = utils.NewFileMailer(cfg.Mail.File, cfg.Mail.From, logger)
+	if cfg.Mail.SMTPHost != "" {
+		mailer = utils.NewSMTPMailer(cfg.Mail.SMTPHost, cfg.Mail.SMTPPort, cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword, cfg.Mail.From)
+	}
+	twoFactorKey := cfg.Auth.TwoFactorKey
+	if twoFactorKey == "" {
+		twoFactorKey = cfg.Auth.JWTSecret
 	}
+	twoFactorPolicy := services.TwoFactorPolicy{
+		RequireForLogin:   cfg.Auth.TwoFactorForLogin,
+		TransferThreshold: cfg.Auth.TwoFactorThreshold,
+	}
+
+	return &Handler{
+		db:                       db,
+		bc:                       bc,
+		walletService:            services.NewWalletService(db, bc),
+		zakatService:             zakatService,
+		miningService:            services.NewMiningService(db, bc, logger, m),
+		transactionService:       transactionService,
+		signingService:           signingService,
+		challengeService:         services.NewChallengeService(),
+		multisigService:          services.NewMultisigService(db, signingService, transactionService),
+		scheduledTransferService: services.NewScheduledTransferService(db, transactionService, logger),
+		adminService:             services.NewAdminService(db),
+		kycService:               services.NewKYCService(db, cfg.Server.KYCUploadDir),
+		twoFactorService:         services.NewTwoFactorService(db, mailer, cfg.Auth.TwoFactorIssuer, twoFactorKey, twoFactorPolicy),
+		twoFactorPolicy:          twoFactorPolicy,
+		logger:                   logger,
+		sessionService:           sessionService,
+		loginGuard: services.NewLoginGuard(db, services.LoginLockoutPolicy{
+			MaxFailures: cfg.Auth.LoginMaxFailures,
+			BaseLockout: time.Duration(cfg.Auth.LoginLockoutBase),
+			MaxLockout:  time.Duration(cfg.Auth.LoginLockoutMax),
+		}),
+		auditService: services.NewAuditService(db),
+		rateLimits:   newRateLimits(cfg.RateLimit),
+		metrics:      m,
+		metricsToken: cfg.Metrics.Token,
+		config:       cfg,
+	}, nil
+}
+
+// Shutdown prepares the handler for the server to drain: readiness checks
+// fail so no new traffic is routed here, and blocks being mined are
+// abandoned. Other requests in flight run to completion.
+func (h *Handler) Shutdown() {
+	h.shuttingDown.Store(true)
+	h.miningService.Stop()
 }
 
-// RegisterRequest represents a registration request
+// BootstrapAdmin promotes the user registered with email to admin if the
+// system has no admin yet
+func (h *Handler) BootstrapAdmin(ctx context.Context, email string) (bool, error) {
+	return h.adminService.BootstrapAdmin(ctx, email)
+}
+
+// RegisterRequest represents a registration request. Custodial users send a
+// Password; non-custodial users send a PublicKey and a signature over a
+// challenge from /api/auth/challenge instead.
 type RegisterRequest struct {
-	Email    string `json:"email" binding:"required"`
-	FullName string `json:"full_name" binding:"required"`
-	CNIC     string `json:"cnic" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Email              string `json:"email" binding:"required"`
+	FullName           string `json:"full_name" binding:"required"`
+	CNIC               string `json:"cnic" binding:"required"`
+	Password           string `json:"password"`
+	PublicKey          string `json:"public_key"`
+	Challenge          string `json:"challenge"`
+	ChallengeSignature string `json:"challenge_signature"`
+}
+
+// ChallengeRequest represents a request for a proof-of-possession challenge
+type ChallengeRequest struct {
+	PublicKey string `json:"public_key" binding:"required"`
+}
+
+// ChallengeHandler issues a challenge that the client signs with the private
+// key matching public_key, for non-custodial registration and login
+func (h *Handler) ChallengeHandler(c *gin.Context) {
+	var req ChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	challenge, expiresAt, err := h.challengeService.Issue(req.PublicKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to issue challenge", Code: "CHALLENGE_ERROR"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Challenge issued",
+		Data: gin.H{
+			"challenge":  challenge,
+			"expires_at": expiresAt,
+		},
+	})
 }
 
 // RegisterHandler handles user registration
@@ -68,17 +189,22 @@ func (h *Handler) RegisterHandler(c *gin.Context) {
 		return
 	}
 
-	if valid, msg := utils.ValidatePassword(req.Password); !valid {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: msg, Code: "WEAK_PASSWORD"})
-		return
+	nonCustodial := req.PublicKey != ""
+	if !nonCustodial {
+		if valid, msg := utils.ValidatePassword(req.Password); !valid {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: msg, Code: "WEAK_PASSWORD"})
+			return
+		}
 	}
 
-	if !utils.ValidateCNIC(req.CNIC) {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid CNIC format", Code: "INVALID_CNIC"})
+	cnic, err := utils.ParseCNIC(req.CNIC)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "INVALID_CNIC"})
 		return
 	}
+	req.CNIC = cnic.Number
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
 	defer cancel()
 
 	// Check if user exists
@@ -93,6 +219,26 @@ func (h *Handler) RegisterHandler(c *gin.Context) {
 		return
 	}
 
+	if nonCustodial {
+		user, wallet, ok := h.registerNonCustodial(ctx, c, &req)
+		if !ok {
+			return
+		}
+		h.recordRegistration(ctx, user, wallet)
+		c.JSON(http.StatusCreated, SuccessResponse{
+			Status:  "success",
+			Message: "User registered successfully",
+			Data: gin.H{
+				"user_id":        user.ID,
+				"email":          user.Email,
+				"wallet_id":      user.WalletID,
+				"wallet_address": wallet.WalletAddress,
+				"custody_mode":   user.CustodyMode,
+			},
+		})
+		return
+	}
+
 	// Generate key pair
 	keyPair, err := crypto.GenerateKeyPair()
 	if err != nil {
@@ -109,12 +255,12 @@ func (h *Handler) RegisterHandler(c *gin.Context) {
 
 	// Create user
 	user := &database.User{
-		Email:                req.Email,
-		FullName:             req.FullName,
-		CNIC:                 req.CNIC,
-		WalletID:             keyPair.WalletID,
-		PublicKey:            keyPair.PublicKey,
-		EncryptedPrivateKey:  encryptedPrivateKey,
+		Email:               req.Email,
+		FullName:            req.FullName,
+		CNIC:                req.CNIC,
+		WalletID:            keyPair.WalletID,
+		PublicKey:           keyPair.PublicKey,
+		EncryptedPrivateKey: encryptedPrivateKey,
 	}
 
 	if err := h.db.CreateUser(ctx, user); err != nil {
@@ -128,23 +274,91 @@ func (h *Handler) RegisterHandler(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create wallet", Code: "WALLET_CREATE_ERROR"})
 		return
 	}
+	h.recordRegistration(ctx, user, wallet)
 
 	c.JSON(http.StatusCreated, SuccessResponse{
 		Status:  "success",
 		Message: "User registered successfully",
 		Data: gin.H{
-			"user_id":       user.ID,
-			"email":         user.Email,
-			"wallet_id":     keyPair.WalletID,
+			"user_id":        user.ID,
+			"email":          user.Email,
+			"wallet_id":      keyPair.WalletID,
 			"wallet_address": wallet.WalletAddress,
 		},
 	})
 }
 
-// LoginRequest represents a login request
+// registerNonCustodial creates a user that holds its own private key. The
+// client proves possession of the key by signing a server challenge, and the
+// wallet address is derived from the public key. On failure the response is
+// written and ok is false.
+func (h *Handler) registerNonCustodial(ctx context.Context, c *gin.Context, req *RegisterRequest) (*database.User, *database.Wallet, bool) {
+	if err := h.challengeService.Verify(req.PublicKey, req.Challenge, req.ChallengeSignature); err != nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid proof of possession", Code: "INVALID_CHALLENGE"})
+		return nil, nil, false
+	}
+
+	walletID := crypto.GenerateWalletID(req.PublicKey)
+	existing, err := h.db.GetUserByWalletID(ctx, walletID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Database error", Code: "DB_ERROR"})
+		return nil, nil, false
+	}
+	if existing != nil {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "Public key already registered", Code: "PUBLIC_KEY_EXISTS"})
+		return nil, nil, false
+	}
+
+	user := &database.User{
+		Email:       req.Email,
+		FullName:    req.FullName,
+		CNIC:        req.CNIC,
+		WalletID:    walletID,
+		PublicKey:   req.PublicKey,
+		CustodyMode: database.CustodyNonCustodial,
+	}
+
+	if err := h.db.CreateUser(ctx, user); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create user", Code: "USER_CREATE_ERROR"})
+		return nil, nil, false
+	}
+
+	wallet, err := h.walletService.CreateWalletForAddress(ctx, user.ID, walletID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create wallet", Code: "WALLET_CREATE_ERROR"})
+		return nil, nil, false
+	}
+
+	return user, wallet, true
+}
+
+// recordRegistration audits a new user and wallet
+func (h *Handler) recordRegistration(ctx context.Context, user *database.User, wallet *database.Wallet) {
+	custodyMode := user.CustodyMode
+	if custodyMode == "" {
+		custodyMode = database.CustodyCustodial
+	}
+	_ = h.auditService.Record(ctx, services.AuditEvent{
+		Type:          database.LogUserRegistered,
+		Message:       fmt.Sprintf("User %s registered with wallet %s", user.ID, wallet.WalletAddress),
+		ActorID:       user.ID,
+		WalletAddress: wallet.WalletAddress,
+		After: gin.H{
+			"user_id":      user.ID,
+			"email":        user.Email,
+			"wallet_id":    user.WalletID,
+			"custody_mode": custodyMode,
+		},
+	})
+}
+
+// LoginRequest represents a login request. Non-custodial users authenticate
+// with a signed challenge instead of a password.
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Email              string `json:"email" binding:"required"`
+	Password           string `json:"password"`
+	Challenge          string `json:"challenge"`
+	ChallengeSignature string `json:"challenge_signature"`
 }
 
 // LoginHandler handles user login
@@ -155,9 +369,14 @@ func (h *Handler) LoginHandler(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
 	defer cancel()
 
+	// Locked addresses are refused before any credential is checked
+	if h.checkLoginLockout(ctx, c, req.Email) {
+		return
+	}
+
 	// Get user
 	user, err := h.db.GetUserByEmail(ctx, req.Email)
 	if err != nil {
@@ -166,26 +385,47 @@ func (h *Handler) LoginHandler(c *gin.Context) {
 	}
 
 	if user == nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid credentials", Code: "INVALID_CREDENTIALS"})
+		h.loginFailed(ctx, c, req.Email, nil, "unknown email")
 		return
 	}
 
-	// Decrypt private key to verify password
-	_, err = crypto.DecryptPrivateKey(user.EncryptedPrivateKey, req.Password)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid credentials", Code: "INVALID_CREDENTIALS"})
+	if user.IsNonCustodial() {
+		// Verify proof of possession of the registered key
+		if err := h.challengeService.Verify(user.PublicKey, req.Challenge, req.ChallengeSignature); err != nil {
+			h.loginFailed(ctx, c, req.Email, user, "invalid challenge signature")
+			return
+		}
+	} else {
+		// Decrypt private key to verify password
+		_, err = crypto.DecryptPrivateKey(user.EncryptedPrivateKey, req.Password)
+		if err != nil {
+			h.loginFailed(ctx, c, req.Email, user, "wrong password")
+			return
+		}
+	}
+
+	if h.requireLoginFactor(ctx, c, user) {
 		return
 	}
 
-	// Generate JWT token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":   user.ID,
-		"email": user.Email,
-		"exp":   time.Now().Add(7 * 24 * time.Hour).Unix(),
-	})
+	h.respondWithSession(c, user)
+}
+
+// respondWithSession starts a session for a fully authenticated user and
+// returns its tokens. Failed logins are only forgotten here, once every
+// factor has passed, so a known password cannot reset the count of wrong
+// second-factor codes.
+func (h *Handler) respondWithSession(c *gin.Context, user *database.User) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	if err := h.loginGuard.Succeed(ctx, user); err != nil {
+		h.logger.ErrorContext(ctx, "Failed to clear failed logins", "error", err)
+	}
 
-	tokenString, err := token.SignedString([]byte(h.jwtSecret))
+	tokens, err := h.sessionService.CreateSession(ctx, user, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to create session", "error", err)
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to generate token", Code: "TOKEN_ERROR"})
 		return
 	}
@@ -193,17 +433,14 @@ func (h *Handler) LoginHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, SuccessResponse{
 		Status:  "success",
 		Message: "Login successful",
-		Data: gin.H{
-			"token":   tokenString,
-			"user_id": user.ID,
-		},
+		Data:    tokens,
 	})
 }
 
 // GetWalletHandler returns wallet information
 func (h *Handler) GetWalletHandler(c *gin.Context) {
 	userID := c.GetString("user_id")
-	
+
 	// If no user_id in context (no auth), get from query or use first registered user (dev mode)
 	if userID == "" {
 		// For testing: accept user_id from query param
@@ -214,7 +451,7 @@ func (h *Handler) GetWalletHandler(c *gin.Context) {
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
 	defer cancel()
 
 	// Get wallets for this user
@@ -225,13 +462,13 @@ func (h *Handler) GetWalletHandler(c *gin.Context) {
 	}
 
 	wallet := wallets[0]
-	
+
 	// Calculate balance from UTXOs
 	utxos, err := h.db.GetUTXOsByWallet(ctx, wallet.WalletAddress)
 	if err != nil {
 		utxos = []*database.UTXO{}
 	}
-	
+
 	balance := 0.0
 	for _, utxo := range utxos {
 		if !utxo.IsSpent {
@@ -257,6 +494,32 @@ func (h *Handler) GetWalletHandler(c *gin.Context) {
 	})
 }
 
+// GetTransferLimitsUsageHandler returns the caller's transfer limits and how
+// much of them has been used today and this month
+func (h *Handler) GetTransferLimitsUsageHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	usage, err := h.transactionService.GetTransferUsage(ctx, c.GetString("user_id"))
+	if errors.Is(err, services.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "User not found", Code: "NOT_FOUND"})
+		return
+	}
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to get transfer usage", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get transfer limits", Code: "DB_ERROR"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Transfer limits retrieved",
+		Data: gin.H{
+			"usage": usage,
+		},
+	})
+}
+
 // GetBalanceRequest represents a balance request
 type GetBalanceRequest struct {
 	WalletAddress string `json:"wallet_address" binding:"required"`
@@ -275,9 +538,13 @@ func (h *Handler) GetBalanceHandler(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
 	defer cancel()
 
+	if _, ok := h.authorizeWallet(ctx, c, req.WalletAddress); !ok {
+		return
+	}
+
 	balance, err := h.walletService.GetWalletBalance(ctx, req.WalletAddress)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to get balance", Code: "BALANCE_ERROR"})
@@ -294,6 +561,53 @@ func (h *Handler) GetBalanceHandler(c *gin.Context) {
 	})
 }
 
+// UnlockWalletRequest represents a request to unlock the custodial signing key
+type UnlockWalletRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// UnlockWalletHandler exchanges the user's password for a short-lived unlock
+// token that can sign transfers without resending the password
+func (h *Handler) UnlockWalletHandler(c *gin.Context) {
+	var req UnlockWalletRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	if h.checkLoginLockout(ctx, c, c.GetString("email")) {
+		return
+	}
+
+	token, expiresAt, err := h.signingService.Unlock(ctx, c.GetString("user_id"), req.Password)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPassword) {
+			h.signingPasswordFailed(ctx, c, "wrong password to unlock wallet")
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid credentials", Code: "INVALID_CREDENTIALS"})
+			return
+		}
+		if errors.Is(err, services.ErrNonCustodialWallet) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "NON_CUSTODIAL_WALLET"})
+			return
+		}
+		h.logger.ErrorContext(ctx, "Failed to unlock wallet", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to unlock wallet", Code: "UNLOCK_ERROR"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Wallet unlocked",
+		Data: gin.H{
+			"unlock_token": token,
+			"expires_at":   expiresAt,
+		},
+	})
+}
+
 // GetSystemLogsHandler retrieves system logs with optional filtering
 func (h *Handler) GetSystemLogsHandler(c *gin.Context) {
 	logType := c.DefaultQuery("type", "ALL")
@@ -315,7 +629,7 @@ func (h *Handler) GetSystemLogsHandler(c *gin.Context) {
 
 	logs, err := h.db.GetSystemLogs(c.Request.Context(), logType, limit, offset)
 	if err != nil {
-		h.logger.Error("Failed to get system logs", err)
+		h.logger.ErrorContext(c.Request.Context(), "Failed to get system logs", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": "Failed to retrieve system logs",
@@ -338,7 +652,7 @@ func (h *Handler) GetSystemLogsHandler(c *gin.Context) {
 func (h *Handler) GetSystemLogStatsHandler(c *gin.Context) {
 	stats, err := h.db.GetSystemLogStats(c.Request.Context())
 	if err != nil {
-		h.logger.Error("Failed to get system log stats", err)
+		h.logger.ErrorContext(c.Request.Context(), "Failed to get system log stats", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status":  "error",
 			"message": "Failed to retrieve log statistics",
@@ -352,27 +666,27 @@ func (h *Handler) GetSystemLogStatsHandler(c *gin.Context) {
 	})
 }
 
-// GetSystemHealthHandler returns system health status
-func (h *Handler) GetSystemHealthHandler(c *gin.Context) {
-	ctx := c.Request.Context()
-	if err := h.db.Ping(ctx); err != nil {
-		h.logger.Error("Database health check failed", err)
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"status": "error",
-			"health": gin.H{
-				"database": "down",
-				"api":      "up",
-			},
+// VerifyAuditLogHandler walks the hash-chained audit log and reports the
+// first broken link, if any
+func (h *Handler) VerifyAuditLogHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 5*time.Minute)
+	defer cancel()
+
+	result, err := h.auditService.Verify(ctx)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to verify audit log", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status":  "error",
+			"message": "Failed to verify audit log",
 		})
 		return
 	}
+	if !result.Valid {
+		h.logger.WarnContext(ctx, "Audit log broken", "sequence", result.FirstBreak.Sequence, "reason", result.FirstBreak.Reason)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
-		"health": gin.H{
-			"database":   "up",
-			"api":        "up",
-			"blockchain": "up",
-		},
+		"data":   result,
 	})
 }
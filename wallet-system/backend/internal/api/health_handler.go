@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Component health states, from best to worst. A degraded component is
+// reported but does not make the node unready.
+const (
+	healthUp       = "up"
+	healthDegraded = "degraded"
+	healthDown     = "down"
+)
+
+const (
+	// healthCheckTimeout bounds the database queries of a readiness check
+	healthCheckTimeout = 3 * time.Second
+	// slowDatabaseLatency marks the database degraded
+	slowDatabaseLatency = 250 * time.Millisecond
+	// chainCheckWindow is how many recent blocks each check validates
+	chainCheckWindow = 50
+	// schedulerStaleAfter marks the scheduler down; it checks for due jobs
+	// at least once a minute
+	schedulerStaleAfter = 3 * time.Minute
+)
+
+// ComponentHealth is the outcome of one readiness check
+type ComponentHealth struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms,omitempty"`
+	Message   string  `json:"message,omitempty"`
+	Details   gin.H   `json:"details,omitempty"`
+}
+
+// HealthReport is the readiness of the node and each of its components
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components"`
+	CheckedAt  time.Time                  `json:"checked_at"`
+}
+
+// LivenessHandler reports that the process is serving requests. It checks
+// nothing else, so a slow dependency never gets the node restarted.
+func (h *Handler) LivenessHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// ReadinessHandler reports whether the node should receive traffic, with a
+// report per component. It answers 503 if any component is down.
+func (h *Handler) ReadinessHandler(c *gin.Context) {
+	report := h.checkHealth(c.Request.Context())
+	code := http.StatusOK
+	if report.Status == healthDown {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, report)
+}
+
+// GetSystemHealthHandler returns the readiness report to staff
+func (h *Handler) GetSystemHealthHandler(c *gin.Context) {
+	report := h.checkHealth(c.Request.Context())
+	if report.Status == healthDown {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "error", "health": report})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "health": report})
+}
+
+// checkHealth runs every readiness check. The node takes the worst status
+// of its components.
+func (h *Handler) checkHealth(ctx context.Context) HealthReport {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	report := HealthReport{
+		Status: healthUp,
+		Components: map[string]ComponentHealth{
+			"server":    h.checkServer(),
+			"database":  h.checkDatabase(ctx),
+			"chain":     h.checkChain(),
+			"sync":      h.checkSync(ctx),
+			"scheduler": h.checkScheduler(),
+		},
+		CheckedAt: time.Now(),
+	}
+	for name, component := range report.Components {
+		if healthRank(component.Status) > healthRank(report.Status) {
+			report.Status = component.Status
+		}
+		if component.Status == healthDown {
+			h.logger.WarnContext(ctx, "Health check failed", "component", name, "reason", component.Message)
+		}
+	}
+	return report
+}
+
+func healthRank(status string) int {
+	switch status {
+	case healthDown:
+		return 2
+	case healthDegraded:
+		return 1
+	}
+	return 0
+}
+
+// checkServer reports whether the server is shutting down
+func (h *Handler) checkServer() ComponentHealth {
+	if h.shuttingDown.Load() {
+		return ComponentHealth{Status: healthDown, Message: "server is shutting down"}
+	}
+	return ComponentHealth{Status: healthUp}
+}
+
+// checkDatabase pings the database, timing the round trip
+func (h *Handler) checkDatabase(ctx context.Context) ComponentHealth {
+	start := time.Now()
+	err := h.db.Ping(ctx)
+	latency := time.Since(start)
+	result := ComponentHealth{Status: healthUp, LatencyMS: float64(latency.Microseconds()) / 1000}
+
+	switch {
+	case err != nil:
+		result.Status, result.Message = healthDown, "database unreachable"
+	case latency > slowDatabaseLatency:
+		result.Status, result.Message = healthDegraded, "database is slow"
+	}
+
+	stats := h.db.Stats()
+	result.Details = gin.H{"open_connections": stats.OpenConnections, "in_use": stats.InUse, "wait_count": stats.WaitCount}
+	return result
+}
+
+// checkChain validates the links and proof of work of the latest blocks
+func (h *Handler) checkChain() ComponentHealth {
+	result := ComponentHealth{
+		Status:  healthUp,
+		Details: gin.H{"height": h.bc.Height(), "validated_blocks": chainCheckWindow},
+	}
+	if h.bc.GetLatestBlock() == nil {
+		result.Status, result.Message = healthDown, "chain has no genesis block"
+	} else if !h.bc.ValidateRecent(chainCheckWindow) {
+		result.Status, result.Message = healthDown, "recent blocks fail validation"
+	}
+	return result
+}
+
+// checkSync compares the tip of the in-memory chain with the latest block
+// stored in the database. The in-memory chain starts from a fresh genesis
+// block on each start, so after a restart it falls behind the blocks mined
+// before; that degrades the node without making it unready.
+func (h *Handler) checkSync(ctx context.Context) ComponentHealth {
+	blocks, err := h.db.GetBlocks(ctx, 1, 0)
+	if err != nil {
+		return ComponentHealth{Status: healthDown, Message: "failed to read the stored chain tip"}
+	}
+
+	memoryTip := h.bc.GetLatestBlock()
+	if memoryTip == nil {
+		return ComponentHealth{Status: healthDown, Message: "chain has no genesis block"}
+	}
+	details := gin.H{"memory_height": memoryTip.Index, "memory_hash": memoryTip.Hash}
+
+	// Only blocks mined after genesis are stored
+	if len(blocks) == 0 {
+		details["db_height"] = nil
+		if memoryTip.Index != 0 {
+			return ComponentHealth{Status: healthDegraded, Message: "in-memory blocks are missing from the database", Details: details}
+		}
+		return ComponentHealth{Status: healthUp, Details: details}
+	}
+
+	dbTip := blocks[0]
+	details["db_height"], details["db_hash"] = dbTip.BlockIndex, dbTip.Hash
+	details["lag"] = dbTip.BlockIndex - memoryTip.Index
+	switch {
+	case dbTip.Hash == memoryTip.Hash:
+		return ComponentHealth{Status: healthUp, Details: details}
+	case dbTip.BlockIndex > memoryTip.Index:
+		return ComponentHealth{Status: healthDegraded, Message: "in-memory chain is behind the database", Details: details}
+	default:
+		return ComponentHealth{Status: healthDegraded, Message: "in-memory chain has diverged from the database", Details: details}
+	}
+}
+
+// checkScheduler checks that background jobs are still being scheduled
+func (h *Handler) checkScheduler() ComponentHealth {
+	if h.scheduler == nil {
+		return ComponentHealth{Status: healthDown, Message: "scheduler not configured"}
+	}
+
+	heartbeat := h.scheduler.Heartbeat()
+	if heartbeat.IsZero() {
+		return ComponentHealth{Status: healthDown, Message: "scheduler is not running"}
+	}
+	age := time.Since(heartbeat)
+	result := ComponentHealth{
+		Status:  healthUp,
+		Details: gin.H{"last_heartbeat": heartbeat, "age_seconds": int(age.Seconds())},
+	}
+	if age > schedulerStaleAfter {
+		result.Status, result.Message = healthDown, "scheduler heartbeat is stale"
+	}
+	return result
+}
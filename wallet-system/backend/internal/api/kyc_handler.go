@@ -0,0 +1,239 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"crypto-wallet-backend/internal/database"
+	"crypto-wallet-backend/internal/services"
+	"crypto-wallet-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// kycMaxRequestBytes bounds a whole verification upload: three documents and
+// the form fields
+const kycMaxRequestBytes = 3*services.KYCMaxDocumentBytes + 1<<20
+
+// kycDocumentFields are the multipart file fields a submission may carry
+var kycDocumentFields = []string{"cnic_front", "cnic_back", "selfie"}
+
+// SubmitKYCHandler accepts a multipart verification request: cnic,
+// full_name, father_name, date_of_birth and gender fields with cnic_front,
+// cnic_back and optional selfie files
+func (h *Handler) SubmitKYCHandler(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, kycMaxRequestBytes)
+	form, err := c.MultipartForm()
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: "Upload is too large", Code: "DOCUMENT_TOO_LARGE"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Expected a multipart form", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	req := services.KYCRequest{
+		CNIC:        c.PostForm("cnic"),
+		FullName:    c.PostForm("full_name"),
+		FatherName:  c.PostForm("father_name"),
+		DateOfBirth: c.PostForm("date_of_birth"),
+		Gender:      c.PostForm("gender"),
+	}
+	for _, field := range kycDocumentFields {
+		headers := form.File[field]
+		if len(headers) == 0 {
+			continue
+		}
+		if len(headers) > 1 {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Only one " + field + " file may be uploaded", Code: "INVALID_KYC"})
+			return
+		}
+		file, err := headers[0].Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Failed to read " + field, Code: "INVALID_REQUEST"})
+			return
+		}
+		defer file.Close()
+		req.Documents = append(req.Documents, services.KYCUpload{Kind: field, Reader: file})
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 30*time.Second)
+	defer cancel()
+
+	sub, err := h.kycService.Submit(ctx, c.GetString("user_id"), req)
+	if err != nil {
+		h.kycError(c, "Failed to submit verification request", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Status:  "success",
+		Message: "Verification request submitted for review",
+		Data:    sub,
+	})
+}
+
+// GetKYCStatusHandler returns the user's verification state and latest request
+func (h *Handler) GetKYCStatusHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	status, err := h.kycService.GetStatus(ctx, c.GetString("user_id"))
+	if err != nil {
+		h.kycError(c, "Failed to get verification status", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Verification status retrieved",
+		Data:    status,
+	})
+}
+
+// GetKYCSubmissionsHandler lists verification requests for review, oldest
+// first. The status filter defaults to pending; pass status=all for every
+// request.
+func (h *Handler) GetKYCSubmissionsHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "limit must be between 1 and 500", Code: "INVALID_REQUEST"})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "offset must not be negative", Code: "INVALID_REQUEST"})
+		return
+	}
+	status := c.DefaultQuery("status", "pending")
+	if status == "all" {
+		status = ""
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	subs, err := h.kycService.GetSubmissions(ctx, status, limit, offset)
+	if err != nil {
+		h.kycError(c, "Failed to get verification requests", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Verification requests retrieved",
+		Data: gin.H{
+			"submissions": subs,
+			"limit":       limit,
+			"offset":      offset,
+			"count":       len(subs),
+		},
+	})
+}
+
+// GetKYCSubmissionHandler returns a verification request with its documents
+func (h *Handler) GetKYCSubmissionHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	sub, err := h.kycService.GetSubmission(ctx, c.Param("id"))
+	if err != nil {
+		h.kycError(c, "Failed to get verification request", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Verification request retrieved",
+		Data:    sub,
+	})
+}
+
+// GetKYCDocumentHandler streams an uploaded document to a reviewer
+func (h *Handler) GetKYCDocumentHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	doc, path, err := h.kycService.GetDocument(ctx, c.Param("id"), c.Param("doc_id"))
+	if err != nil {
+		h.kycError(c, "Failed to get document", err)
+		return
+	}
+
+	c.Header("Content-Type", doc.ContentType)
+	c.Header("Cache-Control", "no-store")
+	c.File(path)
+}
+
+// ReviewKYCRequest carries a reviewer's notes
+type ReviewKYCRequest struct {
+	Notes string `json:"notes"`
+}
+
+// ApproveKYCHandler approves a pending request and verifies its user
+func (h *Handler) ApproveKYCHandler(c *gin.Context) {
+	h.reviewKYC(c, h.kycService.Approve, "Verification request approved")
+}
+
+// RejectKYCHandler rejects a pending request; notes are required
+func (h *Handler) RejectKYCHandler(c *gin.Context) {
+	h.reviewKYC(c, h.kycService.Reject, "Verification request rejected")
+}
+
+func (h *Handler) reviewKYC(c *gin.Context, review func(ctx context.Context, reviewerID, id, notes string) (*database.KYCSubmission, error), message string) {
+	var req ReviewKYCRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	sub, err := review(ctx, c.GetString("user_id"), c.Param("id"), utils.SanitizeInput(req.Notes))
+	if err != nil {
+		h.kycError(c, "Failed to review verification request", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: message,
+		Data:    sub,
+	})
+}
+
+func (h *Handler) kycError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidKYC):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "INVALID_KYC"})
+	case errors.Is(err, services.ErrCNICMismatch):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "CNIC_MISMATCH"})
+	case errors.Is(err, services.ErrInvalidDocument):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "INVALID_DOCUMENT"})
+	case errors.Is(err, services.ErrDocumentTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: err.Error(), Code: "DOCUMENT_TOO_LARGE"})
+	case errors.Is(err, services.ErrReasonRequired):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "notes are required when rejecting", Code: "REASON_REQUIRED"})
+	case errors.Is(err, services.ErrAlreadyVerified):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error(), Code: "ALREADY_VERIFIED"})
+	case errors.Is(err, services.ErrKYCPending):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error(), Code: "KYC_PENDING"})
+	case errors.Is(err, services.ErrKYCNotPending):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error(), Code: "KYC_NOT_PENDING"})
+	case errors.Is(err, services.ErrOwnSubmission):
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error(), Code: "OWN_SUBMISSION"})
+	case errors.Is(err, services.ErrKYCNotFound), errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error(), Code: "NOT_FOUND"})
+	default:
+		h.logger.ErrorContext(c.Request.Context(), message, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: message, Code: "KYC_ERROR"})
+	}
+}
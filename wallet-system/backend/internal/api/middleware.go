@@ -1,15 +1,26 @@
 package api
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
+
+	"crypto-wallet-backend/internal/metrics"
+	"crypto-wallet-backend/internal/services"
+	"crypto-wallet-backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-// AuthMiddleware validates JWT tokens
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// AuthMiddleware validates access tokens and that their session has not
+// been revoked
+func AuthMiddleware(sessions *services.SessionService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -25,21 +36,150 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.Parse(parts[1], func(token *jwt.Token) (interface{}, error) {
-			return []byte(jwtSecret), nil
-		})
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+		defer cancel()
 
-		if err != nil || !token.Valid {
+		claims, err := sessions.Authenticate(ctx, parts[1])
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
 
-		claims := token.Claims.(jwt.MapClaims)
 		c.Set("user_id", claims["sub"])
 		c.Set("email", claims["email"])
+		c.Set("role", claims["role"])
+		c.Set("session_id", claims["sid"])
+
+		info := utils.RequestInfoFrom(c.Request.Context())
+		info.ActorID, _ = claims["sub"].(string)
+		c.Request = c.Request.WithContext(utils.WithRequestInfo(c.Request.Context(), info))
+
+		c.Next()
+	}
+}
+
+// requestIDHeader carries the ID that ties a request to its logs
+const requestIDHeader = "X-Request-ID"
+
+// RequestContextMiddleware gives each request an ID, reusing a sane one
+// sent by the client or a proxy, echoes it back and records it with the
+// client address and user agent in the request context
+func RequestContextMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if !validRequestID(requestID) {
+			b := make([]byte, 16)
+			_, _ = rand.Read(b)
+			requestID = hex.EncodeToString(b)
+		}
+		c.Header(requestIDHeader, requestID)
+		c.Set("request_id", requestID)
+
+		c.Request = c.Request.WithContext(utils.WithRequestInfo(c.Request.Context(), utils.RequestInfo{
+			RequestID: requestID,
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		}))
+
+		c.Next()
+	}
+}
+
+// validRequestID accepts IDs of up to 64 letters, digits, dashes and
+// underscores, so they are safe to store and log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// requestContext returns a context carrying the request's details but not
+// its cancellation, so work a handler starts is not cut short if the client
+// goes away
+func requestContext(c *gin.Context) context.Context {
+	return context.WithoutCancel(c.Request.Context())
+}
 
+// setRequestWallet records the wallet a request acts on, so the request's
+// log line names it
+func setRequestWallet(c *gin.Context, walletAddress string) {
+	info := utils.RequestInfoFrom(c.Request.Context())
+	info.WalletAddress = walletAddress
+	c.Request = c.Request.WithContext(utils.WithRequestInfo(c.Request.Context(), info))
+}
+
+// RequestLogMiddleware logs each request once it completes, at warn level
+// for client errors and error level for server errors
+func RequestLogMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", path),
+			slog.Int("status", status),
+			slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+		logger.LogAttrs(c.Request.Context(), level, "Request completed", attrs...)
+	}
+}
+
+// MetricsMiddleware counts requests and their latency per route and status.
+// Requests matching no route share the "unmatched" route, so scanners
+// cannot inflate the number of series.
+func MetricsMiddleware(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
 		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.HTTPRequests.Inc(c.Request.Method, route, strconv.Itoa(c.Writer.Status()))
+		m.HTTPDuration.Observe(time.Since(start).Seconds(), c.Request.Method, route)
+	}
+}
+
+// metricsHandler serves Prometheus scrapes, requiring the configured bearer
+// token if there is one
+func (h *Handler) metricsHandler() gin.HandlerFunc {
+	serve := gin.WrapH(h.metrics.Handler())
+	return func(c *gin.Context) {
+		if h.metricsToken != "" {
+			token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(h.metricsToken)) != 1 {
+				c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid metrics token", Code: "UNAUTHORIZED"})
+				return
+			}
+		}
+		serve(c)
 	}
 }
 
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"crypto-wallet-backend/internal/blockchain"
+	"crypto-wallet-backend/internal/database"
+	"crypto-wallet-backend/internal/services"
+	"crypto-wallet-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateMultisigRequest represents a request to create an M-of-N wallet
+type CreateMultisigRequest struct {
+	Threshold       int      `json:"threshold" binding:"required"`
+	SignerWalletIDs []string `json:"signer_wallet_ids" binding:"required"`
+}
+
+// CreateMultisigHandler creates a multisig wallet co-signed by the caller and
+// the users owning signer_wallet_ids
+func (h *Handler) CreateMultisigHandler(c *gin.Context) {
+	var req CreateMultisigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	mw, err := h.multisigService.CreateWallet(ctx, c.GetString("user_id"), req.Threshold, req.SignerWalletIDs)
+	if err != nil {
+		h.multisigError(c, "Failed to create multisig wallet", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Status:  "success",
+		Message: "Multisig wallet created",
+		Data: gin.H{
+			"wallet": mw,
+		},
+	})
+}
+
+// GetMultisigWalletsHandler lists the multisig wallets the caller co-signs
+func (h *Handler) GetMultisigWalletsHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	addresses, err := h.db.GetMultisigWalletAddressesBySigner(ctx, c.GetString("user_id"))
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to get multisig wallets", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Database error", Code: "DB_ERROR"})
+		return
+	}
+
+	wallets := []*database.MultisigWallet{}
+	for _, address := range addresses {
+		mw, err := h.db.GetMultisigWallet(ctx, address)
+		if err != nil {
+			h.logger.ErrorContext(ctx, "Failed to get multisig wallet", "wallet", address, "error", err)
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Database error", Code: "DB_ERROR"})
+			return
+		}
+		if mw != nil {
+			wallets = append(wallets, mw)
+		}
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Multisig wallets retrieved",
+		Data: gin.H{
+			"wallets": wallets,
+			"count":   len(wallets),
+		},
+	})
+}
+
+// ProposeMultisigRequest represents a proposed spend from a multisig wallet
+type ProposeMultisigRequest struct {
+	WalletAddress  string  `json:"wallet_address" binding:"required"`
+	ReceiverWallet string  `json:"receiver_wallet" binding:"required"`
+	Amount         float64 `json:"amount" binding:"required"`
+	Fee            float64 `json:"fee"`
+	Note           string  `json:"note"`
+}
+
+// ProposeMultisigHandler proposes a spend that co-signers then approve
+func (h *Handler) ProposeMultisigHandler(c *gin.Context) {
+	var req ProposeMultisigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	if valid, msg := utils.ValidateAmount(req.Amount); !valid {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: msg, Code: "INVALID_AMOUNT"})
+		return
+	}
+	if valid, msg := utils.ValidateFee(req.Fee); !valid {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: msg, Code: "INVALID_FEE"})
+		return
+	}
+	if !utils.ValidateWalletAddress(req.ReceiverWallet) || req.ReceiverWallet == req.WalletAddress {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid receiver wallet", Code: "INVALID_WALLET"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	proposal, err := h.multisigService.Propose(ctx, c.GetString("user_id"), req.WalletAddress, req.ReceiverWallet, req.Amount, req.Fee, req.Note)
+	if err != nil {
+		h.multisigError(c, "Failed to create proposal", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Status:  "success",
+		Message: "Proposal created",
+		Data: gin.H{
+			"proposal": proposal,
+		},
+	})
+}
+
+// GetMultisigProposalsHandler lists proposals for a multisig wallet
+func (h *Handler) GetMultisigProposalsHandler(c *gin.Context) {
+	walletAddress := c.Query("wallet_address")
+	if walletAddress == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "wallet_address is required", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	mw, err := h.multisigService.GetWallet(ctx, c.GetString("user_id"), walletAddress)
+	if err != nil {
+		h.multisigError(c, "Failed to get proposals", err)
+		return
+	}
+
+	proposals, err := h.db.GetMultisigProposalsByWallet(ctx, walletAddress, c.Query("status"))
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to get proposals", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Database error", Code: "DB_ERROR"})
+		return
+	}
+	if proposals == nil {
+		proposals = []*database.MultisigProposal{}
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Proposals retrieved",
+		Data: gin.H{
+			"threshold": mw.Threshold,
+			"proposals": proposals,
+			"count":     len(proposals),
+		},
+	})
+}
+
+// ApproveMultisigRequest carries one way of producing the co-signer signature
+type ApproveMultisigRequest struct {
+	Password    string `json:"password"`
+	UnlockToken string `json:"unlock_token"`
+	Signature   string `json:"signature"`
+}
+
+// ApproveMultisigHandler adds the caller's signature to a proposal
+func (h *Handler) ApproveMultisigHandler(c *gin.Context) {
+	var req ApproveMultisigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	if req.Password != "" && h.checkLoginLockout(ctx, c, c.GetString("email")) {
+		return
+	}
+
+	proposal, err := h.multisigService.Approve(ctx, c.GetString("user_id"), c.Param("id"), services.SignerCredentials{
+		Password:    req.Password,
+		UnlockToken: req.UnlockToken,
+		Signature:   req.Signature,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPassword) {
+			h.signingPasswordFailed(ctx, c, "wrong password to approve multisig proposal")
+		}
+		h.multisigError(c, "Failed to approve proposal", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Proposal approved",
+		Data: gin.H{
+			"proposal": proposal,
+		},
+	})
+}
+
+// BroadcastMultisigHandler submits a proposal once enough co-signers approved
+func (h *Handler) BroadcastMultisigHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 30*time.Second)
+	defer cancel()
+
+	txHash, err := h.multisigService.Broadcast(ctx, c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		h.multisigError(c, "Failed to broadcast proposal", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Transaction created",
+		Data: gin.H{
+			"transaction_hash": txHash,
+			"proposal_id":      c.Param("id"),
+		},
+	})
+}
+
+// multisigError maps multisig service errors to API responses
+func (h *Handler) multisigError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, blockchain.ErrInvalidMultisigPolicy):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "INVALID_MULTISIG_POLICY"})
+	case errors.Is(err, services.ErrSignerNotFound):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "INVALID_WALLET"})
+	case errors.Is(err, services.ErrMultisigExists):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error(), Code: "MULTISIG_EXISTS"})
+	case errors.Is(err, services.ErrNotMultisigSigner):
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error(), Code: "FORBIDDEN"})
+	case errors.Is(err, services.ErrProposalNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error(), Code: "NOT_FOUND"})
+	case errors.Is(err, services.ErrProposalNotPending):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error(), Code: "PROPOSAL_NOT_PENDING"})
+	case errors.Is(err, services.ErrMissingCredentials):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "MISSING_SIGNATURE"})
+	case errors.Is(err, services.ErrNonCustodialWallet):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "NON_CUSTODIAL_WALLET"})
+	case errors.Is(err, services.ErrInvalidPassword):
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid credentials", Code: "INVALID_CREDENTIALS"})
+	case errors.Is(err, services.ErrInvalidUnlockToken):
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid or expired unlock token", Code: "INVALID_UNLOCK_TOKEN"})
+	case errors.Is(err, blockchain.ErrInvalidSignature):
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid digital signature", Code: "INVALID_SIGNATURE"})
+	case errors.Is(err, blockchain.ErrInsufficientSignatures):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "INSUFFICIENT_SIGNATURES"})
+	case transferRefusal(c, err):
+	default:
+		h.logger.ErrorContext(c.Request.Context(), message, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: message, Code: "MULTISIG_ERROR"})
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"crypto-wallet-backend/internal/database"
+	"crypto-wallet-backend/internal/utils"
+	"crypto-wallet-backend/pkg/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimits are the request limiters applied by the routes
+type rateLimits struct {
+	client *utils.RateLimiter // every request, per client IP
+	auth   *utils.RateLimiter // each authentication route, per client IP
+	costly *utils.RateLimiter // key generation and mining, per user or IP
+	user   *utils.RateLimiter // each authenticated route, per user
+}
+
+func newRateLimits(cfg config.RateLimitConfig) rateLimits {
+	return rateLimits{
+		client: utils.NewRateLimiter(cfg.RPS, cfg.Burst),
+		auth:   utils.NewRateLimiter(cfg.AuthPerMinute/60, int(math.Ceil(cfg.AuthPerMinute))),
+		costly: utils.NewRateLimiter(cfg.CostlyPerMinute/60, int(math.Ceil(cfg.CostlyPerMinute))),
+		user:   utils.NewRateLimiter(cfg.UserPerMinute/60, int(math.Ceil(cfg.UserPerMinute))),
+	}
+}
+
+// clientKey counts requests against the client address
+func clientKey(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// routeClientKey counts requests against the route and client address
+func routeClientKey(c *gin.Context) string {
+	return c.FullPath() + "|" + clientKey(c)
+}
+
+// routeUserKey counts requests against the route and the authenticated
+// user, or the client address before authentication
+func routeUserKey(c *gin.Context) string {
+	if userID := c.GetString("user_id"); userID != "" {
+		return c.FullPath() + "|user:" + userID
+	}
+	return routeClientKey(c)
+}
+
+// RateLimitMiddleware answers 429 with Retry-After once the bucket that key
+// picks for a request is empty
+func RateLimitMiddleware(limiter *utils.RateLimiter, key func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ok, wait := limiter.Allow(key(c)); !ok {
+			tooManyRequests(c, wait, ErrorResponse{Error: "Too many requests; slow down", Code: "RATE_LIMITED"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// tooManyRequests answers 429, telling the client when to retry in whole
+// seconds
+func tooManyRequests(c *gin.Context, wait time.Duration, body ErrorResponse) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, body)
+}
+
+// checkLoginLockout answers 429 if logins for email are locked, reporting
+// whether it did
+func (h *Handler) checkLoginLockout(ctx context.Context, c *gin.Context, email string) bool {
+	lockedFor, err := h.loginGuard.LockedFor(ctx, email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Database error", Code: "DB_ERROR"})
+		return true
+	}
+	if lockedFor > 0 {
+		tooManyRequests(c, lockedFor, ErrorResponse{Error: "Too many failed logins; try again later", Code: "ACCOUNT_LOCKED"})
+		return true
+	}
+	return false
+}
+
+// loginFailed records a failed login for email and answers 401
+func (h *Handler) loginFailed(ctx context.Context, c *gin.Context, email string, user *database.User, reason string) {
+	if _, err := h.loginGuard.Fail(ctx, email, user, reason); err != nil {
+		h.logger.ErrorContext(ctx, "Failed to record failed login", "error", err)
+	}
+	c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid credentials", Code: "INVALID_CREDENTIALS"})
+}
+
+// signingPasswordFailed counts a wrong password given to unlock a wallet or
+// sign with it as a failed login for the caller, so the signing routes cannot
+// be used to guess passwords past the lockout
+func (h *Handler) signingPasswordFailed(ctx context.Context, c *gin.Context, reason string) {
+	user, err := h.db.GetUserByID(ctx, c.GetString("user_id"))
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to load user for failed login", "error", err)
+	}
+	if _, err := h.loginGuard.Fail(ctx, c.GetString("email"), user, reason); err != nil {
+		h.logger.ErrorContext(ctx, "Failed to record failed login", "error", err)
+	}
+}
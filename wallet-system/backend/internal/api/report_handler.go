@@ -18,25 +18,29 @@ func (h *Handler) GetMonthlyReportHandler(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
 	defer cancel()
 
+	if _, ok := h.authorizeWallet(ctx, c, walletAddress); !ok {
+		return
+	}
+
 	// Get all transactions for this wallet
 	txns, err := h.db.GetTransactionsByWallet(ctx, walletAddress, 1000, 0)
 	if err != nil {
-		h.logger.Error("Failed to get transactions: %v", err)
+		h.logger.ErrorContext(ctx, "Failed to get transactions", "error", err)
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error(), Code: "DB_ERROR"})
 		return
 	}
 
 	// Group by month
 	type MonthData struct {
-		Month     string  `json:"month"`
-		Incoming  float64 `json:"incoming"`
-		Outgoing  float64 `json:"outgoing"`
-		Fee       float64 `json:"fee"`
-		Net       float64 `json:"net"`
-		Count     int     `json:"count"`
+		Month    string  `json:"month"`
+		Incoming float64 `json:"incoming"`
+		Outgoing float64 `json:"outgoing"`
+		Fee      float64 `json:"fee"`
+		Net      float64 `json:"net"`
+		Count    int     `json:"count"`
 	}
 	monthlyData := make(map[string]MonthData)
 
@@ -84,42 +88,50 @@ func (h *Handler) GetZakatReportHandler(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
 	defer cancel()
 
 	// Get wallet info
-	wallet, err := h.db.GetWalletByAddress(ctx, walletAddress)
-	if err != nil {
-		h.logger.Error("Failed to get wallet: %v", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error(), Code: "DB_ERROR"})
+	wallet, ok := h.authorizeWallet(ctx, c, walletAddress)
+	if !ok {
 		return
 	}
 
-	if wallet == nil {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Wallet not found", Code: "NOT_FOUND"})
+	assessment, err := h.zakatService.Assess(ctx, walletAddress, time.Now())
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to assess zakat", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to assess zakat", Code: "ZAKAT_ERROR"})
 		return
 	}
 
-	// Calculate current zakat (2.5%)
-	currentZakat := wallet.BalanceCache * 0.025
+	history, err := h.zakatService.GetZakatReports(ctx, walletAddress, c.Query("year"))
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to get zakat history", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Database error", Code: "DB_ERROR"})
+		return
+	}
+	if history == nil {
+		history = []*database.ZakatTransaction{}
+	}
 
-	// Mock zakat history - in production fetch from zakat_transactions table
-	zakatHistory := []gin.H{
-		{
-			"month":         time.Now().Format("2006-01"),
-			"zakat_amount":  currentZakat,
-			"percentage":    2.5,
-			"deducted_date": time.Now().Format("2006-01-02"),
-		},
+	// Totals per Gregorian year
+	yearlyTotals := make(map[string]float64)
+	totalPaid := 0.0
+	for _, zt := range history {
+		yearlyTotals[zt.MonthYear[:4]] += zt.Amount
+		totalPaid += zt.Amount
 	}
 
 	c.JSON(http.StatusOK, SuccessResponse{
 		Status:  "success",
 		Message: "Zakat report retrieved",
 		Data: gin.H{
-			"current_balance":      wallet.BalanceCache,
-			"monthly_zakat":        currentZakat,
-			"zakat_history":        zakatHistory,
+			"current_balance":        wallet.BalanceCache,
+			"zakat_due":              assessment.Amount,
+			"assessment":             assessment,
+			"zakat_history":          history,
+			"yearly_totals":          yearlyTotals,
+			"total_paid":             totalPaid,
 			"is_deducted_this_month": wallet.ZakatDeducted,
 		},
 	})
@@ -143,7 +155,7 @@ func (h *Handler) AddBeneficiaryHandler(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
 	defer cancel()
 
 	beneficiary := &database.Beneficiary{
@@ -153,7 +165,7 @@ func (h *Handler) AddBeneficiaryHandler(c *gin.Context) {
 	}
 
 	if err := h.db.CreateBeneficiary(ctx, beneficiary); err != nil {
-		h.logger.Error("Failed to create beneficiary: %v", err)
+		h.logger.ErrorContext(ctx, "Failed to create beneficiary", "error", err)
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error(), Code: "DB_ERROR"})
 		return
 	}
@@ -175,12 +187,12 @@ func (h *Handler) GetBeneficiariesHandler(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
 	defer cancel()
 
 	beneficiaries, err := h.db.GetBeneficiariesByUserID(ctx, userID)
 	if err != nil {
-		h.logger.Error("Failed to get beneficiaries: %v", err)
+		h.logger.ErrorContext(ctx, "Failed to get beneficiaries", "error", err)
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error(), Code: "DB_ERROR"})
 		return
 	}
@@ -1,29 +1,81 @@
 package api
 
 import (
+	"crypto-wallet-backend/internal/database"
+
 	"github.com/gin-gonic/gin"
 )
 
 // SetupRoutes sets up all API routes
 func SetupRoutes(router *gin.Engine, handler *Handler) {
-	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	adminOnly := handler.RequireRole(database.RoleAdmin)
+	staffOnly := handler.RequireRole(database.RoleAdmin, database.RoleAuditor)
+	costly := RateLimitMiddleware(handler.rateLimits.costly, routeUserKey)
+	authenticated := []gin.HandlerFunc{
+		AuthMiddleware(handler.sessionService),
+		RateLimitMiddleware(handler.rateLimits.user, routeUserKey),
+	}
+
+	// Health checks: /health is kept as an alias of liveness
+	router.GET("/health", handler.LivenessHandler)
+	router.GET("/health/live", handler.LivenessHandler)
+	router.GET("/health/ready", handler.ReadinessHandler)
+
+	// Prometheus scrapes, exempt from the client limit
+	router.GET("/metrics", handler.metricsHandler())
+
+	// Every route registered below is limited per client address
+	router.Use(RateLimitMiddleware(handler.rateLimits.client, clientKey))
 
 	// Authentication routes
 	auth := router.Group("/api/auth")
+	auth.Use(RateLimitMiddleware(handler.rateLimits.auth, routeClientKey))
 	{
-		auth.POST("/register", handler.RegisterHandler)
+		auth.POST("/register", costly, handler.RegisterHandler)
 		auth.POST("/login", handler.LoginHandler)
+		auth.POST("/challenge", handler.ChallengeHandler)
+		auth.POST("/2fa/verify", handler.VerifyLoginHandler)
+		auth.POST("/2fa/resend", handler.ResendLoginCodeHandler)
+		auth.POST("/refresh", handler.RefreshTokenHandler)
+	}
+
+	// Session management
+	sessions := router.Group("/api/auth")
+	sessions.Use(authenticated...)
+	{
+		sessions.POST("/logout", handler.LogoutHandler)
+		sessions.POST("/logout-all", handler.LogoutAllHandler)
+		sessions.GET("/sessions", handler.GetSessionsHandler)
+		sessions.DELETE("/sessions/:id", handler.RevokeSessionHandler)
+	}
+
+	// Second factor management
+	twoFactor := router.Group("/api/auth/2fa")
+	twoFactor.Use(authenticated...)
+	{
+		twoFactor.GET("", handler.GetTwoFactorStatusHandler)
+		twoFactor.POST("/send", handler.SendTransferCodeHandler)
+		twoFactor.POST("/totp/enroll", handler.EnrollTOTPHandler)
+		twoFactor.POST("/totp/confirm", handler.ConfirmTOTPHandler)
+		twoFactor.POST("/totp/disable", handler.DisableTOTPHandler)
 	}
 
 	// Wallet routes
 	wallet := router.Group("/api/wallet")
-	wallet.Use(AuthMiddleware(handler.jwtSecret))
+	wallet.Use(authenticated...)
 	{
 		wallet.GET("/profile", handler.GetWalletHandler)
 		wallet.POST("/balance", handler.GetBalanceHandler)
+		wallet.POST("/unlock", handler.UnlockWalletHandler)
+		wallet.GET("/limits", handler.GetTransferLimitsUsageHandler)
+	}
+
+	// Identity verification routes
+	kyc := router.Group("/api/kyc")
+	kyc.Use(authenticated...)
+	{
+		kyc.POST("/submissions", handler.SubmitKYCHandler)
+		kyc.GET("/status", handler.GetKYCStatusHandler)
 	}
 
 	// Blockchain routes
@@ -32,12 +84,12 @@ func SetupRoutes(router *gin.Engine, handler *Handler) {
 		blockchain.GET("/blocks", handler.GetBlocksHandler)
 		blockchain.GET("/latest", handler.GetLatestBlockHandler)
 		blockchain.GET("/blocks/:hash", handler.GetBlockByHashHandler)
-		blockchain.POST("/mine", handler.MineBlockHandler)
+		blockchain.POST("/mine", costly, handler.MineBlockHandler)
 	}
 
 	// Transaction routes
 	transaction := router.Group("/api/transaction")
-	transaction.Use(AuthMiddleware(handler.jwtSecret))
+	transaction.Use(authenticated...)
 	{
 		transaction.GET("/pending", func(c *gin.Context) {
 			// Get pending transactions
@@ -45,30 +97,90 @@ func SetupRoutes(router *gin.Engine, handler *Handler) {
 		})
 		transaction.GET("/history", handler.GetTransactionHistoryHandler)
 		transaction.POST("/send", handler.SendTransactionHandler)
+		transaction.POST("/schedule", handler.ScheduleTransferHandler)
+		transaction.GET("/scheduled", handler.GetScheduledTransfersHandler)
+		transaction.POST("/scheduled/:id/cancel", handler.CancelScheduledTransferHandler)
 	}
 
 	// Reports routes
 	reports := router.Group("/api/reports")
-	reports.Use(AuthMiddleware(handler.jwtSecret))
+	reports.Use(authenticated...)
 	{
 		reports.GET("/monthly", handler.GetMonthlyReportHandler)
 		reports.GET("/zakat", handler.GetZakatReportHandler)
 	}
 
+	// Zakat routes
+	zakat := router.Group("/api/zakat")
+	zakat.Use(authenticated...)
+	{
+		zakat.GET("/assessment", handler.GetZakatAssessmentHandler)
+		zakat.GET("/receipts/:id", handler.GetZakatReceiptHandler)
+		zakat.GET("/pool", staffOnly, handler.GetZakatPoolHandler)
+		zakat.POST("/pool/disburse", adminOnly, handler.DisburseZakatHandler)
+		zakat.POST("/recipients", adminOnly, handler.CreateZakatRecipientHandler)
+		zakat.GET("/settings", handler.GetZakatSettingsHandler)
+		zakat.PUT("/settings", handler.UpdateZakatSettingsHandler)
+		zakat.GET("/declarations", handler.GetZakatDeclarationsHandler)
+		zakat.POST("/declarations", handler.CreateZakatDeclarationHandler)
+		zakat.DELETE("/declarations/:id", handler.DeleteZakatDeclarationHandler)
+		zakat.GET("/exemptions", staffOnly, handler.GetZakatExemptionsHandler)
+		zakat.POST("/exemptions", adminOnly, handler.CreateZakatExemptionHandler)
+		zakat.DELETE("/exemptions/:user_id", adminOnly, handler.DeleteZakatExemptionHandler)
+	}
+
 	// Beneficiary routes
 	beneficiary := router.Group("/api/beneficiary")
-	beneficiary.Use(AuthMiddleware(handler.jwtSecret))
+	beneficiary.Use(authenticated...)
 	{
 		beneficiary.POST("/add", handler.AddBeneficiaryHandler)
 		beneficiary.GET("/list", handler.GetBeneficiariesHandler)
 	}
 
+	// Multisig wallet routes
+	multisig := router.Group("/api/multisig")
+	multisig.Use(authenticated...)
+	{
+		multisig.POST("/create", handler.CreateMultisigHandler)
+		multisig.GET("/list", handler.GetMultisigWalletsHandler)
+		multisig.POST("/proposals", handler.ProposeMultisigHandler)
+		multisig.GET("/proposals", handler.GetMultisigProposalsHandler)
+		multisig.POST("/proposals/:id/approve", handler.ApproveMultisigHandler)
+		multisig.POST("/proposals/:id/broadcast", handler.BroadcastMultisigHandler)
+	}
+
+	// Admin routes; auditors may read but not change anything
+	admin := router.Group("/api/admin")
+	admin.Use(append(authenticated, staffOnly)...)
+	{
+		admin.GET("/users", handler.GetUsersHandler)
+		admin.PUT("/users/:id/role", adminOnly, handler.SetUserRoleHandler)
+		admin.PUT("/users/:id/tier", adminOnly, handler.SetUserTierHandler)
+		admin.GET("/limits", handler.GetTransferLimitsHandler)
+		admin.PUT("/limits/:tier", adminOnly, handler.SetTransferLimitsHandler)
+		admin.POST("/wallets/:address/freeze", adminOnly, handler.FreezeWalletHandler)
+		admin.POST("/wallets/:address/unfreeze", adminOnly, handler.UnfreezeWalletHandler)
+		admin.POST("/wallets/:address/close", adminOnly, handler.CloseWalletHandler)
+		admin.GET("/kyc", handler.GetKYCSubmissionsHandler)
+		admin.GET("/kyc/:id", handler.GetKYCSubmissionHandler)
+		admin.GET("/kyc/:id/documents/:doc_id", handler.GetKYCDocumentHandler)
+		admin.POST("/kyc/:id/approve", adminOnly, handler.ApproveKYCHandler)
+		admin.POST("/kyc/:id/reject", adminOnly, handler.RejectKYCHandler)
+		admin.POST("/zakat/process", adminOnly, handler.ProcessZakatHandler)
+		admin.GET("/jobs", handler.GetJobRunsHandler)
+		admin.GET("/jobs/:id", handler.GetJobRunHandler)
+		admin.POST("/jobs/:id/rerun", adminOnly, handler.RerunJobHandler)
+		admin.POST("/maintenance/:task", adminOnly, handler.RunMaintenanceHandler)
+		admin.GET("/config", adminOnly, handler.GetConfigHandler)
+	}
+
 	// System routes
 	system := router.Group("/api/system")
-	system.Use(AuthMiddleware(handler.jwtSecret))
+	system.Use(authenticated...)
 	{
-		system.GET("/logs", handler.GetSystemLogsHandler)
-		system.GET("/logs/stats", handler.GetSystemLogStatsHandler)
-		system.GET("/health", handler.GetSystemHealthHandler)
+		system.GET("/logs", staffOnly, handler.GetSystemLogsHandler)
+		system.GET("/logs/stats", staffOnly, handler.GetSystemLogStatsHandler)
+		system.GET("/logs/verify", staffOnly, costly, handler.VerifyAuditLogHandler)
+		system.GET("/health", staffOnly, handler.GetSystemHealthHandler)
 	}
 }
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"crypto-wallet-backend/internal/blockchain"
+	"crypto-wallet-backend/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScheduleTransferRequest represents a transfer to be submitted at ExecuteAt.
+// The transaction is signed now with lock_time set to ExecuteAt, so a client
+// signature must cover ":<execute_at unix>:<lock_until>".
+type ScheduleTransferRequest struct {
+	SendTransactionRequest
+	ExecuteAt time.Time `json:"execute_at" binding:"required"`
+}
+
+// ScheduleTransferHandler signs a transfer and stores it for later execution
+func (h *Handler) ScheduleTransferHandler(c *gin.Context) {
+	var req ScheduleTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("Invalid request: %v", err),
+			Code:  "INVALID_REQUEST",
+		})
+		return
+	}
+
+	if !validateTransferRequest(c, &req.SendTransactionRequest) {
+		return
+	}
+
+	if !req.ExecuteAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "execute_at must be in the future",
+			Code:  "INVALID_EXECUTE_AT",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	senderWallet, ok := h.transferSenderWallet(ctx, c, req.SenderWallet)
+	if !ok {
+		return
+	}
+
+	tx := blockchain.NewTransaction(req.SenderWallet, req.ReceiverWallet, req.Amount, req.Fee, req.Note)
+	tx.LockTime = req.ExecuteAt.Unix()
+	tx.LockUntil = req.LockUntil
+	if !h.authorizeTransfer(ctx, c, senderWallet, &req.SendTransactionRequest, tx) {
+		return
+	}
+
+	st, err := h.scheduledTransferService.Schedule(ctx, c.GetString("user_id"), tx)
+	if err != nil {
+		if errors.Is(err, blockchain.ErrInvalidTransaction) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "INVALID_EXECUTE_AT"})
+			return
+		}
+		h.logger.ErrorContext(ctx, "Failed to schedule transfer", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to schedule transfer", Code: "DB_ERROR"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Status:  "success",
+		Message: "Transfer scheduled",
+		Data: gin.H{
+			"scheduled_transfer": st,
+		},
+	})
+}
+
+// GetScheduledTransfersHandler lists the caller's scheduled transfers
+func (h *Handler) GetScheduledTransfersHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	transfers, err := h.db.GetScheduledTransfersByUser(ctx, c.GetString("user_id"))
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to get scheduled transfers", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Database error", Code: "DB_ERROR"})
+		return
+	}
+	if transfers == nil {
+		transfers = []*database.ScheduledTransfer{}
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Scheduled transfers retrieved",
+		Data: gin.H{
+			"scheduled_transfers": transfers,
+			"count":               len(transfers),
+		},
+	})
+}
+
+// CancelScheduledTransferHandler cancels a pending scheduled transfer
+func (h *Handler) CancelScheduledTransferHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	cancelled, err := h.db.CancelScheduledTransfer(ctx, c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to cancel scheduled transfer", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Database error", Code: "DB_ERROR"})
+		return
+	}
+	if !cancelled {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error: "No pending scheduled transfer with that id",
+			Code:  "NOT_FOUND",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Scheduled transfer cancelled",
+		Data: gin.H{
+			"id": c.Param("id"),
+		},
+	})
+}
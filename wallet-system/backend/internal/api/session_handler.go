@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"crypto-wallet-backend/internal/database"
+	"crypto-wallet-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RefreshTokenRequest carries the refresh token of a session
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RefreshTokenHandler exchanges a refresh token for a new access token and
+// a new refresh token; the old refresh token stops working
+func (h *Handler) RefreshTokenHandler(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	tokens, err := h.sessionService.Refresh(ctx, req.RefreshToken, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		h.sessionError(c, "Failed to refresh session", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Session refreshed",
+		Data:    tokens,
+	})
+}
+
+// sessionView is a session as shown to its user
+type sessionView struct {
+	*database.Session
+	Current bool `json:"current"`
+}
+
+// GetSessionsHandler lists the caller's active sessions with the device and
+// address each was last used from
+func (h *Handler) GetSessionsHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	sessions, err := h.sessionService.GetSessions(ctx, c.GetString("user_id"))
+	if err != nil {
+		h.sessionError(c, "Failed to get sessions", err)
+		return
+	}
+
+	current := c.GetString("session_id")
+	views := make([]sessionView, 0, len(sessions))
+	for _, s := range sessions {
+		views = append(views, sessionView{Session: s, Current: s.ID == current})
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Sessions retrieved",
+		Data:    gin.H{"sessions": views, "count": len(views)},
+	})
+}
+
+// RevokeSessionHandler ends one of the caller's sessions
+func (h *Handler) RevokeSessionHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	if err := h.sessionService.Revoke(ctx, c.GetString("user_id"), c.Param("id")); err != nil {
+		h.sessionError(c, "Failed to revoke session", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Status: "success", Message: "Session revoked"})
+}
+
+// LogoutHandler ends the session the request was made with
+func (h *Handler) LogoutHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	err := h.sessionService.Revoke(ctx, c.GetString("user_id"), c.GetString("session_id"))
+	if err != nil && !errors.Is(err, services.ErrSessionNotFound) {
+		h.sessionError(c, "Failed to log out", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Status: "success", Message: "Logged out"})
+}
+
+// LogoutAllHandler ends every session of the caller, including this one
+func (h *Handler) LogoutAllHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	revoked, err := h.sessionService.RevokeAll(ctx, c.GetString("user_id"))
+	if err != nil {
+		h.sessionError(c, "Failed to log out", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Logged out everywhere",
+		Data:    gin.H{"revoked": revoked},
+	})
+}
+
+func (h *Handler) sessionError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRefreshToken):
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error(), Code: "INVALID_REFRESH_TOKEN"})
+	case errors.Is(err, services.ErrRefreshTokenReused):
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error(), Code: "REFRESH_TOKEN_REUSED"})
+	case errors.Is(err, services.ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error(), Code: "NOT_FOUND"})
+	default:
+		h.logger.ErrorContext(c.Request.Context(), message, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: message, Code: "SESSION_ERROR"})
+	}
+}
@@ -0,0 +1,293 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"crypto-wallet-backend/internal/database"
+	"crypto-wallet-backend/internal/services"
+	"crypto-wallet-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Background job names
+const (
+	jobScheduledTransfers = "scheduled-transfers"
+	jobMultisigRecovery   = "multisig-recovery"
+	jobMonthlyZakat       = "monthly-zakat"
+	jobRunsCleanup        = "job-runs-cleanup"
+	jobSessionsCleanup    = "sessions-cleanup"
+	jobUnlockTokens       = "unlock-tokens-cleanup"
+	jobLoginFailures      = "login-failures-cleanup"
+	jobReconcileBalances  = "reconcile-balances"
+	jobAuditAnchor        = "audit-anchor"
+)
+
+// jobRunRetention is how long scheduler run records are kept
+const jobRunRetention = 30 * 24 * time.Hour
+
+// sessionRetention is how long expired and revoked sessions are kept, so
+// recent logins can still be looked into
+const sessionRetention = 7 * 24 * time.Hour
+
+var (
+	errUnknownJob = errors.New("unknown job")
+	errJobRunning = errors.New("job is already running")
+)
+
+// jobParams are the parameters a job run was started with
+type jobParams struct {
+	MonthYear string `json:"month_year,omitempty"`
+	DryRun    bool   `json:"dry_run,omitempty"`
+}
+
+// RegisterTasks adds the handler's background jobs to scheduler. zakatSpec
+// is the cron expression of the monthly zakat run.
+func (h *Handler) RegisterTasks(scheduler *utils.Scheduler, zakatSpec string) error {
+	h.scheduler = scheduler
+
+	err := scheduler.AddJob(jobScheduledTransfers, "@every 1m", false, func(ctx context.Context, _ time.Time) error {
+		_, err := h.runJob(ctx, jobScheduledTransfers, utils.JobRunID(ctx), jobParams{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = scheduler.AddJob(jobMultisigRecovery, "@every 5m", false, func(ctx context.Context, _ time.Time) error {
+		_, err := h.runJob(ctx, jobMultisigRecovery, utils.JobRunID(ctx), jobParams{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// The month is taken from the scheduled time, so a run caught up after
+	// downtime still processes the month it was due for. It is recorded with
+	// the run so a rerun processes the same month.
+	err = scheduler.AddJob(jobMonthlyZakat, zakatSpec, true, func(ctx context.Context, scheduledFor time.Time) error {
+		params := jobParams{MonthYear: scheduledFor.Format("2006-01")}
+		runID := utils.JobRunID(ctx)
+		if runID != "" {
+			raw, _ := json.Marshal(params)
+			if err := h.db.SetJobRunParams(ctx, runID, raw); err != nil {
+				h.logger.ErrorContext(ctx, "Failed to record parameters of zakat run", "run_id", runID, "error", err)
+			}
+		}
+		_, err := h.runJob(ctx, jobMonthlyZakat, runID, params)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = scheduler.AddJob(jobRunsCleanup, "@daily", false, func(ctx context.Context, _ time.Time) error {
+		_, err := h.runJob(ctx, jobRunsCleanup, utils.JobRunID(ctx), jobParams{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = scheduler.AddJob(jobSessionsCleanup, "@daily", false, func(ctx context.Context, _ time.Time) error {
+		_, err := h.runJob(ctx, jobSessionsCleanup, utils.JobRunID(ctx), jobParams{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = scheduler.AddJob(jobUnlockTokens, "@hourly", false, func(ctx context.Context, _ time.Time) error {
+		_, err := h.runJob(ctx, jobUnlockTokens, utils.JobRunID(ctx), jobParams{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = scheduler.AddJob(jobLoginFailures, "@daily", false, func(ctx context.Context, _ time.Time) error {
+		_, err := h.runJob(ctx, jobLoginFailures, utils.JobRunID(ctx), jobParams{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return scheduler.AddJob(jobAuditAnchor, "@hourly", false, func(ctx context.Context, _ time.Time) error {
+		_, err := h.runJob(ctx, jobAuditAnchor, utils.JobRunID(ctx), jobParams{})
+		return err
+	})
+}
+
+// runJob runs one occurrence of the named job, recording per-wallet outcomes
+// under runID when it is set. The returned result summarises the run and may
+// be set even when err is not nil.
+func (h *Handler) runJob(ctx context.Context, name, runID string, params jobParams) (interface{}, error) {
+	switch name {
+	case jobScheduledTransfers:
+		executed, failed, err := h.scheduledTransferService.ProcessDue(ctx)
+		if executed > 0 || failed > 0 {
+			h.logger.InfoContext(ctx, "Scheduled transfers processed", "executed", executed, "failed", failed)
+		}
+		return gin.H{"executed": executed, "failed": failed}, err
+
+	case jobMultisigRecovery:
+		broadcast, reopened, err := h.multisigService.RecoverStale(ctx)
+		if broadcast > 0 || reopened > 0 {
+			h.logger.WarnContext(ctx, "Recovered stale multisig broadcasts", "broadcast", broadcast, "reopened", reopened)
+		}
+		return gin.H{"broadcast": broadcast, "reopened": reopened}, err
+
+	case jobMonthlyZakat:
+		result, err := h.zakatService.ProcessMonthlyZakat(ctx, params.MonthYear, params.DryRun)
+		if result == nil {
+			return nil, err
+		}
+		h.recordJobItems(runID, result.Wallets)
+		h.observeZakatRun(result, err)
+		h.logger.InfoContext(ctx, "Monthly zakat processed", "month", result.MonthYear, "dry_run", result.DryRun,
+			"deducted", result.Deducted, "would_deduct", result.WouldDeduct, "skipped", result.Skipped, "failed", result.Failed)
+		// Failed wallets fail the run so it shows up for a rerun
+		if err == nil && result.Failed > 0 {
+			err = fmt.Errorf("zakat failed for %d wallets", result.Failed)
+		}
+		return result, err
+
+	case jobReconcileBalances:
+		result, err := h.walletService.ReconcileBalances(ctx, params.DryRun)
+		if result == nil {
+			return nil, err
+		}
+		h.recordJobItems(runID, result.Wallets)
+		h.logger.InfoContext(ctx, "Balances reconciled", "dry_run", result.DryRun,
+			"checked", result.Checked, "updated", result.Updated, "skipped", result.Skipped)
+		return result, err
+
+	case jobRunsCleanup:
+		deleted, err := h.db.DeleteJobRunsBefore(ctx, time.Now().Add(-jobRunRetention))
+		return gin.H{"deleted": deleted}, err
+
+	case jobSessionsCleanup:
+		deleted, err := h.db.DeleteSessionsBefore(ctx, time.Now().Add(-sessionRetention))
+		return gin.H{"deleted": deleted}, err
+
+	case jobUnlockTokens:
+		deleted, err := h.db.DeleteUnlockTokensBefore(ctx, time.Now())
+		return gin.H{"deleted": deleted}, err
+
+	case jobLoginFailures:
+		deleted, err := h.db.DeleteLoginFailuresBefore(ctx, time.Now().Add(-services.LoginFailureWindow))
+		return gin.H{"deleted": deleted}, err
+
+	case jobAuditAnchor:
+		anchor, err := h.miningService.AnchorAuditLog(ctx)
+		if anchor == nil {
+			return gin.H{"anchored": false}, err
+		}
+		h.logger.InfoContext(ctx, "Anchored audit log", "sequence", anchor.Sequence, "block_index", anchor.BlockIndex)
+		return gin.H{"anchored": true, "anchor": anchor}, err
+	}
+
+	return nil, errUnknownJob
+}
+
+// observeZakatRun counts the outcomes of a zakat run that changed balances.
+// Dry runs are left out.
+func (h *Handler) observeZakatRun(result *services.ZakatRunResult, err error) {
+	if result.DryRun {
+		return
+	}
+	for _, item := range result.Wallets {
+		h.metrics.ZakatWallets.Inc(item.Outcome)
+	}
+	h.metrics.ZakatDeducted.Add(result.Total)
+
+	status := utils.JobSucceeded
+	if err != nil || result.Failed > 0 {
+		status = utils.JobFailed
+	}
+	h.metrics.ZakatRuns.Inc(status)
+}
+
+// recordJobItems stores per-wallet outcomes of a run. They are written even
+// if the run was cancelled, since they describe what already happened.
+func (h *Handler) recordJobItems(runID string, items []*database.JobRunItem) {
+	if runID == "" || len(items) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := h.db.AddJobRunItems(ctx, runID, items); err != nil {
+		h.logger.ErrorContext(ctx, "Failed to record outcomes of job run", "run_id", runID, "error", err)
+	}
+}
+
+// executeJob runs a job on an admin's request under the lock the scheduler
+// uses, so it never overlaps a scheduled run, and records it with trigger.
+// A failure of the job itself is reported in the returned run's status.
+func (h *Handler) executeJob(ctx context.Context, name, trigger string, scheduledFor time.Time, rerunOf string, params jobParams) (*database.JobRun, interface{}, error) {
+	release, ok, err := h.db.TryLock(ctx, "job:"+name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, errJobRunning
+	}
+	defer release()
+
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, nil, err
+	}
+	run := &database.JobRun{
+		JobName:      name,
+		Trigger:      trigger,
+		Params:       raw,
+		RerunOf:      rerunOf,
+		ScheduledFor: scheduledFor,
+	}
+	if err := h.db.CreateJobRun(ctx, run); err != nil {
+		return nil, nil, err
+	}
+	_ = h.auditService.Record(ctx, services.AuditEvent{
+		Type:    database.LogJobTriggered,
+		Message: fmt.Sprintf("Job %s run %s started (%s)", name, run.ID, trigger),
+		After:   gin.H{"job": name, "run_id": run.ID, "trigger": trigger, "rerun_of": rerunOf, "params": params},
+	})
+
+	var result interface{}
+	jobErr := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		result, err = h.runJob(ctx, name, run.ID, params)
+		return err
+	}()
+
+	run.Status = utils.JobSucceeded
+	if jobErr != nil {
+		run.Status, run.Error = utils.JobFailed, jobErr.Error()
+		h.logger.ErrorContext(ctx, "Job failed", "job", name, "trigger", trigger, "run_id", run.ID, "error", jobErr)
+		_ = h.auditService.Record(ctx, services.AuditEvent{
+			Type:    database.LogError,
+			Message: fmt.Sprintf("Job %s run %s failed: %v", name, run.ID, jobErr),
+		})
+	}
+
+	finishCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := h.db.FinishJobRun(finishCtx, run.ID, run.Status, run.Error); err != nil {
+		return run, result, err
+	}
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+
+	return run, result, nil
+}
@@ -2,11 +2,14 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
+	"crypto-wallet-backend/internal/blockchain"
 	"crypto-wallet-backend/internal/database"
+	"crypto-wallet-backend/internal/services"
 	"crypto-wallet-backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -19,14 +22,28 @@ type SendTransactionRequest struct {
 	Amount         float64 `json:"amount" binding:"required"`
 	Fee            float64 `json:"fee" binding:"required"`
 	Note           string  `json:"note"`
-	Signature      string  `json:"signature" binding:"required"`
+
+	// LockUntil keeps the receiver's output unspendable until this block
+	// height (below 500000000) or Unix time
+	LockUntil int64 `json:"lock_until"`
+
+	// Exactly one way of authorising the transfer must be supplied: a
+	// client-side Signature over the canonical data (with its Timestamp), the
+	// account Password, or an UnlockToken from /api/wallet/unlock.
+	Timestamp   int64  `json:"timestamp"`
+	Signature   string `json:"signature"`
+	Password    string `json:"password"`
+	UnlockToken string `json:"unlock_token"`
+
+	// OTP is a second-factor code, required above the two-factor threshold
+	OTP string `json:"otp"`
 }
 
 // SendTransactionHandler sends a transaction
 func (h *Handler) SendTransactionHandler(c *gin.Context) {
 	var req SendTransactionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Error("SendTransaction bind error: %v", err)
+		h.logger.WarnContext(c.Request.Context(), "Invalid transfer request", "error", err)
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error: fmt.Sprintf("Invalid request: %v", err),
 			Code:  "INVALID_REQUEST",
@@ -34,13 +51,113 @@ func (h *Handler) SendTransactionHandler(c *gin.Context) {
 		return
 	}
 
+	if !validateTransferRequest(c, &req) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 30*time.Second)
+	defer cancel()
+
+	senderWallet, ok := h.transferSenderWallet(ctx, c, req.SenderWallet)
+	if !ok {
+		return
+	}
+
+	tx := blockchain.NewTransaction(req.SenderWallet, req.ReceiverWallet, req.Amount, req.Fee, req.Note)
+	tx.LockUntil = req.LockUntil
+	if !h.authorizeTransfer(ctx, c, senderWallet, &req, tx) {
+		return
+	}
+
+	// Client signatures stay valid for a while, so each is recorded to stop
+	// the same signed request being replayed
+	var signingHash string
+	if req.Password == "" && req.UnlockToken == "" {
+		signingHash = services.SigningHash(tx)
+	}
+
+	// Create and execute transaction
+	txHash, err := h.transactionService.CreateTransaction(ctx, database.Transaction{
+		SenderWallet:   req.SenderWallet,
+		ReceiverWallet: req.ReceiverWallet,
+		Amount:         req.Amount,
+		Fee:            req.Fee,
+		Note:           req.Note,
+		Signature:      tx.Signature,
+		Status:         "pending",
+		LockUntil:      tx.LockUntil,
+		SignedAt:       tx.Timestamp,
+		SigningHash:    signingHash,
+		CreatedAt:      time.Now(),
+	})
+	if err != nil {
+		if transferRefusal(c, err) {
+			return
+		}
+		if errors.Is(err, database.ErrSignatureReused) {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "This signature was already used; sign the transfer again", Code: "SIGNATURE_REUSED"})
+			return
+		}
+		if errors.Is(err, blockchain.ErrTransactionNotFinal) {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "TRANSACTION_LOCKED"})
+			return
+		}
+		h.logger.ErrorContext(ctx, "Failed to create transaction", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: err.Error(),
+			Code:  "TRANSACTION_ERROR",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Transaction created",
+		Data: gin.H{
+			"transaction_hash": txHash,
+			"sender":           req.SenderWallet,
+			"receiver":         req.ReceiverWallet,
+			"amount":           req.Amount,
+			"fee":              req.Fee,
+		},
+	})
+}
+
+// transferRefusal writes the response for a transfer refused because of a
+// wallet hold or a tier limit and reports whether err was such a refusal
+func transferRefusal(c *gin.Context, err error) bool {
+	var code string
+	switch {
+	case errors.Is(err, services.ErrWalletFrozen):
+		code = "WALLET_FROZEN"
+	case errors.Is(err, services.ErrWalletClosed):
+		code = "WALLET_CLOSED"
+	case errors.Is(err, services.ErrReceiverClosed):
+		code = "RECEIVER_CLOSED"
+	case errors.Is(err, services.ErrPerTransactionLimit):
+		code = "LIMIT_PER_TRANSACTION"
+	case errors.Is(err, services.ErrDailyLimit):
+		code = "LIMIT_DAILY"
+	case errors.Is(err, services.ErrMonthlyLimit):
+		code = "LIMIT_MONTHLY"
+	default:
+		return false
+	}
+
+	c.JSON(http.StatusForbidden, ErrorResponse{Error: err.Error(), Code: code})
+	return true
+}
+
+// validateTransferRequest checks amounts and wallet addresses of a transfer.
+// On failure the response is written and false is returned.
+func validateTransferRequest(c *gin.Context, req *SendTransactionRequest) bool {
 	// Validate amount
 	if req.Amount <= 0 {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
 			Error: "Amount must be greater than 0",
 			Code:  "INVALID_AMOUNT",
 		})
-		return
+		return false
 	}
 
 	// Validate fee
@@ -49,7 +166,7 @@ func (h *Handler) SendTransactionHandler(c *gin.Context) {
 			Error: "Fee cannot be negative",
 			Code:  "INVALID_FEE",
 		})
-		return
+		return false
 	}
 
 	// Validate wallet addresses
@@ -58,7 +175,7 @@ func (h *Handler) SendTransactionHandler(c *gin.Context) {
 			Error: "Sender and receiver wallets are required",
 			Code:  "INVALID_WALLET",
 		})
-		return
+		return false
 	}
 
 	if !utils.ValidateWalletAddress(req.SenderWallet) || !utils.ValidateWalletAddress(req.ReceiverWallet) {
@@ -66,7 +183,7 @@ func (h *Handler) SendTransactionHandler(c *gin.Context) {
 			Error: "Invalid wallet address format",
 			Code:  "INVALID_WALLET",
 		})
-		return
+		return false
 	}
 
 	if req.SenderWallet == req.ReceiverWallet {
@@ -74,43 +191,89 @@ func (h *Handler) SendTransactionHandler(c *gin.Context) {
 			Error: "Cannot send to the same wallet",
 			Code:  "SAME_WALLET",
 		})
-		return
+		return false
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	if req.LockUntil < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "lock_until cannot be negative",
+			Code:  "INVALID_LOCK",
+		})
+		return false
+	}
 
-	// Create and execute transaction
-	txHash, err := h.transactionService.CreateTransaction(ctx, database.Transaction{
-		SenderWallet:   req.SenderWallet,
-		ReceiverWallet: req.ReceiverWallet,
-		Amount:         req.Amount,
-		Fee:            req.Fee,
-		Note:           req.Note,
-		Signature:      req.Signature,
-		Status:         "pending",
-		CreatedAt:      time.Now(),
-	})
-	if err != nil {
-		h.logger.Error("Failed to create transaction: %v", err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error: err.Error(),
-			Code:  "TRANSACTION_ERROR",
+	return true
+}
+
+// transferSenderWallet authorizes the caller for the sending wallet and
+// rejects wallets that cannot use the single-signature send flow
+func (h *Handler) transferSenderWallet(ctx context.Context, c *gin.Context, walletAddress string) (*database.Wallet, bool) {
+	wallet, ok := h.authorizeWallet(ctx, c, walletAddress)
+	if !ok {
+		return nil, false
+	}
+
+	if wallet.WalletType == database.WalletTypeMultisig {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "Multisig wallets spend through /api/multisig/proposals",
+			Code:  "MULTISIG_WALLET",
 		})
-		return
+		return nil, false
 	}
 
-	c.JSON(http.StatusOK, SuccessResponse{
-		Status:  "success",
-		Message: "Transaction created",
-		Data: gin.H{
-			"transaction_hash": txHash,
-			"sender":           req.SenderWallet,
-			"receiver":         req.ReceiverWallet,
-			"amount":           req.Amount,
-			"fee":              req.Fee,
-		},
-	})
+	return wallet, true
+}
+
+// authorizeTransfer signs tx server-side when the request carries a password
+// or unlock token, and otherwise verifies the client-supplied signature. On
+// failure the response is written and false is returned.
+func (h *Handler) authorizeTransfer(ctx context.Context, c *gin.Context, wallet *database.Wallet, req *SendTransactionRequest, tx *blockchain.Transaction) bool {
+	if !h.checkTransferFactor(ctx, c, req.Amount, req.OTP) {
+		return false
+	}
+
+	var err error
+	switch {
+	case req.Password != "":
+		if h.checkLoginLockout(ctx, c, c.GetString("email")) {
+			return false
+		}
+		err = h.signingService.SignWithPassword(ctx, wallet.UserID, req.Password, tx)
+	case req.UnlockToken != "":
+		err = h.signingService.SignWithUnlockToken(ctx, wallet.UserID, req.UnlockToken, tx)
+	case req.Signature != "":
+		tx.Timestamp = req.Timestamp
+		tx.Signature = req.Signature
+		err = h.signingService.VerifyClientSignature(ctx, wallet.UserID, tx)
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: "A signature, password or unlock token is required",
+			Code:  "MISSING_SIGNATURE",
+		})
+		return false
+	}
+
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, services.ErrInvalidPassword):
+		h.signingPasswordFailed(ctx, c, "wrong password to sign transfer")
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid credentials", Code: "INVALID_CREDENTIALS"})
+	case errors.Is(err, services.ErrNonCustodialWallet):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "NON_CUSTODIAL_WALLET"})
+	case errors.Is(err, services.ErrInvalidUnlockToken):
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid or expired unlock token", Code: "INVALID_UNLOCK_TOKEN"})
+	case errors.Is(err, services.ErrSignatureExpired):
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Signature timestamp expired", Code: "SIGNATURE_EXPIRED"})
+	case errors.Is(err, database.ErrSignatureReused):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "This signature was already used; sign the transfer again", Code: "SIGNATURE_REUSED"})
+	case errors.Is(err, blockchain.ErrInvalidSignature):
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Invalid digital signature", Code: "INVALID_SIGNATURE"})
+	default:
+		h.logger.ErrorContext(ctx, "Failed to authorize transfer", "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to sign transaction", Code: "SIGNING_ERROR"})
+	}
+	return false
 }
 
 // GetTransactionHistoryHandler retrieves transaction history for a wallet
@@ -127,12 +290,16 @@ func (h *Handler) GetTransactionHistoryHandler(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
 	defer cancel()
 
+	if _, ok := h.authorizeWallet(ctx, c, wallet); !ok {
+		return
+	}
+
 	txns, err := h.transactionService.GetTransactionHistory(ctx, wallet, limit, offset)
 	if err != nil {
-		h.logger.Error("Failed to get transaction history: %v", err)
+		h.logger.ErrorContext(ctx, "Failed to get transaction history", "error", err)
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error: err.Error(),
 			Code:  "TRANSACTION_ERROR",
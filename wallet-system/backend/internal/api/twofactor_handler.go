@@ -0,0 +1,340 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"crypto-wallet-backend/internal/database"
+	"crypto-wallet-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// loginChallengeLifetime is how long a password-checked login may wait for
+// its second factor
+const loginChallengeLifetime = 5 * time.Minute
+
+// loginChallengeType marks a token that only proves the first factor. The
+// auth middleware only accepts access tokens, so it cannot be used as one.
+const loginChallengeType = "2fa_login"
+
+// signLoginChallenge issues the token a client exchanges, with a second
+// factor, for a session token
+func (h *Handler) signLoginChallenge(user *database.User) (string, error) {
+	return h.sessionService.Sign(jwt.MapClaims{
+		"sub": user.ID,
+		"typ": loginChallengeType,
+		"exp": time.Now().Add(loginChallengeLifetime).Unix(),
+	})
+}
+
+// parseLoginChallenge returns the user a login challenge token was issued to
+func (h *Handler) parseLoginChallenge(ctx context.Context, tokenString string) (*database.User, error) {
+	claims, err := h.sessionService.Parse(tokenString)
+	if err != nil {
+		return nil, errInvalidChallengeToken
+	}
+	if typ, _ := claims["typ"].(string); typ != loginChallengeType {
+		return nil, errInvalidChallengeToken
+	}
+	userID, _ := claims["sub"].(string)
+
+	user, err := h.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errInvalidChallengeToken
+	}
+	return user, nil
+}
+
+var errInvalidChallengeToken = errors.New("invalid or expired login challenge")
+
+// requireLoginFactor answers a login whose first factor passed. When the
+// policy wants a second factor it sends any emailed code and returns a
+// challenge token instead of a session, reporting true.
+func (h *Handler) requireLoginFactor(ctx context.Context, c *gin.Context, user *database.User) bool {
+	factor, err := h.twoFactorService.LoginFactor(ctx, user)
+	if err != nil {
+		h.twoFactorError(c, "Failed to check two-factor policy", err)
+		return true
+	}
+	if factor == "" {
+		return false
+	}
+
+	if factor == services.FactorEmail {
+		if err := h.twoFactorService.SendCode(ctx, user, database.OTPPurposeLogin); err != nil && !errors.Is(err, services.ErrOTPTooSoon) {
+			h.twoFactorError(c, "Failed to send verification code", err)
+			return true
+		}
+	}
+
+	challenge, err := h.signLoginChallenge(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to generate token", Code: "TOKEN_ERROR"})
+		return true
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Second factor required",
+		Data: gin.H{
+			"two_factor_required": true,
+			"method":              factor,
+			"challenge_token":     challenge,
+			"expires_in":          int(loginChallengeLifetime.Seconds()),
+		},
+	})
+	return true
+}
+
+// LoginChallengeRequest carries a login challenge token and, when verifying,
+// the second-factor code
+type LoginChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code"`
+}
+
+// VerifyLoginHandler completes a login with its second factor
+func (h *Handler) VerifyLoginHandler(c *gin.Context) {
+	var req LoginChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	user, err := h.parseLoginChallenge(ctx, req.ChallengeToken)
+	if err != nil {
+		h.twoFactorError(c, "Failed to verify login", err)
+		return
+	}
+	if h.checkLoginLockout(ctx, c, user.Email) {
+		return
+	}
+	if err := h.twoFactorService.Verify(ctx, user, database.OTPPurposeLogin, req.Code); err != nil {
+		// Wrong codes count towards the lockout, which also bounds guesses
+		// at authenticator codes
+		if errors.Is(err, services.ErrInvalidOTP) {
+			if _, ferr := h.loginGuard.Fail(ctx, user.Email, user, "wrong verification code"); ferr != nil {
+				h.logger.ErrorContext(ctx, "Failed to record failed login", "error", ferr)
+			}
+		}
+		h.twoFactorError(c, "Failed to verify login", err)
+		return
+	}
+
+	h.respondWithSession(c, user)
+}
+
+// ResendLoginCodeHandler emails a fresh login code for a pending challenge
+func (h *Handler) ResendLoginCodeHandler(c *gin.Context) {
+	var req LoginChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	user, err := h.parseLoginChallenge(ctx, req.ChallengeToken)
+	if err != nil {
+		h.twoFactorError(c, "Failed to send verification code", err)
+		return
+	}
+	if err := h.twoFactorService.SendCode(ctx, user, database.OTPPurposeLogin); err != nil {
+		h.twoFactorError(c, "Failed to send verification code", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Status: "success", Message: "Verification code sent"})
+}
+
+// GetTwoFactorStatusHandler returns the caller's enrolment and the policy
+func (h *Handler) GetTwoFactorStatusHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	status, err := h.twoFactorService.GetStatus(ctx, c.GetString("user_id"))
+	if err != nil {
+		h.twoFactorError(c, "Failed to get two-factor status", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Two-factor status retrieved",
+		Data:    status,
+	})
+}
+
+// SendTransferCodeHandler emails a code to authorise a transfer above the
+// threshold. Users with an authenticator app use its codes instead.
+func (h *Handler) SendTransferCodeHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	if err := h.twoFactorService.SendCode(ctx, user, database.OTPPurposeTransfer); err != nil {
+		h.twoFactorError(c, "Failed to send verification code", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Status: "success", Message: "Verification code sent"})
+}
+
+// EnrollTOTPHandler starts authenticator app enrolment, returning the
+// secret and an otpauth:// URI to show as a QR code
+func (h *Handler) EnrollTOTPHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	enrolment, err := h.twoFactorService.BeginTOTP(ctx, user)
+	if err != nil {
+		h.twoFactorError(c, "Failed to start enrolment", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Scan the QR code, then confirm with a code from the app",
+		Data:    enrolment,
+	})
+}
+
+// TOTPCodeRequest carries a code from the user's authenticator app
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// ConfirmTOTPHandler enables the authenticator app after checking a code
+func (h *Handler) ConfirmTOTPHandler(c *gin.Context) {
+	h.withTOTPCode(c, h.twoFactorService.ConfirmTOTP, "Authenticator app enabled")
+}
+
+// DisableTOTPHandler removes the authenticator app after checking a code
+func (h *Handler) DisableTOTPHandler(c *gin.Context) {
+	h.withTOTPCode(c, h.twoFactorService.DisableTOTP, "Authenticator app disabled")
+}
+
+func (h *Handler) withTOTPCode(c *gin.Context, action func(ctx context.Context, user *database.User, code string) error, message string) {
+	var req TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return
+	}
+	if err := action(ctx, user, req.Code); err != nil {
+		h.twoFactorError(c, "Failed to update authenticator app", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{Status: "success", Message: message})
+}
+
+// checkTransferFactor requires a second-factor code for transfers above the
+// policy threshold, reporting whether the transfer may go ahead. Wrong codes
+// count towards the login lockout, which refuses the check while it lasts.
+func (h *Handler) checkTransferFactor(ctx context.Context, c *gin.Context, amount float64, code string) bool {
+	user, ok := h.currentUser(ctx, c)
+	if !ok {
+		return false
+	}
+
+	factor, err := h.twoFactorService.TransferFactor(ctx, user, amount)
+	if err != nil {
+		h.twoFactorError(c, "Failed to check two-factor policy", err)
+		return false
+	}
+	if factor == "" {
+		return true
+	}
+	if h.checkLoginLockout(ctx, c, user.Email) {
+		return false
+	}
+
+	err = h.twoFactorService.Verify(ctx, user, database.OTPPurposeTransfer, code)
+	if errors.Is(err, services.ErrInvalidOTP) {
+		// As at login, wrong codes count towards the lockout, bounding
+		// guesses at authenticator codes
+		if _, ferr := h.loginGuard.Fail(ctx, user.Email, user, "wrong transfer verification code"); ferr != nil {
+			h.logger.ErrorContext(ctx, "Failed to record failed login", "error", ferr)
+		}
+	}
+	if errors.Is(err, services.ErrSecondFactor) {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   fmt.Sprintf("Transfers above %.8f need a verification code", h.twoFactorPolicy.TransferThreshold),
+			Message: factor,
+			Code:    "TWO_FACTOR_REQUIRED",
+		})
+		return false
+	}
+	if err != nil {
+		h.twoFactorError(c, "Failed to verify code", err)
+		return false
+	}
+	return true
+}
+
+// currentUser loads the authenticated user
+func (h *Handler) currentUser(ctx context.Context, c *gin.Context) (*database.User, bool) {
+	user, err := h.db.GetUserByID(ctx, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Database error", Code: "DB_ERROR"})
+		return nil, false
+	}
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "User not found", Code: "UNAUTHORIZED"})
+		return nil, false
+	}
+	return user, true
+}
+
+func (h *Handler) twoFactorError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, errInvalidChallengeToken):
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error(), Code: "INVALID_CHALLENGE_TOKEN"})
+	case errors.Is(err, services.ErrSecondFactor):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "TWO_FACTOR_REQUIRED"})
+	case errors.Is(err, services.ErrInvalidOTP):
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error(), Code: "INVALID_OTP"})
+	case errors.Is(err, services.ErrOTPExpired):
+		c.JSON(http.StatusUnauthorized, ErrorResponse{Error: err.Error(), Code: "OTP_EXPIRED"})
+	case errors.Is(err, services.ErrOTPAttempts):
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: err.Error(), Code: "OTP_ATTEMPTS"})
+	case errors.Is(err, services.ErrOTPTooSoon):
+		c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: err.Error(), Code: "OTP_TOO_SOON"})
+	case errors.Is(err, services.ErrUseAuthenticator):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error(), Code: "USE_AUTHENTICATOR"})
+	case errors.Is(err, services.ErrTOTPEnabled):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error(), Code: "TOTP_ENABLED"})
+	case errors.Is(err, services.ErrTOTPNotEnrolled):
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error(), Code: "TOTP_NOT_ENROLLED"})
+	default:
+		h.logger.ErrorContext(c.Request.Context(), message, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: message, Code: "OTP_ERROR"})
+	}
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"crypto-wallet-backend/internal/blockchain"
+	"crypto-wallet-backend/internal/services"
+	"crypto-wallet-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetZakatAssessmentHandler explains whether zakat is currently due on a
+// wallet under the nisab and hawl rules
+func (h *Handler) GetZakatAssessmentHandler(c *gin.Context) {
+	walletAddress := c.Query("wallet_address")
+	if walletAddress == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "wallet_address is required", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	if _, ok := h.authorizeWallet(ctx, c, walletAddress); !ok {
+		return
+	}
+
+	assessment, err := h.zakatService.Assess(ctx, walletAddress, time.Now())
+	if err != nil {
+		h.zakatError(c, "Failed to assess zakat", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Zakat assessment retrieved",
+		Data: gin.H{
+			"assessment": assessment,
+		},
+	})
+}
+
+// GetZakatReceiptHandler downloads the receipt of one zakat deduction as
+// plain text, or as JSON with ?format=json
+func (h *Handler) GetZakatReceiptHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	receipt, err := h.zakatService.GetReceipt(ctx, c.Param("id"))
+	if err != nil {
+		h.zakatError(c, "Failed to get receipt", err)
+		return
+	}
+
+	if _, ok := h.authorizeWallet(ctx, c, receipt.WalletAddress); !ok {
+		return
+	}
+
+	if c.Query("format") == "json" {
+		c.JSON(http.StatusOK, SuccessResponse{
+			Status:  "success",
+			Message: "Zakat receipt retrieved",
+			Data: gin.H{
+				"receipt": receipt,
+			},
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.txt"`, receipt.ReceiptNumber))
+	c.String(http.StatusOK, receipt.Text())
+}
+
+// GetZakatPoolHandler shows the zakat pool's inflows, disbursements and
+// balance to admins and auditors
+func (h *Handler) GetZakatPoolHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	report, err := h.zakatService.PoolReport(ctx)
+	if err != nil {
+		h.zakatError(c, "Failed to build pool report", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Zakat pool report retrieved",
+		Data: gin.H{
+			"pool": report,
+		},
+	})
+}
+
+// CreateZakatRecipientRequest registers a zakat pool recipient
+type CreateZakatRecipientRequest struct {
+	Name          string `json:"name" binding:"required"`
+	WalletAddress string `json:"wallet_address" binding:"required"`
+	Description   string `json:"description"`
+}
+
+// CreateZakatRecipientHandler registers a recipient of pool disbursements
+func (h *Handler) CreateZakatRecipientHandler(c *gin.Context) {
+	var req CreateZakatRecipientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+		return
+	}
+	if !utils.ValidateWalletAddress(req.WalletAddress) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid wallet address format", Code: "INVALID_WALLET"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	recipient, err := h.zakatService.RegisterRecipient(ctx, utils.SanitizeInput(req.Name), req.WalletAddress, utils.SanitizeInput(req.Description))
+	if err != nil {
+		h.zakatError(c, "Failed to register recipient", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Status:  "success",
+		Message: "Zakat recipient registered",
+		Data: gin.H{
+			"recipient": recipient,
+		},
+	})
+}
+
+// DisburseZakatRequest pays a registered recipient from the zakat pool
+type DisburseZakatRequest struct {
+	RecipientID string  `json:"recipient_id" binding:"required"`
+	Amount      float64 `json:"amount" binding:"required"`
+	Note        string  `json:"note"`
+}
+
+// DisburseZakatHandler transfers funds from the pool to a recipient
+func (h *Handler) DisburseZakatHandler(c *gin.Context) {
+	var req DisburseZakatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+		return
+	}
+	if valid, msg := utils.ValidateAmount(req.Amount); !valid {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: msg, Code: "INVALID_AMOUNT"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 30*time.Second)
+	defer cancel()
+
+	txHash, err := h.zakatService.Disburse(ctx, req.RecipientID, req.Amount, utils.SanitizeInput(req.Note))
+	if err != nil {
+		h.zakatError(c, "Failed to disburse zakat", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Zakat disbursed",
+		Data: gin.H{
+			"transaction_hash": txHash,
+			"recipient_id":     req.RecipientID,
+			"amount":           req.Amount,
+		},
+	})
+}
+
+// zakatError maps zakat service errors to API responses
+func (h *Handler) zakatError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrZakatNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error(), Code: "NOT_FOUND"})
+	case errors.Is(err, services.ErrRecipientNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error(), Code: "NOT_FOUND"})
+	case errors.Is(err, services.ErrRecipientInactive):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "RECIPIENT_INACTIVE"})
+	case errors.Is(err, services.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: err.Error(), Code: "NOT_FOUND"})
+	case errors.Is(err, services.ErrInvalidSadaqah):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "INVALID_SADAQAH"})
+	case errors.Is(err, services.ErrInvalidDeclaration):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "INVALID_DECLARATION"})
+	case transferRefusal(c, err):
+	case errors.Is(err, blockchain.ErrInvalidWallet):
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: "INVALID_WALLET"})
+	default:
+		h.logger.ErrorContext(c.Request.Context(), message, "error", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: message, Code: "ZAKAT_ERROR"})
+	}
+}
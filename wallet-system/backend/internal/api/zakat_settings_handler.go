@@ -0,0 +1,257 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"crypto-wallet-backend/internal/database"
+	"crypto-wallet-backend/internal/services"
+	"crypto-wallet-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetZakatSettingsHandler returns the caller's zakat settings and any
+// exemption granted to them
+func (h *Handler) GetZakatSettingsHandler(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	settings, err := h.db.GetZakatSettings(ctx, userID)
+	if err != nil {
+		h.zakatError(c, "Failed to get zakat settings", err)
+		return
+	}
+	exemption, err := h.db.GetZakatExemption(ctx, userID)
+	if err != nil {
+		h.zakatError(c, "Failed to get zakat settings", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Zakat settings retrieved",
+		Data: gin.H{
+			"settings":  settings,
+			"exemption": exemption,
+		},
+	})
+}
+
+// UpdateZakatSettingsRequest represents a change to zakat settings
+type UpdateZakatSettingsRequest struct {
+	OptedIn           *bool   `json:"opted_in" binding:"required"`
+	SadaqahPercentage float64 `json:"sadaqah_percentage"`
+}
+
+// UpdateZakatSettingsHandler opts the caller in or out of zakat deduction
+// and sets their voluntary sadaqah percentage
+func (h *Handler) UpdateZakatSettingsHandler(c *gin.Context) {
+	var req UpdateZakatSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	settings, err := h.zakatService.UpdateSettings(ctx, c.GetString("user_id"), *req.OptedIn, req.SadaqahPercentage)
+	if err != nil {
+		h.zakatError(c, "Failed to update zakat settings", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Zakat settings updated",
+		Data: gin.H{
+			"settings": settings,
+		},
+	})
+}
+
+// GetZakatDeclarationsHandler lists the caller's declared assets and
+// liabilities
+func (h *Handler) GetZakatDeclarationsHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	declarations, err := h.db.GetZakatDeclarationsByUser(ctx, c.GetString("user_id"))
+	if err != nil {
+		h.zakatError(c, "Failed to get declarations", err)
+		return
+	}
+	if declarations == nil {
+		declarations = []*database.ZakatDeclaration{}
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Zakat declarations retrieved",
+		Data: gin.H{
+			"declarations": declarations,
+			"count":        len(declarations),
+		},
+	})
+}
+
+// CreateZakatDeclarationRequest declares an off-chain asset or liability
+type CreateZakatDeclarationRequest struct {
+	WalletAddress string  `json:"wallet_address" binding:"required"`
+	Kind          string  `json:"kind" binding:"required"`
+	Description   string  `json:"description" binding:"required"`
+	Amount        float64 `json:"amount" binding:"required"`
+}
+
+// CreateZakatDeclarationHandler records an asset or liability that adjusts
+// the zakatable amount of one of the caller's wallets
+func (h *Handler) CreateZakatDeclarationHandler(c *gin.Context) {
+	var req CreateZakatDeclarationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	if _, ok := h.authorizeWallet(ctx, c, req.WalletAddress); !ok {
+		return
+	}
+
+	declaration := &database.ZakatDeclaration{
+		UserID:        c.GetString("user_id"),
+		WalletAddress: req.WalletAddress,
+		Kind:          req.Kind,
+		Description:   utils.SanitizeInput(req.Description),
+		Amount:        req.Amount,
+	}
+	if err := h.zakatService.Declare(ctx, declaration); err != nil {
+		h.zakatError(c, "Failed to save declaration", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Status:  "success",
+		Message: "Zakat declaration saved",
+		Data: gin.H{
+			"declaration": declaration,
+		},
+	})
+}
+
+// DeleteZakatDeclarationHandler removes one of the caller's declarations
+func (h *Handler) DeleteZakatDeclarationHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	deleted, err := h.db.DeleteZakatDeclaration(ctx, c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		h.zakatError(c, "Failed to delete declaration", err)
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Declaration not found", Code: "NOT_FOUND"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Zakat declaration deleted",
+		Data: gin.H{
+			"id": c.Param("id"),
+		},
+	})
+}
+
+// GetZakatExemptionsHandler lists exempted users for admins and auditors
+func (h *Handler) GetZakatExemptionsHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	exemptions, err := h.db.GetZakatExemptions(ctx)
+	if err != nil {
+		h.zakatError(c, "Failed to get exemptions", err)
+		return
+	}
+	if exemptions == nil {
+		exemptions = []*database.ZakatExemption{}
+	}
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Zakat exemptions retrieved",
+		Data: gin.H{
+			"exemptions": exemptions,
+			"count":      len(exemptions),
+		},
+	})
+}
+
+// CreateZakatExemptionRequest exempts a user from zakat
+type CreateZakatExemptionRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+// CreateZakatExemptionHandler exempts a user's wallets from zakat
+func (h *Handler) CreateZakatExemptionHandler(c *gin.Context) {
+	var req CreateZakatExemptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request", Code: "INVALID_REQUEST"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	exemption, err := h.zakatService.Exempt(ctx, req.UserID, utils.SanitizeInput(req.Reason), c.GetString("user_id"))
+	if err != nil {
+		h.zakatError(c, "Failed to save exemption", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, SuccessResponse{
+		Status:  "success",
+		Message: "Zakat exemption granted",
+		Data: gin.H{
+			"exemption": exemption,
+		},
+	})
+}
+
+// DeleteZakatExemptionHandler revokes a user's exemption
+func (h *Handler) DeleteZakatExemptionHandler(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(requestContext(c), 10*time.Second)
+	defer cancel()
+
+	deleted, err := h.db.DeleteZakatExemption(ctx, c.Param("user_id"))
+	if err != nil {
+		h.zakatError(c, "Failed to revoke exemption", err)
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Exemption not found", Code: "NOT_FOUND"})
+		return
+	}
+
+	_ = h.auditService.Record(ctx, services.AuditEvent{
+		Type:    database.LogZakatExemptRevoked,
+		Message: fmt.Sprintf("Zakat exemption of user %s revoked by %s", c.Param("user_id"), c.GetString("user_id")),
+		Before:  gin.H{"user_id": c.Param("user_id"), "exempt": true},
+		After:   gin.H{"user_id": c.Param("user_id"), "exempt": false},
+	})
+
+	c.JSON(http.StatusOK, SuccessResponse{
+		Status:  "success",
+		Message: "Zakat exemption revoked",
+		Data: gin.H{
+			"user_id": c.Param("user_id"),
+		},
+	})
+}
@@ -9,31 +9,35 @@ import (
 
 // Block represents a single block in the blockchain
 type Block struct {
-	Index        int64          `json:"index"`
-	Timestamp    int64          `json:"timestamp"`
-	Transactions []Transaction  `json:"transactions"`
-	PreviousHash string         `json:"previous_hash"`
-	Nonce        int64          `json:"nonce"`
-	Hash         string         `json:"hash"`
-	MerkleRoot   string         `json:"merkle_root"`
-	Difficulty   int            `json:"difficulty"`
-	MinedBy      string         `json:"mined_by,omitempty"`
+	Index        int64         `json:"index"`
+	Timestamp    int64         `json:"timestamp"`
+	Transactions []Transaction `json:"transactions"`
+	PreviousHash string        `json:"previous_hash"`
+	Nonce        int64         `json:"nonce"`
+	Hash         string        `json:"hash"`
+	MerkleRoot   string        `json:"merkle_root"`
+	Difficulty   int           `json:"difficulty"`
+	MinedBy      string        `json:"mined_by,omitempty"`
 }
 
 // Transaction represents a transaction in the blockchain
 type Transaction struct {
-	ID             string        `json:"id"`
-	SenderWallet   string        `json:"sender_wallet"`
-	ReceiverWallet string        `json:"receiver_wallet"`
-	Amount         float64       `json:"amount"`
-	Fee            float64       `json:"fee"`
-	Note           string        `json:"note,omitempty"`
-	Timestamp      int64         `json:"timestamp"`
-	Signature      string        `json:"signature"`
-	PublicKey      string        `json:"public_key"`
-	UTXOInputs     []UTXO        `json:"utxo_inputs"`
-	UTXOOutputs    []UTXO        `json:"utxo_outputs"`
-	Status         string        `json:"status"` // pending, confirmed, failed
+	ID             string          `json:"id"`
+	SenderWallet   string          `json:"sender_wallet"`
+	ReceiverWallet string          `json:"receiver_wallet"`
+	Amount         float64         `json:"amount"`
+	Fee            float64         `json:"fee"`
+	Note           string          `json:"note,omitempty"`
+	Timestamp      int64           `json:"timestamp"`
+	Signature      string          `json:"signature"`
+	PublicKey      string          `json:"public_key"`
+	Signatures     []TxSignature   `json:"signatures,omitempty"` // multisig co-signer signatures
+	Multisig       *MultisigPolicy `json:"multisig,omitempty"`   // policy the multisig sender's address is derived from
+	LockTime       int64           `json:"lock_time,omitempty"`  // not valid in a block before this height/time
+	LockUntil      int64           `json:"lock_until,omitempty"` // receiver's output is unspendable before this height/time
+	UTXOInputs     []UTXO          `json:"utxo_inputs"`
+	UTXOOutputs    []UTXO          `json:"utxo_outputs"`
+	Status         string          `json:"status"` // pending, confirmed, failed
 }
 
 // UTXO represents an Unspent Transaction Output
@@ -44,6 +48,7 @@ type UTXO struct {
 	Amount          float64 `json:"amount"`
 	IsSpent         bool    `json:"is_spent"`
 	SpentInTx       string  `json:"spent_in_transaction,omitempty"`
+	LockUntil       int64   `json:"lock_until,omitempty"`
 }
 
 // NewBlock creates a new block
@@ -78,6 +83,18 @@ func NewTransaction(senderWallet, receiverWallet string, amount, fee float64, no
 	}
 }
 
+// SigningData returns the canonical representation of a transaction that the
+// sender signs and the node verifies. Lock values are appended only when set
+// so signatures over unlocked transactions keep their original form.
+func (tx *Transaction) SigningData() string {
+	data := fmt.Sprintf("%s:%s:%.8f:%.8f:%s:%d",
+		tx.SenderWallet, tx.ReceiverWallet, tx.Amount, tx.Fee, tx.Note, tx.Timestamp)
+	if tx.LockTime != 0 || tx.LockUntil != 0 {
+		data += fmt.Sprintf(":%d:%d", tx.LockTime, tx.LockUntil)
+	}
+	return data
+}
+
 // CalculateMerkleRoot calculates the Merkle root of transactions
 func calculateMerkleRoot(transactions []Transaction) string {
 	if len(transactions) == 0 {
@@ -111,7 +128,7 @@ func calculateMerkleRoot(transactions []Transaction) string {
 
 // hashTransaction hashes a transaction
 func hashTransaction(tx Transaction) string {
-	txData := tx.ID + tx.SenderWallet + tx.ReceiverWallet + 
+	txData := tx.ID + tx.SenderWallet + tx.ReceiverWallet +
 		floatToString(tx.Amount) + floatToString(tx.Fee) + tx.Signature
 	return hashData(txData)
 }
@@ -157,4 +174,3 @@ func CalculateMerkleRoot(txHashes []string) string {
 
 	return tree[0]
 }
-
@@ -1,22 +1,43 @@
 package blockchain
 
+import "time"
+
+// Params are the chain's consensus parameters
+type Params struct {
+	// Difficulty of the genesis block, which mined blocks carry forward
+	Difficulty int
+	// TargetBlockTime is the interval AdjustDifficulty aims for
+	TargetBlockTime time.Duration
+}
+
+// DefaultParams are used by NewBlockchain
+var DefaultParams = Params{Difficulty: 4, TargetBlockTime: time.Minute}
+
 // Blockchain represents the entire blockchain
 type Blockchain struct {
 	Chain  []*Block
 	Blocks map[string]*Block
 	UTXOs  map[string][]UTXO
+	Params Params
 }
 
 // NewBlockchain creates a new blockchain with genesis block
 func NewBlockchain() *Blockchain {
+	return NewBlockchainWithParams(DefaultParams)
+}
+
+// NewBlockchainWithParams creates a new blockchain with a genesis block
+// mined at the given difficulty
+func NewBlockchainWithParams(params Params) *Blockchain {
 	bc := &Blockchain{
 		Chain:  make([]*Block, 0),
 		Blocks: make(map[string]*Block),
 		UTXOs:  make(map[string][]UTXO),
+		Params: params,
 	}
 
 	// Create genesis block
-	genesisBlock := NewBlock(0, []Transaction{}, "0", 4)
+	genesisBlock := NewBlock(0, []Transaction{}, "0", params.Difficulty)
 	pow := NewProofOfWork(genesisBlock)
 	pow.Mine()
 
@@ -33,6 +54,13 @@ func (bc *Blockchain) AddBlock(block *Block) error {
 		}
 	}
 
+	if err := block.validateLocks(); err != nil {
+		return err
+	}
+	if err := block.validateMultisig(); err != nil {
+		return err
+	}
+
 	bc.Chain = append(bc.Chain, block)
 	bc.Blocks[block.Hash] = block
 	return nil
@@ -79,7 +107,23 @@ func (bc *Blockchain) MarkUTXOAsSpent(walletAddress, txHash string, outputIndex
 
 // ValidateChain validates the entire blockchain
 func (bc *Blockchain) ValidateChain() bool {
-	for i := 1; i < len(bc.Chain); i++ {
+	return bc.validateFrom(1)
+}
+
+// ValidateRecent validates the latest n blocks, for checks run too often to
+// walk the whole chain
+func (bc *Blockchain) ValidateRecent(n int) bool {
+	from := len(bc.Chain) - n
+	if from < 1 {
+		from = 1
+	}
+	return bc.validateFrom(from)
+}
+
+// validateFrom checks each block from position from onwards against the one
+// before it
+func (bc *Blockchain) validateFrom(from int) bool {
+	for i := from; i < len(bc.Chain); i++ {
 		block := bc.Chain[i]
 		previousBlock := bc.Chain[i-1]
 
@@ -91,10 +135,36 @@ func (bc *Blockchain) ValidateChain() bool {
 		if !pow.ValidateProof() {
 			return false
 		}
+
+		if block.validateLocks() != nil || block.validateMultisig() != nil {
+			return false
+		}
 	}
 	return true
 }
 
+// Height returns the index of the latest block, or -1 for an empty chain
+func (bc *Blockchain) Height() int64 {
+	latest := bc.GetLatestBlock()
+	if latest == nil {
+		return -1
+	}
+	return latest.Index
+}
+
+// GetSpendableUTXOs returns the unspent UTXOs of a wallet whose locks have
+// passed at the given time
+func (bc *Blockchain) GetSpendableUTXOs(walletAddress string, now int64) []UTXO {
+	var spendable []UTXO
+	height := bc.Height()
+	for _, utxo := range bc.UTXOs[walletAddress] {
+		if utxo.IsSpendable(height, now) {
+			spendable = append(spendable, utxo)
+		}
+	}
+	return spendable
+}
+
 // GetBalance calculates the balance of a wallet from UTXOs
 func (bc *Blockchain) GetBalance(walletAddress string) float64 {
 	var balance float64
@@ -3,10 +3,13 @@ package blockchain
 import "errors"
 
 var (
-	ErrInvalidPreviousHash = errors.New("invalid previous hash")
-	ErrInsufficientBalance = errors.New("insufficient balance")
-	ErrInvalidTransaction = errors.New("invalid transaction")
-	ErrUTXOAlreadySpent = errors.New("UTXO already spent")
-	ErrInvalidSignature = errors.New("invalid digital signature")
-	ErrInvalidWallet = errors.New("invalid wallet address")
+	ErrInvalidPreviousHash    = errors.New("invalid previous hash")
+	ErrInsufficientBalance    = errors.New("insufficient balance")
+	ErrInvalidTransaction     = errors.New("invalid transaction")
+	ErrUTXOAlreadySpent       = errors.New("UTXO already spent")
+	ErrInvalidSignature       = errors.New("invalid digital signature")
+	ErrInvalidWallet          = errors.New("invalid wallet address")
+	ErrInvalidMultisigPolicy  = errors.New("invalid multisig policy")
+	ErrInsufficientSignatures = errors.New("not enough valid co-signer signatures")
+	ErrTransactionNotFinal    = errors.New("transaction is time-locked")
 )
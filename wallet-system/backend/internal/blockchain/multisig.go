@@ -0,0 +1,110 @@
+package blockchain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"crypto-wallet-backend/internal/crypto"
+)
+
+// MaxMultisigKeys caps the number of co-signers on a multisig wallet
+const MaxMultisigKeys = 15
+
+// TxSignature is one co-signer's signature on a multisig transaction
+type TxSignature struct {
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+// MultisigPolicy describes an M-of-N multi-signature wallet
+type MultisigPolicy struct {
+	Threshold  int      `json:"threshold"`
+	PublicKeys []string `json:"public_keys"`
+}
+
+// NewMultisigPolicy validates an M-of-N policy and sorts its keys so the same
+// set of co-signers always yields the same address
+func NewMultisigPolicy(threshold int, publicKeys []string) (*MultisigPolicy, error) {
+	if len(publicKeys) < 2 || len(publicKeys) > MaxMultisigKeys {
+		return nil, fmt.Errorf("%w: need between 2 and %d public keys", ErrInvalidMultisigPolicy, MaxMultisigKeys)
+	}
+	if threshold < 1 || threshold > len(publicKeys) {
+		return nil, fmt.Errorf("%w: threshold must be between 1 and %d", ErrInvalidMultisigPolicy, len(publicKeys))
+	}
+
+	keys := make([]string, len(publicKeys))
+	copy(keys, publicKeys)
+	sort.Strings(keys)
+
+	for i := 1; i < len(keys); i++ {
+		if keys[i] == keys[i-1] {
+			return nil, fmt.Errorf("%w: duplicate public key", ErrInvalidMultisigPolicy)
+		}
+	}
+
+	return &MultisigPolicy{Threshold: threshold, PublicKeys: keys}, nil
+}
+
+// Address derives the wallet address of the policy from its threshold and
+// sorted public keys
+func (p *MultisigPolicy) Address() string {
+	return hashData(fmt.Sprintf("multisig:%d:%s", p.Threshold, strings.Join(p.PublicKeys, ",")))
+}
+
+// HasKey reports whether publicKey is one of the policy's co-signers
+func (p *MultisigPolicy) HasKey(publicKey string) bool {
+	i := sort.SearchStrings(p.PublicKeys, publicKey)
+	return i < len(p.PublicKeys) && p.PublicKeys[i] == publicKey
+}
+
+// CountValidSignatures counts signatures over data made by distinct
+// co-signers of the policy. Unknown keys, repeats and bad signatures are
+// ignored.
+func (p *MultisigPolicy) CountValidSignatures(data string, signatures []TxSignature) int {
+	seen := make(map[string]bool)
+	count := 0
+	for _, sig := range signatures {
+		if seen[sig.PublicKey] || !p.HasKey(sig.PublicKey) {
+			continue
+		}
+		valid, err := crypto.VerifySignature(data, sig.Signature, sig.PublicKey)
+		if err != nil || !valid {
+			continue
+		}
+		seen[sig.PublicKey] = true
+		count++
+	}
+	return count
+}
+
+// VerifyMultisig checks that the transaction spends from the policy's address
+// and carries at least Threshold valid signatures from distinct co-signers
+func (tx *Transaction) VerifyMultisig(p *MultisigPolicy) error {
+	if tx.SenderWallet != p.Address() {
+		return ErrInvalidWallet
+	}
+	if p.CountValidSignatures(tx.SigningData(), tx.Signatures) < p.Threshold {
+		return ErrInsufficientSignatures
+	}
+	return nil
+}
+
+// validateMultisig checks each multisig transaction in the block against the
+// policy it carries, which must derive the sending address, so a block cannot
+// spend a multisig wallet's funds without enough co-signers
+func (b *Block) validateMultisig() error {
+	for i := range b.Transactions {
+		tx := &b.Transactions[i]
+		if tx.Multisig == nil && len(tx.Signatures) == 0 {
+			continue
+		}
+		if tx.Multisig == nil {
+			return ErrInsufficientSignatures
+		}
+		if err := tx.VerifyMultisig(tx.Multisig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package blockchain
 
 import (
-	"fmt"
+	"context"
+	"log/slog"
+	"time"
 )
 
 // ProofOfWork represents a Proof-of-Work mining operation
@@ -9,6 +11,8 @@ type ProofOfWork struct {
 	Block      *Block
 	Difficulty int
 	Target     string
+	Hashes     int64         // hashes computed by the last Mine
+	Duration   time.Duration // time the last Mine took
 }
 
 // NewProofOfWork creates a new ProofOfWork instance
@@ -25,28 +29,49 @@ func NewProofOfWork(block *Block) *ProofOfWork {
 	}
 }
 
-// Mine performs the Proof-of-Work mining
+// cancelCheckInterval is how many nonces MineContext tries between checks
+// for cancellation
+const cancelCheckInterval = 10000
+
+// Mine performs the Proof-of-Work mining, logging through the default slog
+// logger
 func (pow *ProofOfWork) Mine() {
+	_ = pow.MineContext(context.Background())
+}
+
+// MineContext mines like Mine but gives up once ctx is done, returning its
+// error and leaving the block's hash unset
+func (pow *ProofOfWork) MineContext(ctx context.Context) error {
 	var nonce int64 = 0
 	var hash string
+	start := time.Now()
 
-	fmt.Printf("Mining block with difficulty %d...\n", pow.Difficulty)
+	slog.Debug("Mining block", "index", pow.Block.Index, "difficulty", pow.Difficulty)
 
 	for {
 		pow.Block.Nonce = nonce
 		hash = pow.Block.CalculateHash()
 
 		if hash[:pow.Difficulty] == pow.Target {
-			fmt.Printf("Block mined! Hash: %s, Nonce: %d\n", hash, nonce)
+			slog.Info("Block mined", "index", pow.Block.Index, "hash", hash, "nonce", nonce)
 			pow.Block.Hash = hash
 			pow.Block.Nonce = nonce
-			break
+			pow.Hashes, pow.Duration = nonce+1, time.Since(start)
+			return nil
 		}
 
 		nonce++
 
+		if nonce%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				slog.Info("Mining abandoned", "index", pow.Block.Index, "nonce", nonce, "reason", err)
+				pow.Hashes, pow.Duration = nonce, time.Since(start)
+				return err
+			}
+		}
+
 		if nonce%100000 == 0 {
-			fmt.Printf("Nonce: %d, Hash: %s\n", nonce, hash)
+			slog.Debug("Mining in progress", "index", pow.Block.Index, "nonce", nonce)
 		}
 	}
 }
@@ -58,10 +83,11 @@ func (pow *ProofOfWork) ValidateProof() bool {
 	return hash[:pow.Difficulty] == pow.Target
 }
 
-// AdjustDifficulty adjusts the difficulty based on mining time
-func AdjustDifficulty(previousDifficulty int, previousBlock *Block, currentTime int64) int {
+// AdjustDifficulty adjusts the difficulty based on mining time, aiming for
+// a block every target
+func AdjustDifficulty(previousDifficulty int, previousBlock *Block, currentTime int64, target time.Duration) int {
 	miningTime := currentTime - previousBlock.Timestamp
-	targetTime := int64(60) // 1 minute target mining time
+	targetTime := int64(target / time.Second)
 
 	// If mining took longer than target, decrease difficulty
 	if miningTime > targetTime {
@@ -0,0 +1,40 @@
+package blockchain
+
+// LockTimeThreshold separates the two meanings of a lock value, following
+// Bitcoin's nLockTime convention: values below it are block heights, values
+// at or above it are Unix timestamps. Zero means unlocked.
+const LockTimeThreshold = 500000000
+
+// IsLockSatisfied reports whether a lock-time or lock-until value has passed
+// at the given chain height and Unix time
+func IsLockSatisfied(lock int64, height int64, now int64) bool {
+	if lock <= 0 {
+		return true
+	}
+	if lock < LockTimeThreshold {
+		return height >= lock
+	}
+	return now >= lock
+}
+
+// IsFinal reports whether the transaction may be included in a block at the
+// given height and time
+func (tx *Transaction) IsFinal(height int64, now int64) bool {
+	return IsLockSatisfied(tx.LockTime, height, now)
+}
+
+// IsSpendable reports whether the output is unspent and its lock has passed
+func (u UTXO) IsSpendable(height int64, now int64) bool {
+	return !u.IsSpent && IsLockSatisfied(u.LockUntil, height, now)
+}
+
+// validateLocks checks that every transaction in the block is final at the
+// block's height and timestamp
+func (b *Block) validateLocks() error {
+	for i := range b.Transactions {
+		if !b.Transactions[i].IsFinal(b.Index, b.Timestamp) {
+			return ErrTransactionNotFinal
+		}
+	}
+	return nil
+}
@@ -2,21 +2,16 @@ package crypto
 
 import (
 	"crypto/rand"
+	"fmt"
 	"math/big"
 )
 
-// GenerateOTP generates a random 6-digit OTP
-func GenerateOTP() string {
-	max := big.NewInt(1000000)
-	randomNum, err := rand.Int(rand.Reader, max)
+// GenerateOTP generates a random 6-digit OTP, keeping leading zeros so every
+// code in 000000-999999 is equally likely
+func GenerateOTP() (string, error) {
+	randomNum, err := rand.Int(rand.Reader, big.NewInt(1000000))
 	if err != nil {
-		return "000000"
+		return "", err
 	}
-
-	otp := randomNum.Int64()
-	if otp < 100000 {
-		otp += 100000
-	}
-
-	return string(rune(otp))
+	return fmt.Sprintf("%06d", randomNum.Int64()), nil
 }
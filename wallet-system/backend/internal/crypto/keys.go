@@ -9,6 +9,7 @@ import (
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"io"
 )
 
@@ -45,6 +46,28 @@ func GenerateKeyPair() (*KeyPair, error) {
 	}, nil
 }
 
+// PublicKeyFromPrivateKey derives the base64 PKIX public key of a base64
+// PKCS1 private key
+func PublicKeyFromPrivateKey(privateKeyBase64 string) (string, error) {
+	privateKeyBytes, err := base64.StdEncoding.DecodeString(privateKeyBase64)
+	if err != nil {
+		return "", err
+	}
+	defer ZeroBytes(privateKeyBytes)
+
+	privateKey, err := x509.ParsePKCS1PrivateKey(privateKeyBytes)
+	if err != nil {
+		return "", err
+	}
+	defer zeroPrivateKey(privateKey)
+
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(publicKeyBytes), nil
+}
+
 // GenerateWalletID generates a wallet ID from public key
 func GenerateWalletID(publicKey string) string {
 	hash := sha256.Sum256([]byte(publicKey))
@@ -53,6 +76,12 @@ func GenerateWalletID(publicKey string) string {
 
 // EncryptPrivateKey encrypts a private key with a password
 func EncryptPrivateKey(privateKey, password string) (string, error) {
+	return EncryptPrivateKeyBytes([]byte(privateKey), password)
+}
+
+// EncryptPrivateKeyBytes encrypts private key material held in a byte slice
+// with a password
+func EncryptPrivateKeyBytes(privateKey []byte, password string) (string, error) {
 	key := sha256.Sum256([]byte(password))
 	block, err := aes.NewCipher(key[:32])
 	if err != nil {
@@ -69,35 +98,51 @@ func EncryptPrivateKey(privateKey, password string) (string, error) {
 		return "", err
 	}
 
-	ciphertext := gcm.Seal(nonce, nonce, []byte(privateKey), nil)
+	ciphertext := gcm.Seal(nonce, nonce, privateKey, nil)
 	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
 // DecryptPrivateKey decrypts a private key with a password
 func DecryptPrivateKey(encryptedKey, password string) (string, error) {
+	plaintext, err := DecryptPrivateKeyBytes(encryptedKey, password)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// DecryptPrivateKeyBytes decrypts a private key with a password and returns
+// it as a byte slice so callers can wipe it with ZeroBytes after use
+func DecryptPrivateKeyBytes(encryptedKey, password string) ([]byte, error) {
 	key := sha256.Sum256([]byte(password))
 	block, err := aes.NewCipher(key[:32])
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	gcm, err := cipher.NewGCM(block)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	data, err := base64.StdEncoding.DecodeString(encryptedKey)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return "", err
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("encrypted key is too short")
 	}
 
-	return string(plaintext), nil
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// ZeroBytes overwrites key material in place
+func ZeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
 }
 
 // SHA256Hash hashes data using SHA256
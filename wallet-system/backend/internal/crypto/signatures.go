@@ -6,19 +6,30 @@ import (
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
+	"errors"
 )
 
 // SignTransaction signs a transaction with a private key
 func SignTransaction(data string, privateKeyBase64 string) (string, error) {
-	privateKeyBytes, err := base64.StdEncoding.DecodeString(privateKeyBase64)
+	return SignTransactionWithKey(data, []byte(privateKeyBase64))
+}
+
+// SignTransactionWithKey signs a transaction with a base64 encoded private key
+// held in a byte slice. The decoded key material is zeroed before returning.
+func SignTransactionWithKey(data string, privateKeyBase64 []byte) (string, error) {
+	privateKeyBytes := make([]byte, base64.StdEncoding.DecodedLen(len(privateKeyBase64)))
+	defer ZeroBytes(privateKeyBytes)
+
+	n, err := base64.StdEncoding.Decode(privateKeyBytes, privateKeyBase64)
 	if err != nil {
 		return "", err
 	}
 
-	privateKey, err := x509.ParsePKCS1PrivateKey(privateKeyBytes)
+	privateKey, err := x509.ParsePKCS1PrivateKey(privateKeyBytes[:n])
 	if err != nil {
 		return "", err
 	}
+	defer zeroPrivateKey(privateKey)
 
 	hash := sha256.Sum256([]byte(data))
 	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, 0, hash[:])
@@ -29,6 +40,19 @@ func SignTransaction(data string, privateKeyBase64 string) (string, error) {
 	return base64.StdEncoding.EncodeToString(signature), nil
 }
 
+// zeroPrivateKey clears the secret components of a parsed RSA key
+func zeroPrivateKey(key *rsa.PrivateKey) {
+	key.D.SetInt64(0)
+	for _, p := range key.Primes {
+		p.SetInt64(0)
+	}
+	if key.Precomputed.Dp != nil {
+		key.Precomputed.Dp.SetInt64(0)
+		key.Precomputed.Dq.SetInt64(0)
+		key.Precomputed.Qinv.SetInt64(0)
+	}
+}
+
 // VerifySignature verifies a transaction signature
 func VerifySignature(data string, signatureBase64 string, publicKeyBase64 string) (bool, error) {
 	signature, err := base64.StdEncoding.DecodeString(signatureBase64)
@@ -46,7 +70,10 @@ func VerifySignature(data string, signatureBase64 string, publicKeyBase64 string
 		return false, err
 	}
 
-	rsaPublicKey := publicKey.(*rsa.PublicKey)
+	rsaPublicKey, ok := publicKey.(*rsa.PublicKey)
+	if !ok {
+		return false, errors.New("public key is not an RSA key")
+	}
 	hash := sha256.Sum256([]byte(data))
 
 	err = rsa.VerifyPKCS1v15(rsaPublicKey, 0, hash[:], signature)
@@ -0,0 +1,272 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// GenesisAuditHash is the previous hash of the first chained system log
+const GenesisAuditHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// maxAppendAttempts bounds how often a writer retries after losing the race
+// for the next sequence number
+const maxAppendAttempts = 10
+
+// AuditAnchor records a system log hash sealed into a mined block
+type AuditAnchor struct {
+	ID         string    `json:"id"`
+	Sequence   int64     `json:"sequence"`
+	EntryHash  string    `json:"entry_hash"`
+	BlockHash  string    `json:"block_hash"`
+	BlockIndex int64     `json:"block_index"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// auditHashInput is what an entry's hash covers, in a fixed field order
+type auditHashInput struct {
+	Sequence      int64           `json:"sequence"`
+	PrevHash      string          `json:"prev_hash"`
+	LogType       string          `json:"log_type"`
+	Message       string          `json:"message"`
+	ActorID       string          `json:"actor_id"`
+	WalletAddress string          `json:"wallet_address"`
+	IPAddress     string          `json:"ip_address"`
+	UserAgent     string          `json:"user_agent"`
+	RequestID     string          `json:"request_id"`
+	Before        json.RawMessage `json:"before"`
+	After         json.RawMessage `json:"after"`
+	CreatedAt     int64           `json:"created_at"`
+}
+
+// CalculateHash returns the entry's chain hash: SHA-256 over its previous
+// hash and content. Values are canonicalised first, so an entry read back
+// from the database hashes the same as when it was written.
+func (l *SystemLog) CalculateHash() (string, error) {
+	before, err := canonicalJSON(l.Before)
+	if err != nil {
+		return "", err
+	}
+	after, err := canonicalJSON(l.After)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(auditHashInput{
+		Sequence:      l.Sequence,
+		PrevHash:      l.PrevHash,
+		LogType:       l.LogType,
+		Message:       l.Message,
+		ActorID:       strings.ToLower(l.ActorID),
+		WalletAddress: l.WalletAddress,
+		IPAddress:     canonicalIP(l.IPAddress),
+		UserAgent:     l.UserAgent,
+		RequestID:     l.RequestID,
+		Before:        before,
+		After:         after,
+		CreatedAt:     l.CreatedAt.UnixMicro(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(append([]byte(l.PrevHash), data...))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// canonicalJSON re-encodes raw with sorted keys and no spacing, the form
+// JSONB hands back whatever was stored
+func canonicalJSON(raw json.RawMessage) (json.RawMessage, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// canonicalIP formats an address the way it reads back from an INET column,
+// dropping anything that is not an address
+func canonicalIP(s string) string {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return ""
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4.String()
+	}
+	return ip.String()
+}
+
+// CreateSystemLog appends an entry to the audit chain, taking the next
+// sequence number and linking to the hash of the entry before it. Writers
+// racing for a sequence number collide on its unique constraint; the loser
+// retries on the new head.
+func (d *Database) CreateSystemLog(ctx context.Context, log *SystemLog) error {
+	var err error
+	if log.Before, err = canonicalJSON(log.Before); err != nil {
+		return err
+	}
+	if log.After, err = canonicalJSON(log.After); err != nil {
+		return err
+	}
+	log.ActorID = strings.ToLower(log.ActorID)
+	log.IPAddress = canonicalIP(log.IPAddress)
+	log.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	query := `
+		INSERT INTO system_logs (log_type, message, actor_id, wallet_address, ip_address, user_agent, request_id,
+			before_state, after_state, sequence, prev_hash, entry_hash, created_at)
+		VALUES ($1, $2, NULLIF($3, '')::uuid, NULLIF($4, ''), NULLIF($5, '')::inet, NULLIF($6, ''), NULLIF($7, ''),
+			$8, $9, $10, $11, $12, $13)
+		RETURNING id
+	`
+
+	for attempt := 1; ; attempt++ {
+		head, err := d.GetAuditHead(ctx)
+		if err != nil {
+			return err
+		}
+		log.Sequence, log.PrevHash = 1, GenesisAuditHash
+		if head != nil {
+			log.Sequence, log.PrevHash = head.Sequence+1, head.EntryHash
+		}
+		if log.EntryHash, err = log.CalculateHash(); err != nil {
+			return err
+		}
+
+		err = d.db.QueryRowContext(ctx, query,
+			log.LogType, log.Message, log.ActorID, log.WalletAddress, log.IPAddress, log.UserAgent, log.RequestID,
+			nullJSON(log.Before), nullJSON(log.After), log.Sequence, log.PrevHash, log.EntryHash, log.CreatedAt,
+		).Scan(&log.ID)
+		if isUniqueViolation(err) && attempt < maxAppendAttempts {
+			continue
+		}
+		return err
+	}
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// chainedLogColumns are the columns scanned by scanChainedLog
+const chainedLogColumns = `
+	id, log_type, message, COALESCE(actor_id::text, ''), COALESCE(wallet_address, ''),
+	COALESCE(host(ip_address), ''), COALESCE(user_agent, ''), COALESCE(request_id, ''),
+	before_state, after_state, sequence, prev_hash, entry_hash, created_at
+`
+
+func scanChainedLog(row interface{ Scan(...interface{}) error }) (*SystemLog, error) {
+	log := &SystemLog{}
+	var before, after []byte
+	err := row.Scan(&log.ID, &log.LogType, &log.Message, &log.ActorID, &log.WalletAddress,
+		&log.IPAddress, &log.UserAgent, &log.RequestID, &before, &after,
+		&log.Sequence, &log.PrevHash, &log.EntryHash, &log.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	log.Before, log.After = before, after
+	return log, nil
+}
+
+// GetAuditHead returns the latest chained system log, or nil if there is none
+func (d *Database) GetAuditHead(ctx context.Context) (*SystemLog, error) {
+	row := d.db.QueryRowContext(ctx, `SELECT `+chainedLogColumns+`
+		FROM system_logs WHERE sequence IS NOT NULL ORDER BY sequence DESC LIMIT 1`)
+	log, err := scanChainedLog(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return log, err
+}
+
+// GetChainedSystemLogs returns up to limit chained system logs after the
+// given sequence number, in chain order
+func (d *Database) GetChainedSystemLogs(ctx context.Context, afterSequence int64, limit int) ([]SystemLog, error) {
+	rows, err := d.db.QueryContext(ctx, `SELECT `+chainedLogColumns+`
+		FROM system_logs WHERE sequence > $1 ORDER BY sequence LIMIT $2`, afterSequence, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []SystemLog
+	for rows.Next() {
+		log, err := scanChainedLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, *log)
+	}
+	return logs, rows.Err()
+}
+
+// CountUnchainedSystemLogs counts entries written before the log was chained
+func (d *Database) CountUnchainedSystemLogs(ctx context.Context) (int64, error) {
+	var count int64
+	err := d.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM system_logs WHERE sequence IS NULL`).Scan(&count)
+	return count, err
+}
+
+// CreateAuditAnchor records that a system log hash was sealed into a block
+func (d *Database) CreateAuditAnchor(ctx context.Context, anchor *AuditAnchor) error {
+	query := `
+		INSERT INTO audit_anchors (sequence, entry_hash, block_hash, block_index)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	return d.db.QueryRowContext(ctx, query,
+		anchor.Sequence, anchor.EntryHash, anchor.BlockHash, anchor.BlockIndex,
+	).Scan(&anchor.ID, &anchor.CreatedAt)
+}
+
+// GetLatestAuditAnchor returns the anchor covering the most entries, or nil
+func (d *Database) GetLatestAuditAnchor(ctx context.Context) (*AuditAnchor, error) {
+	anchor := &AuditAnchor{}
+	err := d.db.QueryRowContext(ctx, `
+		SELECT id, sequence, entry_hash, block_hash, block_index, created_at
+		FROM audit_anchors ORDER BY sequence DESC, created_at DESC LIMIT 1
+	`).Scan(&anchor.ID, &anchor.Sequence, &anchor.EntryHash, &anchor.BlockHash, &anchor.BlockIndex, &anchor.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return anchor, err
+}
+
+// GetAuditAnchors returns every anchor in sequence order
+func (d *Database) GetAuditAnchors(ctx context.Context) ([]AuditAnchor, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT id, sequence, entry_hash, block_hash, block_index, created_at
+		FROM audit_anchors ORDER BY sequence, created_at
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var anchors []AuditAnchor
+	for rows.Next() {
+		var a AuditAnchor
+		if err := rows.Scan(&a.ID, &a.Sequence, &a.EntryHash, &a.BlockHash, &a.BlockIndex, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		anchors = append(anchors, a)
+	}
+	return anchors, rows.Err()
+}
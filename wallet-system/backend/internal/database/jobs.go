@@ -0,0 +1,208 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// LastJobRun returns the latest scheduled time recorded for a job. Manual
+// runs and reruns are ignored so they do not shift the schedule.
+func (d *Database) LastJobRun(ctx context.Context, name string) (time.Time, bool, error) {
+	var last sql.NullTime
+	err := d.db.QueryRowContext(ctx,
+		`SELECT MAX(scheduled_for) FROM job_runs WHERE job_name = $1 AND trigger_type = 'schedule'`, name,
+	).Scan(&last)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return last.Time, last.Valid, nil
+}
+
+// StartJobRun records that a job occurrence started
+func (d *Database) StartJobRun(ctx context.Context, name string, scheduledFor time.Time) (string, error) {
+	query := `
+		INSERT INTO job_runs (job_name, scheduled_for, status)
+		VALUES ($1, $2, 'running')
+		RETURNING id
+	`
+
+	var id string
+	err := d.db.QueryRowContext(ctx, query, name, scheduledFor).Scan(&id)
+	return id, err
+}
+
+// CreateJobRun records the start of a manually triggered job run
+func (d *Database) CreateJobRun(ctx context.Context, run *JobRun) error {
+	query := `
+		INSERT INTO job_runs (job_name, scheduled_for, status, trigger_type, params, rerun_of)
+		VALUES ($1, $2, 'running', $3, $4, NULLIF($5, '')::uuid)
+		RETURNING id, started_at
+	`
+
+	var params interface{}
+	if len(run.Params) > 0 {
+		params = []byte(run.Params)
+	}
+
+	run.Status = "running"
+	return d.db.QueryRowContext(ctx, query,
+		run.JobName, run.ScheduledFor, run.Trigger, params, run.RerunOf,
+	).Scan(&run.ID, &run.StartedAt)
+}
+
+// SetJobRunParams records the parameters a job run used
+func (d *Database) SetJobRunParams(ctx context.Context, id string, params []byte) error {
+	_, err := d.db.ExecContext(ctx, `UPDATE job_runs SET params = $1 WHERE id = $2`, params, id)
+	return err
+}
+
+// AddJobRunItems records per-subject outcomes of a job run
+func (d *Database) AddJobRunItems(ctx context.Context, runID string, items []*JobRunItem) error {
+	query := `
+		INSERT INTO job_run_items (job_run_id, subject, outcome, amount, transaction_hash, detail)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''))
+	`
+
+	for _, item := range items {
+		if _, err := d.db.ExecContext(ctx, query,
+			runID, item.Subject, item.Outcome, item.Amount, item.TransactionHash, item.Detail,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const jobRunColumns = `id, job_name, trigger_type, params, COALESCE(rerun_of::text, ''),
+	scheduled_for, started_at, finished_at, status, COALESCE(error, '')`
+
+func scanJobRun(row interface{ Scan(...interface{}) error }) (*JobRun, error) {
+	run := &JobRun{}
+	var params []byte
+	var finishedAt sql.NullTime
+	err := row.Scan(&run.ID, &run.JobName, &run.Trigger, &params, &run.RerunOf,
+		&run.ScheduledFor, &run.StartedAt, &finishedAt, &run.Status, &run.Error)
+	if err != nil {
+		return nil, err
+	}
+	if len(params) > 0 {
+		run.Params = params
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+	return run, nil
+}
+
+// GetJobRuns returns recent job runs, newest first, optionally filtered by
+// job name and status
+func (d *Database) GetJobRuns(ctx context.Context, name, status string, limit int) ([]*JobRun, error) {
+	query := `SELECT ` + jobRunColumns + `
+		FROM job_runs
+		WHERE ($1 = '' OR job_name = $1) AND ($2 = '' OR status = $2)
+		ORDER BY started_at DESC
+		LIMIT $3
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, name, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*JobRun
+	for rows.Next() {
+		run, err := scanJobRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// GetJobRun returns a job run with its per-subject outcomes, or nil if it
+// does not exist
+func (d *Database) GetJobRun(ctx context.Context, id string) (*JobRun, error) {
+	run, err := scanJobRun(d.db.QueryRowContext(ctx,
+		`SELECT `+jobRunColumns+` FROM job_runs WHERE id::text = $1`, id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT subject, outcome, amount, COALESCE(transaction_hash, ''), COALESCE(detail, '')
+		FROM job_run_items
+		WHERE job_run_id = $1
+		ORDER BY created_at, subject
+	`, run.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	run.Items = []*JobRunItem{}
+	for rows.Next() {
+		item := &JobRunItem{}
+		if err := rows.Scan(&item.Subject, &item.Outcome, &item.Amount, &item.TransactionHash, &item.Detail); err != nil {
+			return nil, err
+		}
+		run.Items = append(run.Items, item)
+	}
+	return run, rows.Err()
+}
+
+// FinishJobRun records the outcome of a job occurrence
+func (d *Database) FinishJobRun(ctx context.Context, id, status, errMsg string) error {
+	query := `
+		UPDATE job_runs SET status = $1, error = NULLIF($2, ''), finished_at = NOW()
+		WHERE id = $3
+	`
+
+	_, err := d.db.ExecContext(ctx, query, status, errMsg, id)
+	return err
+}
+
+// DeleteJobRunsBefore removes finished job runs started before cutoff
+func (d *Database) DeleteJobRunsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := d.db.ExecContext(ctx,
+		`DELETE FROM job_runs WHERE started_at < $1 AND status <> 'running'`, cutoff,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// TryLock takes a Postgres session-level advisory lock on key without
+// waiting. The lock lives on a dedicated connection that release unlocks and
+// returns to the pool.
+func (d *Database) TryLock(ctx context.Context, key string) (func(), bool, error) {
+	conn, err := d.pool.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, key).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !locked {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	release := func() {
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, _ = conn.ExecContext(unlockCtx, `SELECT pg_advisory_unlock(hashtext($1))`, key)
+		conn.Close()
+	}
+	return release, true, nil
+}
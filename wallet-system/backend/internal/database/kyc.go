@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+const kycSubmissionColumns = `
+	id, user_id, cnic, full_name, COALESCE(father_name, ''), TO_CHAR(date_of_birth, 'YYYY-MM-DD'),
+	gender, region, status, COALESCE(review_notes, ''), reviewed_by, reviewed_at, created_at
+`
+
+func scanKYCSubmission(row interface{ Scan(...interface{}) error }) (*KYCSubmission, error) {
+	s := &KYCSubmission{}
+	var reviewedBy sql.NullString
+	var reviewedAt sql.NullTime
+	err := row.Scan(&s.ID, &s.UserID, &s.CNIC, &s.FullName, &s.FatherName, &s.DateOfBirth,
+		&s.Gender, &s.Region, &s.Status, &s.ReviewNotes, &reviewedBy, &reviewedAt, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if reviewedBy.Valid {
+		s.ReviewedBy = &reviewedBy.String
+	}
+	if reviewedAt.Valid {
+		s.ReviewedAt = &reviewedAt.Time
+	}
+	return s, nil
+}
+
+// CreateKYCSubmission records a pending submission and its documents
+func (d *Database) CreateKYCSubmission(ctx context.Context, sub *KYCSubmission) error {
+	query := `
+		INSERT INTO kyc_submissions (user_id, cnic, full_name, father_name, date_of_birth, gender, region, status)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, 'pending')
+		RETURNING id, status, created_at
+	`
+
+	err := d.db.QueryRowContext(ctx, query,
+		sub.UserID, sub.CNIC, sub.FullName, sub.FatherName, sub.DateOfBirth, sub.Gender, sub.Region,
+	).Scan(&sub.ID, &sub.Status, &sub.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, doc := range sub.Documents {
+		doc.SubmissionID = sub.ID
+		err := d.db.QueryRowContext(ctx, `
+			INSERT INTO kyc_documents (submission_id, kind, storage_path, content_type, size_bytes, sha256)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, created_at
+		`, doc.SubmissionID, doc.Kind, doc.StoragePath, doc.ContentType, doc.SizeBytes, doc.SHA256,
+		).Scan(&doc.ID, &doc.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetKYCSubmission returns a submission with its documents, or nil if missing
+func (d *Database) GetKYCSubmission(ctx context.Context, id string) (*KYCSubmission, error) {
+	sub, err := scanKYCSubmission(d.db.QueryRowContext(ctx,
+		`SELECT `+kycSubmissionColumns+` FROM kyc_submissions WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sub.Documents, err = d.getKYCDocuments(ctx, id)
+	return sub, err
+}
+
+// GetLatestKYCSubmission returns a user's most recent submission, or nil
+func (d *Database) GetLatestKYCSubmission(ctx context.Context, userID string) (*KYCSubmission, error) {
+	sub, err := scanKYCSubmission(d.db.QueryRowContext(ctx,
+		`SELECT `+kycSubmissionColumns+` FROM kyc_submissions WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1`, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return sub, err
+}
+
+// GetKYCSubmissions lists submissions oldest first, so the review queue is
+// worked in the order users submitted. An empty status lists all of them.
+func (d *Database) GetKYCSubmissions(ctx context.Context, status string, limit, offset int) ([]*KYCSubmission, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT `+kycSubmissionColumns+`
+		FROM kyc_submissions
+		WHERE $1 = '' OR status = $1
+		ORDER BY created_at
+		LIMIT $2 OFFSET $3
+	`, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subs []*KYCSubmission
+	for rows.Next() {
+		sub, err := scanKYCSubmission(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// ReviewKYCSubmission moves a pending submission to approved or rejected,
+// reporting whether it was still pending
+func (d *Database) ReviewKYCSubmission(ctx context.Context, id, status, notes, reviewerID string) (bool, error) {
+	result, err := d.db.ExecContext(ctx, `
+		UPDATE kyc_submissions
+		SET status = $1, review_notes = NULLIF($2, ''), reviewed_by = $3, reviewed_at = NOW()
+		WHERE id = $4 AND status = 'pending'
+	`, status, notes, reviewerID, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (d *Database) getKYCDocuments(ctx context.Context, submissionID string) ([]*KYCDocument, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT id, submission_id, kind, storage_path, content_type, size_bytes, sha256, created_at
+		FROM kyc_documents
+		WHERE submission_id = $1
+		ORDER BY created_at
+	`, submissionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []*KYCDocument
+	for rows.Next() {
+		doc := &KYCDocument{}
+		if err := rows.Scan(&doc.ID, &doc.SubmissionID, &doc.Kind, &doc.StoragePath,
+			&doc.ContentType, &doc.SizeBytes, &doc.SHA256, &doc.CreatedAt); err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, rows.Err()
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// GetTransferLimits returns the limits of every tier
+func (d *Database) GetTransferLimits(ctx context.Context) ([]*TransferLimit, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT tier, per_transaction, daily, monthly, updated_at
+		FROM transfer_limits
+		ORDER BY tier
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var limits []*TransferLimit
+	for rows.Next() {
+		l := &TransferLimit{}
+		if err := rows.Scan(&l.Tier, &l.PerTransaction, &l.Daily, &l.Monthly, &l.UpdatedAt); err != nil {
+			return nil, err
+		}
+		limits = append(limits, l)
+	}
+	return limits, rows.Err()
+}
+
+// GetTransferLimit returns a tier's limits, or nil if the tier is unknown
+func (d *Database) GetTransferLimit(ctx context.Context, tier string) (*TransferLimit, error) {
+	l := &TransferLimit{}
+	err := d.db.QueryRowContext(ctx, `
+		SELECT tier, per_transaction, daily, monthly, updated_at
+		FROM transfer_limits WHERE tier = $1
+	`, tier).Scan(&l.Tier, &l.PerTransaction, &l.Daily, &l.Monthly, &l.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return l, err
+}
+
+// SaveTransferLimit creates or replaces a tier's limits
+func (d *Database) SaveTransferLimit(ctx context.Context, limit *TransferLimit) error {
+	query := `
+		INSERT INTO transfer_limits (tier, per_transaction, daily, monthly, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (tier) DO UPDATE
+		SET per_transaction = EXCLUDED.per_transaction, daily = EXCLUDED.daily,
+			monthly = EXCLUDED.monthly, updated_at = NOW()
+		RETURNING updated_at
+	`
+
+	return d.db.QueryRowContext(ctx, query,
+		limit.Tier, limit.PerTransaction, limit.Daily, limit.Monthly,
+	).Scan(&limit.UpdatedAt)
+}
+
+// LockUser locks a user's row until the transaction ends, serialising
+// transfers counted against the user's limits. Use it within InTx.
+func (d *Database) LockUser(ctx context.Context, userID string) error {
+	var id string
+	err := d.db.QueryRowContext(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
+
+// UpdateUserTier sets a user's limit tier, reporting whether the user exists
+func (d *Database) UpdateUserTier(ctx context.Context, userID, tier string) (bool, error) {
+	result, err := d.db.ExecContext(ctx, `UPDATE users SET tier = $1, updated_at = NOW() WHERE id = $2`, tier, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetOutgoingTotal sums the amounts a user has sent from all their wallets
+// since the given time. System-initiated zakat transfers are not counted.
+func (d *Database) GetOutgoingTotal(ctx context.Context, userID string, since time.Time) (float64, error) {
+	// created_at is a TIMESTAMP holding NOW() in the session time zone, so
+	// since is converted to that zone instead of relying on an implicit cast
+	query := `
+		SELECT COALESCE(SUM(t.amount), 0)
+		FROM transactions t
+		JOIN wallets w ON w.wallet_address = t.sender_wallet
+		WHERE w.user_id = $1
+		AND t.created_at >= ($2::timestamptz AT TIME ZONE current_setting('TimeZone'))
+		AND t.transaction_type NOT IN ('zakat', 'zakat_disbursement')
+	`
+
+	var total float64
+	err := d.db.QueryRowContext(ctx, query, userID, since).Scan(&total)
+	return total, err
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// GetLoginFailure returns the failed login record of email, or nil
+func (d *Database) GetLoginFailure(ctx context.Context, email string) (*LoginFailure, error) {
+	f := &LoginFailure{}
+	var lockedUntil sql.NullTime
+	err := d.db.QueryRowContext(ctx, `
+		SELECT email, failures, last_failure_at, locked_until FROM login_failures WHERE email = $1
+	`, email).Scan(&f.Email, &f.Failures, &f.LastFailureAt, &lockedUntil)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if lockedUntil.Valid {
+		f.LockedUntil = &lockedUntil.Time
+	}
+	return f, nil
+}
+
+// RecordLoginFailure counts a failed login for email and returns the new
+// count. Failures from before since are forgotten first.
+func (d *Database) RecordLoginFailure(ctx context.Context, email string, since time.Time) (int, error) {
+	var failures int
+	err := d.db.QueryRowContext(ctx, `
+		INSERT INTO login_failures (email, failures, last_failure_at)
+		VALUES ($1, 1, NOW())
+		ON CONFLICT (email) DO UPDATE
+		SET failures = CASE WHEN login_failures.last_failure_at < $2 THEN 1 ELSE login_failures.failures + 1 END,
+			last_failure_at = NOW()
+		RETURNING failures
+	`, email, since).Scan(&failures)
+	return failures, err
+}
+
+// LockLogin refuses logins for email until until
+func (d *Database) LockLogin(ctx context.Context, email string, until time.Time) error {
+	_, err := d.db.ExecContext(ctx, `UPDATE login_failures SET locked_until = $2 WHERE email = $1`, email, until)
+	return err
+}
+
+// ClearLoginFailures forgets the failed logins of email
+func (d *Database) ClearLoginFailures(ctx context.Context, email string) error {
+	_, err := d.db.ExecContext(ctx, `DELETE FROM login_failures WHERE email = $1`, email)
+	return err
+}
+
+// DeleteLoginFailuresBefore removes records whose last failure was before
+// cutoff and that are no longer locked
+func (d *Database) DeleteLoginFailuresBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := d.db.ExecContext(ctx, `
+		DELETE FROM login_failures
+		WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until < NOW())
+	`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
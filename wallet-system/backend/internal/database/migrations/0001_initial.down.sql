@@ -0,0 +1,30 @@
+-- Drops every table of the initial schema, and all data with it
+DROP TABLE IF EXISTS audit_anchors;
+DROP TABLE IF EXISTS job_run_items;
+DROP TABLE IF EXISTS job_runs;
+DROP TABLE IF EXISTS otp_codes;
+DROP TABLE IF EXISTS user_totp;
+DROP TABLE IF EXISTS login_failures;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS kyc_documents;
+DROP TABLE IF EXISTS kyc_submissions;
+DROP TABLE IF EXISTS transfer_limits;
+DROP TABLE IF EXISTS scheduled_transfers;
+DROP TABLE IF EXISTS multisig_approvals;
+DROP TABLE IF EXISTS multisig_proposals;
+DROP TABLE IF EXISTS multisig_signers;
+DROP TABLE IF EXISTS multisig_wallets;
+DROP TABLE IF EXISTS beneficiaries;
+DROP TABLE IF EXISTS system_logs;
+DROP TABLE IF EXISTS zakat_hawl;
+DROP TABLE IF EXISTS zakat_recipients;
+DROP TABLE IF EXISTS zakat_declarations;
+DROP TABLE IF EXISTS zakat_exemptions;
+DROP TABLE IF EXISTS zakat_settings;
+DROP TABLE IF EXISTS zakat_transaction_duplicates;
+DROP TABLE IF EXISTS zakat_transactions;
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS blocks;
+DROP TABLE IF EXISTS utxos;
+DROP TABLE IF EXISTS wallets;
+DROP TABLE IF EXISTS users;
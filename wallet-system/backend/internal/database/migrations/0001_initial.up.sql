@@ -0,0 +1,427 @@
+-- Initial schema of the crypto wallet system. Databases created by the
+-- former one-shot schema.sql adopt it unchanged: every statement is
+-- idempotent, and the upgrades before the indexes bring older copies up to
+-- date, so indexes can cover the columns they add.
+
+-- Users table
+CREATE TABLE IF NOT EXISTS users (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email VARCHAR(255) UNIQUE NOT NULL,
+    full_name VARCHAR(255),
+    cnic VARCHAR(20) UNIQUE,
+    wallet_id VARCHAR(64) UNIQUE NOT NULL,
+    public_key TEXT NOT NULL,
+    encrypted_private_key TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    is_verified BOOLEAN DEFAULT FALSE,
+    role VARCHAR(20) NOT NULL DEFAULT 'user',
+    tier VARCHAR(20) NOT NULL DEFAULT 'standard',
+    custody_mode VARCHAR(20) NOT NULL DEFAULT 'custodial'
+);
+
+-- Wallets table
+CREATE TABLE IF NOT EXISTS wallets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    wallet_address VARCHAR(64) UNIQUE NOT NULL,
+    balance_cache DECIMAL(20,8) DEFAULT 0,
+    last_updated TIMESTAMP DEFAULT NOW(),
+    zakat_deducted_this_month BOOLEAN DEFAULT FALSE,
+    wallet_type VARCHAR(20) NOT NULL DEFAULT 'standard',
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    status_reason TEXT
+);
+
+-- UTXO table (Unspent Transaction Outputs)
+CREATE TABLE IF NOT EXISTS utxos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_hash VARCHAR(64) NOT NULL,
+    output_index INTEGER NOT NULL,
+    wallet_address VARCHAR(64) NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    is_spent BOOLEAN DEFAULT FALSE,
+    spent_in_transaction VARCHAR(64),
+    created_at TIMESTAMP DEFAULT NOW(),
+    lock_until BIGINT NOT NULL DEFAULT 0,
+    UNIQUE(transaction_hash, output_index)
+);
+
+-- Blocks table
+CREATE TABLE IF NOT EXISTS blocks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    block_index INTEGER UNIQUE NOT NULL,
+    timestamp BIGINT NOT NULL,
+    previous_hash VARCHAR(64) NOT NULL,
+    hash VARCHAR(64) UNIQUE NOT NULL,
+    nonce BIGINT NOT NULL,
+    merkle_root VARCHAR(64),
+    difficulty INTEGER DEFAULT 4,
+    mined_by VARCHAR(64),
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Transactions table
+CREATE TABLE IF NOT EXISTS transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_hash VARCHAR(64) UNIQUE NOT NULL,
+    block_hash VARCHAR(64) REFERENCES blocks(hash),
+    sender_wallet VARCHAR(64) NOT NULL,
+    receiver_wallet VARCHAR(64) NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    fee DECIMAL(20,8) DEFAULT 0,
+    note TEXT,
+    signature TEXT NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending',
+    created_at TIMESTAMP DEFAULT NOW(),
+    transaction_type VARCHAR(20) DEFAULT 'transfer',
+    lock_time BIGINT NOT NULL DEFAULT 0,
+    lock_until BIGINT NOT NULL DEFAULT 0,
+    signed_at BIGINT NOT NULL DEFAULT 0,
+    signing_hash VARCHAR(64)
+);
+
+-- Zakat Transactions table
+CREATE TABLE IF NOT EXISTS zakat_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_address VARCHAR(64) NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    zakat_percentage DECIMAL(5,2) DEFAULT 2.5,
+    sadaqah_amount DECIMAL(20,8) NOT NULL DEFAULT 0,
+    transaction_hash VARCHAR(64) REFERENCES transactions(transaction_hash),
+    month_year VARCHAR(7) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Per-user zakat preferences; users without a row are opted in
+CREATE TABLE IF NOT EXISTS zakat_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    opted_in BOOLEAN NOT NULL DEFAULT TRUE,
+    sadaqah_percentage DECIMAL(5,2) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Users exempted from zakat by an admin
+CREATE TABLE IF NOT EXISTS zakat_exemptions (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    granted_by UUID REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Off-chain assets and liabilities declared against a wallet
+CREATE TABLE IF NOT EXISTS zakat_declarations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    wallet_address VARCHAR(64) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('asset', 'liability')),
+    description TEXT NOT NULL,
+    amount DECIMAL(20,8) NOT NULL CHECK (amount > 0),
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Registered recipients of zakat pool disbursements
+CREATE TABLE IF NOT EXISTS zakat_recipients (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    wallet_address VARCHAR(64) UNIQUE NOT NULL,
+    description TEXT,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Zakat hawl (lunar holding year) tracking per wallet
+CREATE TABLE IF NOT EXISTS zakat_hawl (
+    wallet_address VARCHAR(64) PRIMARY KEY,
+    hawl_start TIMESTAMP,
+    last_paid_at TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- System Logs table
+CREATE TABLE IF NOT EXISTS system_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    log_type VARCHAR(50) NOT NULL,
+    message TEXT NOT NULL,
+    actor_id UUID,
+    wallet_address VARCHAR(64),
+    ip_address INET,
+    user_agent TEXT,
+    request_id VARCHAR(64),
+    before_state JSONB,
+    after_state JSONB,
+    sequence BIGINT UNIQUE,
+    prev_hash VARCHAR(64),
+    entry_hash VARCHAR(64),
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Beneficiaries table
+CREATE TABLE IF NOT EXISTS beneficiaries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    beneficiary_wallet_id VARCHAR(64) NOT NULL,
+    nickname VARCHAR(100),
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(user_id, beneficiary_wallet_id)
+);
+
+-- Multisig wallets table (M-of-N)
+CREATE TABLE IF NOT EXISTS multisig_wallets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_address VARCHAR(64) UNIQUE NOT NULL REFERENCES wallets(wallet_address) ON DELETE CASCADE,
+    threshold INTEGER NOT NULL,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Multisig co-signers table
+CREATE TABLE IF NOT EXISTS multisig_signers (
+    multisig_wallet_id UUID REFERENCES multisig_wallets(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    public_key TEXT NOT NULL,
+    PRIMARY KEY (multisig_wallet_id, user_id)
+);
+
+-- Multisig spend proposals table
+CREATE TABLE IF NOT EXISTS multisig_proposals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_address VARCHAR(64) NOT NULL REFERENCES multisig_wallets(wallet_address) ON DELETE CASCADE,
+    receiver_wallet VARCHAR(64) NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    fee DECIMAL(20,8) DEFAULT 0,
+    note TEXT,
+    timestamp BIGINT NOT NULL,
+    proposed_by UUID REFERENCES users(id),
+    status VARCHAR(20) DEFAULT 'pending',
+    transaction_hash VARCHAR(64),
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Multisig co-signer approvals table
+CREATE TABLE IF NOT EXISTS multisig_approvals (
+    proposal_id UUID REFERENCES multisig_proposals(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    public_key TEXT NOT NULL,
+    signature TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (proposal_id, user_id)
+);
+
+-- Scheduled transfers table (pre-signed, submitted at execute_at)
+CREATE TABLE IF NOT EXISTS scheduled_transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    sender_wallet VARCHAR(64) NOT NULL,
+    receiver_wallet VARCHAR(64) NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    fee DECIMAL(20,8) DEFAULT 0,
+    note TEXT,
+    timestamp BIGINT NOT NULL,
+    execute_at TIMESTAMP NOT NULL,
+    lock_until BIGINT NOT NULL DEFAULT 0,
+    signature TEXT NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending',
+    transaction_hash VARCHAR(64),
+    error TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    executed_at TIMESTAMP
+);
+
+-- Outgoing transfer limits per user tier; 0 means no limit
+CREATE TABLE IF NOT EXISTS transfer_limits (
+    tier VARCHAR(20) PRIMARY KEY,
+    per_transaction DECIMAL(20,8) NOT NULL DEFAULT 0,
+    daily DECIMAL(20,8) NOT NULL DEFAULT 0,
+    monthly DECIMAL(20,8) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+INSERT INTO transfer_limits (tier, per_transaction, daily, monthly) VALUES
+    ('standard', 1000, 5000, 50000),
+    ('premium', 10000, 50000, 500000),
+    ('unverified', 100, 200, 1000)
+ON CONFLICT (tier) DO NOTHING;
+
+-- Identity verification requests awaiting or after review
+CREATE TABLE IF NOT EXISTS kyc_submissions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    cnic VARCHAR(15) NOT NULL,
+    full_name VARCHAR(255) NOT NULL,
+    father_name VARCHAR(255),
+    date_of_birth DATE NOT NULL,
+    gender VARCHAR(10) NOT NULL,
+    region VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    review_notes TEXT,
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Documents uploaded with a verification request, stored on local disk
+CREATE TABLE IF NOT EXISTS kyc_documents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    submission_id UUID NOT NULL REFERENCES kyc_submissions(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL,
+    storage_path TEXT NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    sha256 VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Login sessions; refresh tokens are stored as SHA-256 hashes and rotated on
+-- every refresh, keeping the previous hash to detect replay
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    refresh_hash VARCHAR(64) NOT NULL UNIQUE,
+    previous_hash VARCHAR(64),
+    user_agent TEXT,
+    ip_address VARCHAR(64),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ
+);
+
+-- Recent failed logins per email address and the lockout they led to
+CREATE TABLE IF NOT EXISTS login_failures (
+    email VARCHAR(255) PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMPTZ
+);
+
+-- Authenticator app (TOTP) enrolments; the secret is encrypted at rest
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    encrypted_secret TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    last_step BIGINT NOT NULL DEFAULT 0,
+    enabled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- One-time codes sent by email, stored as keyed hashes
+CREATE TABLE IF NOT EXISTS otp_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose VARCHAR(20) NOT NULL,
+    code_hash VARCHAR(64) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    consumed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Background job runs recorded by the scheduler or triggered by an admin
+CREATE TABLE IF NOT EXISTS job_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_name VARCHAR(100) NOT NULL,
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    started_at TIMESTAMPTZ DEFAULT NOW(),
+    finished_at TIMESTAMPTZ,
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    error TEXT,
+    trigger_type VARCHAR(20) NOT NULL DEFAULT 'schedule',
+    params JSONB,
+    rerun_of UUID REFERENCES job_runs(id) ON DELETE SET NULL
+);
+
+-- Per-subject outcomes of a job run, such as each wallet in a zakat run
+CREATE TABLE IF NOT EXISTS job_run_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_run_id UUID NOT NULL REFERENCES job_runs(id) ON DELETE CASCADE,
+    subject VARCHAR(255) NOT NULL,
+    outcome VARCHAR(20) NOT NULL,
+    amount DECIMAL(20,8) NOT NULL DEFAULT 0,
+    transaction_hash VARCHAR(255),
+    detail TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Audit log heads sealed into mined blocks, so the log cannot be rewritten
+-- without also rewriting the chain
+CREATE TABLE IF NOT EXISTS audit_anchors (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sequence BIGINT NOT NULL,
+    entry_hash VARCHAR(64) NOT NULL,
+    block_hash VARCHAR(64) NOT NULL REFERENCES blocks(hash),
+    block_index BIGINT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Upgrades for databases created from an earlier version of this script
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'user';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS signed_at BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS signing_hash VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS custody_mode VARCHAR(20) NOT NULL DEFAULT 'custodial';
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS wallet_type VARCHAR(20) NOT NULL DEFAULT 'standard';
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS lock_time BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS lock_until BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE utxos ADD COLUMN IF NOT EXISTS lock_until BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE zakat_transactions ADD COLUMN IF NOT EXISTS sadaqah_amount DECIMAL(20,8) NOT NULL DEFAULT 0;
+ALTER TABLE job_runs ADD COLUMN IF NOT EXISTS trigger_type VARCHAR(20) NOT NULL DEFAULT 'schedule';
+ALTER TABLE job_runs ADD COLUMN IF NOT EXISTS params JSONB;
+ALTER TABLE job_runs ADD COLUMN IF NOT EXISTS rerun_of UUID REFERENCES job_runs(id) ON DELETE SET NULL;
+ALTER TABLE system_logs ADD COLUMN IF NOT EXISTS actor_id UUID;
+ALTER TABLE system_logs ADD COLUMN IF NOT EXISTS request_id VARCHAR(64);
+ALTER TABLE system_logs ADD COLUMN IF NOT EXISTS before_state JSONB;
+ALTER TABLE system_logs ADD COLUMN IF NOT EXISTS after_state JSONB;
+ALTER TABLE system_logs ADD COLUMN IF NOT EXISTS sequence BIGINT UNIQUE;
+ALTER TABLE system_logs ADD COLUMN IF NOT EXISTS prev_hash VARCHAR(64);
+ALTER TABLE system_logs ADD COLUMN IF NOT EXISTS entry_hash VARCHAR(64);
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active';
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS status_reason TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tier VARCHAR(20) NOT NULL DEFAULT 'standard';
+
+-- Older databases may record more than one zakat deduction for a wallet in a
+-- month. Keep the earliest, and set the others aside in
+-- zakat_transaction_duplicates for review, so the unique index can be built.
+CREATE TABLE IF NOT EXISTS zakat_transaction_duplicates (LIKE zakat_transactions INCLUDING DEFAULTS);
+WITH ranked AS (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY wallet_address, month_year ORDER BY created_at, id) AS n
+    FROM zakat_transactions
+), moved AS (
+    DELETE FROM zakat_transactions
+    WHERE id IN (SELECT id FROM ranked WHERE n > 1)
+    RETURNING *
+)
+INSERT INTO zakat_transaction_duplicates SELECT * FROM moved;
+
+-- Create indexes for faster queries
+CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+CREATE INDEX IF NOT EXISTS idx_users_wallet_id ON users(wallet_id);
+CREATE INDEX IF NOT EXISTS idx_wallets_user_id ON wallets(user_id);
+CREATE INDEX IF NOT EXISTS idx_wallets_address ON wallets(wallet_address);
+CREATE INDEX IF NOT EXISTS idx_transactions_sender ON transactions(sender_wallet);
+CREATE INDEX IF NOT EXISTS idx_transactions_sender_created ON transactions(sender_wallet, created_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_receiver ON transactions(receiver_wallet);
+CREATE INDEX IF NOT EXISTS idx_transactions_hash ON transactions(transaction_hash);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_signing_hash ON transactions(signing_hash);
+CREATE INDEX IF NOT EXISTS idx_blocks_index ON blocks(block_index);
+CREATE INDEX IF NOT EXISTS idx_blocks_hash ON blocks(hash);
+CREATE INDEX IF NOT EXISTS idx_utxos_wallet ON utxos(wallet_address);
+CREATE INDEX IF NOT EXISTS idx_utxos_spent ON utxos(is_spent);
+CREATE INDEX IF NOT EXISTS idx_zakat_wallet ON zakat_transactions(wallet_address);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_zakat_wallet_month ON zakat_transactions(wallet_address, month_year);
+CREATE INDEX IF NOT EXISTS idx_zakat_declarations_wallet ON zakat_declarations(wallet_address);
+CREATE INDEX IF NOT EXISTS idx_job_runs_name ON job_runs(job_name, scheduled_for DESC);
+CREATE INDEX IF NOT EXISTS idx_job_run_items_run ON job_run_items(job_run_id);
+CREATE INDEX IF NOT EXISTS idx_kyc_submissions_status ON kyc_submissions(status, created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_kyc_submissions_pending ON kyc_submissions(user_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_kyc_documents_submission ON kyc_documents(submission_id);
+CREATE INDEX IF NOT EXISTS idx_system_logs_type ON system_logs(log_type, created_at);
+CREATE INDEX IF NOT EXISTS idx_system_logs_actor ON system_logs(actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_anchors_sequence ON audit_anchors(sequence);
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_previous_hash ON sessions(previous_hash);
+CREATE INDEX IF NOT EXISTS idx_otp_codes_user ON otp_codes(user_id, purpose);
+CREATE INDEX IF NOT EXISTS idx_beneficiaries_user ON beneficiaries(user_id);
+CREATE INDEX IF NOT EXISTS idx_multisig_signers_user ON multisig_signers(user_id);
+CREATE INDEX IF NOT EXISTS idx_multisig_proposals_wallet ON multisig_proposals(wallet_address);
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_due ON scheduled_transfers(status, execute_at);
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_user ON scheduled_transfers(user_id);
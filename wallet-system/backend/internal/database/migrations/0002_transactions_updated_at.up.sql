@@ -0,0 +1,5 @@
+-- UpdateTransactionStatus records when a transaction was confirmed or failed.
+-- Existing rows take their creation time rather than the time of migration.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP;
+UPDATE transactions SET updated_at = created_at WHERE updated_at IS NULL;
+ALTER TABLE transactions ALTER COLUMN updated_at SET DEFAULT NOW();
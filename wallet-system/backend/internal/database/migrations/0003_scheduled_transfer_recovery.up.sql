@@ -0,0 +1,9 @@
+-- Scheduled transfers record when they were claimed for execution, so those
+-- left executing by a server that stopped mid-run can be recovered
+ALTER TABLE scheduled_transfers ADD COLUMN IF NOT EXISTS executing_since TIMESTAMP;
+
+-- Transfers already stuck executing predate signing hashes, so whether their
+-- transaction was recorded cannot be told; fail them rather than risk a retry
+UPDATE scheduled_transfers
+SET status = 'failed', error = 'interrupted during execution; check the wallet history before rescheduling', executed_at = NOW()
+WHERE status = 'executing';
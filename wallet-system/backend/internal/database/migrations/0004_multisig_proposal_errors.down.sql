@@ -0,0 +1 @@
+ALTER TABLE multisig_proposals DROP COLUMN IF EXISTS error;
@@ -0,0 +1,3 @@
+-- Proposals whose broadcast failed after being claimed record why, as they
+-- may have moved funds and are not reopened for another broadcast
+ALTER TABLE multisig_proposals ADD COLUMN IF NOT EXISTS error TEXT;
@@ -0,0 +1,10 @@
+-- Proposals record when they were claimed for broadcast, so those left
+-- broadcasting by a server that stopped mid-broadcast can be recovered
+ALTER TABLE multisig_proposals ADD COLUMN IF NOT EXISTS broadcasting_since TIMESTAMPTZ;
+
+-- Proposals already stuck broadcasting predate signing hashes on multisig
+-- transactions, so whether theirs was recorded cannot be told; fail them
+-- rather than risk a second spend
+UPDATE multisig_proposals
+SET status = 'failed', error = 'interrupted during broadcast; check the wallet history before proposing the spend again'
+WHERE status = 'broadcasting';
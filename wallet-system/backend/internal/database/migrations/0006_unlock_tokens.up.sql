@@ -0,0 +1,12 @@
+-- Unlock tokens; each holds the user's private key re-encrypted under the
+-- token, which is stored only as a SHA-256 hash, so tokens outlive a restart
+-- and work on every instance
+CREATE TABLE IF NOT EXISTS unlock_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    sealed_key TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_unlock_tokens_expires ON unlock_tokens(expires_at);
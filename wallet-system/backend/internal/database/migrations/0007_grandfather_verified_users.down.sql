@@ -0,0 +1,3 @@
+-- Which users were grandfathered is not recorded, and verifications since
+-- approved look the same, so they are left verified
+SELECT 1;
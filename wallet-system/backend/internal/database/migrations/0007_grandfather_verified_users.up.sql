@@ -0,0 +1,13 @@
+-- Users who registered before identity verification existed count as
+-- verified, so they keep their tier's limits instead of dropping to the
+-- unverified tier. The initial migration introduced verification, so users
+-- created before it was applied predate it. Users whose verification was
+-- since rejected are left as they are.
+UPDATE users
+SET is_verified = TRUE, updated_at = NOW()
+WHERE NOT COALESCE(is_verified, FALSE)
+  AND created_at < (SELECT applied_at FROM schema_migrations WHERE version = 1)
+  AND NOT EXISTS (
+      SELECT 1 FROM kyc_submissions
+      WHERE kyc_submissions.user_id = users.id AND kyc_submissions.status = 'rejected'
+  );
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_custody_key;
+UPDATE users SET encrypted_private_key = '' WHERE encrypted_private_key IS NULL;
+ALTER TABLE users ALTER COLUMN encrypted_private_key SET NOT NULL;
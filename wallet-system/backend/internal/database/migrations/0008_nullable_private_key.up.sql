@@ -0,0 +1,9 @@
+-- Non-custodial users have no private key on the server; store NULL for them
+-- rather than an empty string, and hold every custodial user to having one
+ALTER TABLE users ALTER COLUMN encrypted_private_key DROP NOT NULL;
+
+UPDATE users SET encrypted_private_key = NULL
+WHERE custody_mode = 'non_custodial';
+
+ALTER TABLE users ADD CONSTRAINT users_custody_key
+    CHECK ((custody_mode = 'non_custodial') = (encrypted_private_key IS NULL));
@@ -1,61 +1,237 @@
 package database
 
 import (
+	"encoding/json"
 	"time"
 )
 
 // User represents a user in the system
 type User struct {
-	ID                 string    `json:"id"`
-	Email              string    `json:"email"`
-	FullName           string    `json:"full_name"`
-	CNIC               string    `json:"cnic"`
-	WalletID           string    `json:"wallet_id"`
-	PublicKey          string    `json:"public_key"`
-	EncryptedPrivateKey string   `json:"encrypted_private_key"`
-	CreatedAt          time.Time `json:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at"`
-	IsVerified         bool      `json:"is_verified"`
+	ID                  string    `json:"id"`
+	Email               string    `json:"email"`
+	FullName            string    `json:"full_name"`
+	CNIC                string    `json:"cnic"`
+	WalletID            string    `json:"wallet_id"`
+	PublicKey           string    `json:"public_key"`
+	EncryptedPrivateKey string    `json:"encrypted_private_key"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+	IsVerified          bool      `json:"is_verified"`
+	Role                string    `json:"role"`
+	Tier                string    `json:"tier"`
+	CustodyMode         string    `json:"custody_mode"`
+}
+
+// User roles. Auditors can read logs and reports but change nothing.
+const (
+	RoleUser    = "user"
+	RoleAuditor = "auditor"
+	RoleAdmin   = "admin"
+)
+
+// IsValidRole reports whether role is a known user role
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleAuditor || role == RoleAdmin
+}
+
+// Custody modes. Non-custodial users keep their private key on the client;
+// their encrypted_private_key is NULL and reads as "".
+const (
+	CustodyCustodial    = "custodial"
+	CustodyNonCustodial = "non_custodial"
+)
+
+// IsNonCustodial reports whether the user's private key is held client-side
+func (u *User) IsNonCustodial() bool {
+	return u.CustodyMode == CustodyNonCustodial
 }
 
 // Wallet represents a user's wallet
 type Wallet struct {
-	ID               string    `json:"id"`
-	UserID           string    `json:"user_id"`
-	WalletAddress    string    `json:"wallet_address"`
-	BalanceCache     float64   `json:"balance_cache"`
-	LastUpdated      time.Time `json:"last_updated"`
-	ZakatDeducted    bool      `json:"zakat_deducted_this_month"`
+	ID            string    `json:"id"`
+	UserID        string    `json:"user_id"`
+	WalletAddress string    `json:"wallet_address"`
+	BalanceCache  float64   `json:"balance_cache"`
+	LastUpdated   time.Time `json:"last_updated"`
+	ZakatDeducted bool      `json:"zakat_deducted_this_month"`
+	WalletType    string    `json:"wallet_type"`
+	Status        string    `json:"status"`
+	StatusReason  string    `json:"status_reason,omitempty"`
+}
+
+// Wallet statuses. Frozen wallets cannot send funds; closed wallets can
+// neither send nor receive and cannot be reopened.
+const (
+	WalletActive = "active"
+	WalletFrozen = "frozen"
+	WalletClosed = "closed"
+)
+
+// TierStandard is the limit tier users are created with. Users who have not
+// passed KYC are held to TierUnverified whatever their assigned tier.
+const (
+	TierStandard   = "standard"
+	TierUnverified = "unverified"
+)
+
+// TransferLimit caps a tier's outgoing transfers. Zero means no limit.
+type TransferLimit struct {
+	Tier           string    `json:"tier"`
+	PerTransaction float64   `json:"per_transaction"`
+	Daily          float64   `json:"daily"`
+	Monthly        float64   `json:"monthly"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// KYCSubmission is a user's identity details and documents awaiting or
+// after an admin's review
+type KYCSubmission struct {
+	ID          string         `json:"id"`
+	UserID      string         `json:"user_id"`
+	CNIC        string         `json:"cnic"`
+	FullName    string         `json:"full_name"`
+	FatherName  string         `json:"father_name,omitempty"`
+	DateOfBirth string         `json:"date_of_birth"`
+	Gender      string         `json:"gender"`
+	Region      string         `json:"region"`
+	Status      string         `json:"status"`
+	ReviewNotes string         `json:"review_notes,omitempty"`
+	ReviewedBy  *string        `json:"reviewed_by,omitempty"`
+	ReviewedAt  *time.Time     `json:"reviewed_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	Documents   []*KYCDocument `json:"documents,omitempty"`
+}
+
+// KYC submission statuses
+const (
+	KYCPending  = "pending"
+	KYCApproved = "approved"
+	KYCRejected = "rejected"
+)
+
+// KYCDocument is an uploaded identity document. StoragePath is relative to
+// the upload directory and never leaves the server.
+type KYCDocument struct {
+	ID           string    `json:"id"`
+	SubmissionID string    `json:"submission_id"`
+	Kind         string    `json:"kind"`
+	StoragePath  string    `json:"-"`
+	ContentType  string    `json:"content_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	SHA256       string    `json:"sha256"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// KYC document kinds
+const (
+	KYCDocCNICFront = "cnic_front"
+	KYCDocCNICBack  = "cnic_back"
+	KYCDocSelfie    = "selfie"
+)
+
+// UserTOTP is a user's authenticator app enrolment. The secret is stored
+// encrypted and the enrolment only counts once a code has confirmed it.
+type UserTOTP struct {
+	UserID          string     `json:"user_id"`
+	EncryptedSecret string     `json:"-"`
+	Enabled         bool       `json:"enabled"`
+	LastStep        int64      `json:"-"`
+	EnabledAt       *time.Time `json:"enabled_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// OTPCode is a one-time code sent by email, stored as a hash
+type OTPCode struct {
+	ID         string
+	UserID     string
+	Purpose    string
+	CodeHash   string
+	Attempts   int
+	ExpiresAt  time.Time
+	ConsumedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// OTP purposes. A code is only accepted for the purpose it was sent for.
+const (
+	OTPPurposeLogin    = "login"
+	OTPPurposeTransfer = "transfer"
+)
+
+// Session is one login of a user, holding the hash of its current refresh
+// token. Refreshing rotates the token and keeps the previous hash so a
+// replayed old token can be detected.
+type Session struct {
+	ID           string     `json:"id"`
+	UserID       string     `json:"user_id"`
+	RefreshHash  string     `json:"-"`
+	PreviousHash string     `json:"-"`
+	UserAgent    string     `json:"user_agent"`
+	IPAddress    string     `json:"ip_address"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastUsedAt   time.Time  `json:"last_used_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+}
+
+// LoginFailure counts recent failed logins for an email address and any
+// lockout they led to. Rows are keyed by the address as typed, lowercased,
+// whether or not it belongs to a user, so lockouts do not reveal accounts.
+type LoginFailure struct {
+	Email         string     `json:"email"`
+	Failures      int        `json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+}
+
+// Wallet types
+const (
+	WalletTypeStandard = "standard"
+	WalletTypeMultisig = "multisig"
+)
+
+// WalletBalance compares a wallet's cached balance with its unspent outputs
+type WalletBalance struct {
+	WalletAddress string  `json:"wallet_address"`
+	BalanceCache  float64 `json:"balance_cache"`
+	UTXOBalance   float64 `json:"utxo_balance"`
+	UTXOCount     int     `json:"utxo_count"`
 }
 
 // Transaction represents a blockchain transaction
 type Transaction struct {
-	ID              string     `json:"id"`
-	TransactionHash string     `json:"transaction_hash"`
-	BlockHash       *string    `json:"block_hash,omitempty"`
-	SenderWallet    string     `json:"sender_wallet"`
-	ReceiverWallet  string     `json:"receiver_wallet"`
-	Amount          float64    `json:"amount"`
-	Fee             float64    `json:"fee"`
-	Note            string     `json:"note,omitempty"`
-	Signature       string     `json:"signature"`
-	Status          string     `json:"status"`
-	CreatedAt       time.Time  `json:"created_at"`
-	TransactionType string     `json:"transaction_type"`
+	ID              string    `json:"id"`
+	TransactionHash string    `json:"transaction_hash"`
+	BlockHash       *string   `json:"block_hash,omitempty"`
+	SenderWallet    string    `json:"sender_wallet"`
+	ReceiverWallet  string    `json:"receiver_wallet"`
+	Amount          float64   `json:"amount"`
+	Fee             float64   `json:"fee"`
+	Note            string    `json:"note,omitempty"`
+	Signature       string    `json:"signature"`
+	Status          string    `json:"status"`
+	CreatedAt       time.Time `json:"created_at"`
+	TransactionType string    `json:"transaction_type"`
+	LockTime        int64     `json:"lock_time,omitempty"`
+	LockUntil       int64     `json:"lock_until,omitempty"`
+	SignedAt        int64     `json:"signed_at,omitempty"` // timestamp covered by the signature
+	// SigningHash is the hash of client-signed data, unique so a client
+	// signature cannot be replayed
+	SigningHash string `json:"-"`
 }
 
 // Block represents a blockchain block
 type Block struct {
-	ID            string    `json:"id"`
-	BlockIndex    int64     `json:"block_index"`
-	Timestamp     int64     `json:"timestamp"`
-	PreviousHash  string    `json:"previous_hash"`
-	Hash          string    `json:"hash"`
-	Nonce         int64     `json:"nonce"`
-	MerkleRoot    string    `json:"merkle_root"`
-	Difficulty    int       `json:"difficulty"`
-	MinedBy       string    `json:"mined_by,omitempty"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID           string    `json:"id"`
+	BlockIndex   int64     `json:"block_index"`
+	Timestamp    int64     `json:"timestamp"`
+	PreviousHash string    `json:"previous_hash"`
+	Hash         string    `json:"hash"`
+	Nonce        int64     `json:"nonce"`
+	MerkleRoot   string    `json:"merkle_root"`
+	Difficulty   int       `json:"difficulty"`
+	MinedBy      string    `json:"mined_by,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 // UTXO represents an unspent transaction output
@@ -68,6 +244,7 @@ type UTXO struct {
 	IsSpent            bool      `json:"is_spent"`
 	SpentInTransaction *string   `json:"spent_in_transaction,omitempty"`
 	CreatedAt          time.Time `json:"created_at"`
+	LockUntil          int64     `json:"lock_until,omitempty"`
 }
 
 // ZakatTransaction represents a zakat deduction
@@ -76,27 +253,262 @@ type ZakatTransaction struct {
 	WalletAddress   string    `json:"wallet_address"`
 	Amount          float64   `json:"amount"`
 	ZakatPercentage float64   `json:"zakat_percentage"`
+	SadaqahAmount   float64   `json:"sadaqah_amount"`
 	TransactionHash string    `json:"transaction_hash"`
 	MonthYear       string    `json:"month_year"`
 	CreatedAt       time.Time `json:"created_at"`
 }
 
-// SystemLog represents a system log entry
-type SystemLog struct {
+// ZakatSettings holds a user's zakat preferences. Users are opted in unless
+// they opt out; SadaqahPercentage is a voluntary extra charged with zakat.
+type ZakatSettings struct {
+	UserID            string    `json:"user_id"`
+	OptedIn           bool      `json:"opted_in"`
+	SadaqahPercentage float64   `json:"sadaqah_percentage"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// ZakatExemption excludes a user's wallets from zakat processing
+type ZakatExemption struct {
+	UserID    string    `json:"user_id"`
+	Reason    string    `json:"reason"`
+	GrantedBy string    `json:"granted_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Zakat declaration kinds
+const (
+	DeclarationAsset     = "asset"
+	DeclarationLiability = "liability"
+)
+
+// ZakatDeclaration is an off-chain asset or liability a user declares
+// against one of their wallets to adjust its zakatable amount
+type ZakatDeclaration struct {
+	ID            string    `json:"id"`
+	UserID        string    `json:"user_id"`
+	WalletAddress string    `json:"wallet_address"`
+	Kind          string    `json:"kind"`
+	Description   string    `json:"description"`
+	Amount        float64   `json:"amount"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ZakatMonthTotal aggregates the zakat deducted in one month
+type ZakatMonthTotal struct {
+	MonthYear string  `json:"month_year"`
+	Count     int     `json:"count"`
+	Amount    float64 `json:"amount"`
+}
+
+// ZakatRecipient is a registered beneficiary of zakat pool disbursements
+type ZakatRecipient struct {
 	ID            string    `json:"id"`
-	LogType       string    `json:"log_type"`
-	Message       string    `json:"message"`
-	WalletAddress string    `json:"wallet_address,omitempty"`
-	IPAddress     string    `json:"ip_address,omitempty"`
-	UserAgent     string    `json:"user_agent,omitempty"`
+	Name          string    `json:"name"`
+	WalletAddress string    `json:"wallet_address"`
+	Description   string    `json:"description,omitempty"`
+	IsActive      bool      `json:"is_active"`
 	CreatedAt     time.Time `json:"created_at"`
 }
 
+// ZakatDisbursement is a transfer from the zakat pool to a recipient
+type ZakatDisbursement struct {
+	TransactionHash string    `json:"transaction_hash"`
+	RecipientID     string    `json:"recipient_id"`
+	RecipientName   string    `json:"recipient_name"`
+	WalletAddress   string    `json:"wallet_address"`
+	Amount          float64   `json:"amount"`
+	Note            string    `json:"note,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// ZakatHawl tracks a wallet's current zakat year. HawlStart is when the
+// balance last rose to the nisab or the previous hawl ended with zakat paid;
+// it is nil while the balance is below the nisab.
+type ZakatHawl struct {
+	WalletAddress string     `json:"wallet_address"`
+	HawlStart     *time.Time `json:"hawl_start"`
+	LastPaidAt    *time.Time `json:"last_paid_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// BalanceChange is one credit or debit in a wallet's UTXO history
+type BalanceChange struct {
+	At     time.Time
+	Amount float64
+}
+
+// Job run triggers
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+	JobTriggerRerun    = "rerun"
+)
+
+// JobRun is one execution of a background or admin-triggered job
+type JobRun struct {
+	ID           string          `json:"id"`
+	JobName      string          `json:"job_name"`
+	Trigger      string          `json:"trigger"`
+	Params       json.RawMessage `json:"params,omitempty"`
+	RerunOf      string          `json:"rerun_of,omitempty"`
+	ScheduledFor time.Time       `json:"scheduled_for"`
+	StartedAt    time.Time       `json:"started_at"`
+	FinishedAt   *time.Time      `json:"finished_at,omitempty"`
+	Status       string          `json:"status"`
+	Error        string          `json:"error,omitempty"`
+	Items        []*JobRunItem   `json:"items,omitempty"`
+}
+
+// JobRunItem is the outcome of a job run for one subject, such as a wallet
+type JobRunItem struct {
+	Subject         string  `json:"subject"`
+	Outcome         string  `json:"outcome"`
+	Amount          float64 `json:"amount,omitempty"`
+	TransactionHash string  `json:"transaction_hash,omitempty"`
+	Detail          string  `json:"detail,omitempty"`
+}
+
+// SystemLog is an audit entry for one state change. ActorID is the user
+// who made it, empty for background jobs; Before and After hold the changed
+// values as JSON.
+type SystemLog struct {
+	ID            string          `json:"id"`
+	LogType       string          `json:"log_type"`
+	Message       string          `json:"message"`
+	ActorID       string          `json:"actor_id,omitempty"`
+	WalletAddress string          `json:"wallet_address,omitempty"`
+	IPAddress     string          `json:"ip_address,omitempty"`
+	UserAgent     string          `json:"user_agent,omitempty"`
+	RequestID     string          `json:"request_id,omitempty"`
+	Before        json.RawMessage `json:"before,omitempty"`
+	After         json.RawMessage `json:"after,omitempty"`
+	Sequence      int64           `json:"sequence,omitempty"`
+	PrevHash      string          `json:"prev_hash,omitempty"`
+	EntryHash     string          `json:"entry_hash,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// System log types, shared by the writers and GetSystemLogStats
+const (
+	LogAuth               = "AUTH"
+	LogUserRegistered     = "USER_REGISTERED"
+	LogSessionReuse       = "SESSION_REUSE"
+	LogTwoFactorEnabled   = "TWO_FACTOR_ENABLED"
+	LogTwoFactorDisabled  = "TWO_FACTOR_DISABLED"
+	LogTransactionCreated = "TRANSACTION_CREATED"
+	LogTransferBlocked    = "TRANSFER_BLOCKED"
+	LogBlockMined         = "BLOCK_MINED"
+	LogZakatDeducted      = "ZAKAT_DEDUCTED"
+	LogZakatDisbursed     = "ZAKAT_DISBURSED"
+	LogZakatRecipient     = "ZAKAT_RECIPIENT_ADDED"
+	LogZakatExempted      = "ZAKAT_EXEMPTION_GRANTED"
+	LogZakatExemptRevoked = "ZAKAT_EXEMPTION_REVOKED"
+	LogKYCSubmitted       = "KYC_SUBMITTED"
+	LogKYCApproved        = "KYC_APPROVED"
+	LogKYCRejected        = "KYC_REJECTED"
+	LogRoleChanged        = "ROLE_CHANGED"
+	LogTierChanged        = "TIER_CHANGED"
+	LogLimitsChanged      = "LIMITS_CHANGED"
+	LogWalletFrozen       = "WALLET_FROZEN"
+	LogWalletUnfrozen     = "WALLET_UNFROZEN"
+	LogWalletClosed       = "WALLET_CLOSED"
+	LogJobTriggered       = "JOB_TRIGGERED"
+	LogError              = "ERROR"
+)
+
 // Beneficiary represents a saved beneficiary wallet
 type Beneficiary struct {
-	ID                 string    `json:"id"`
-	UserID             string    `json:"user_id"`
-	BeneficiaryWalletID string   `json:"beneficiary_wallet_id"`
-	Nickname           string    `json:"nickname"`
-	CreatedAt          time.Time `json:"created_at"`
+	ID                  string    `json:"id"`
+	UserID              string    `json:"user_id"`
+	BeneficiaryWalletID string    `json:"beneficiary_wallet_id"`
+	Nickname            string    `json:"nickname"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+// MultisigWallet is an M-of-N wallet whose address is derived from its
+// co-signers' public keys
+type MultisigWallet struct {
+	ID            string            `json:"id"`
+	WalletAddress string            `json:"wallet_address"`
+	Threshold     int               `json:"threshold"`
+	CreatedBy     string            `json:"created_by"`
+	CreatedAt     time.Time         `json:"created_at"`
+	Signers       []*MultisigSigner `json:"signers"`
+}
+
+// MultisigSigner is a co-signer of a multisig wallet
+type MultisigSigner struct {
+	UserID    string `json:"user_id"`
+	WalletID  string `json:"wallet_id"`
+	PublicKey string `json:"public_key"`
+}
+
+// MultisigProposal is a proposed spend from a multisig wallet awaiting
+// co-signer approvals
+type MultisigProposal struct {
+	ID              string              `json:"id"`
+	WalletAddress   string              `json:"wallet_address"`
+	ReceiverWallet  string              `json:"receiver_wallet"`
+	Amount          float64             `json:"amount"`
+	Fee             float64             `json:"fee"`
+	Note            string              `json:"note,omitempty"`
+	Timestamp       int64               `json:"timestamp"`
+	ProposedBy      string              `json:"proposed_by"`
+	Status          string              `json:"status"`
+	TransactionHash *string             `json:"transaction_hash,omitempty"`
+	Error           string              `json:"error,omitempty"`
+	CreatedAt       time.Time           `json:"created_at"`
+	Approvals       []*MultisigApproval `json:"approvals"`
+}
+
+// Multisig proposal statuses. A proposal is broadcasting while its
+// transaction is being submitted, so only one broadcast can spend it, and
+// failed if the submission failed other than by being refused.
+const (
+	ProposalPending      = "pending"
+	ProposalBroadcasting = "broadcasting"
+	ProposalBroadcast    = "broadcast"
+	ProposalFailed       = "failed"
+	ProposalCancelled    = "cancelled"
+)
+
+// MultisigApproval is one co-signer's signature on a proposal
+type MultisigApproval struct {
+	ProposalID string    `json:"proposal_id"`
+	UserID     string    `json:"user_id"`
+	PublicKey  string    `json:"public_key"`
+	Signature  string    `json:"signature"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ScheduledTransfer is a transfer signed in advance and submitted by the
+// scheduler at ExecuteAt. The signed transaction carries ExecuteAt as its lock
+// time so it cannot be accepted earlier.
+type ScheduledTransfer struct {
+	ID              string     `json:"id"`
+	UserID          string     `json:"user_id"`
+	SenderWallet    string     `json:"sender_wallet"`
+	ReceiverWallet  string     `json:"receiver_wallet"`
+	Amount          float64    `json:"amount"`
+	Fee             float64    `json:"fee"`
+	Note            string     `json:"note,omitempty"`
+	Timestamp       int64      `json:"timestamp"`
+	ExecuteAt       time.Time  `json:"execute_at"`
+	LockUntil       int64      `json:"lock_until,omitempty"`
+	Signature       string     `json:"-"`
+	Status          string     `json:"status"`
+	TransactionHash *string    `json:"transaction_hash,omitempty"`
+	Error           string     `json:"error,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ExecutedAt      *time.Time `json:"executed_at,omitempty"`
 }
+
+// Scheduled transfer statuses
+const (
+	ScheduledPending   = "pending"
+	ScheduledExecuting = "executing"
+	ScheduledExecuted  = "executed"
+	ScheduledFailed    = "failed"
+	ScheduledCancelled = "cancelled"
+)
@@ -0,0 +1,287 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// CreateMultisigWallet stores a multisig wallet and its co-signers. The
+// wallets row for the address must already exist.
+func (d *Database) CreateMultisigWallet(ctx context.Context, mw *MultisigWallet) error {
+	query := `
+		INSERT INTO multisig_wallets (wallet_address, threshold, created_by)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+
+	if err := d.db.QueryRowContext(ctx, query,
+		mw.WalletAddress, mw.Threshold, mw.CreatedBy,
+	).Scan(&mw.ID, &mw.CreatedAt); err != nil {
+		return err
+	}
+
+	signerQuery := `
+		INSERT INTO multisig_signers (multisig_wallet_id, user_id, public_key)
+		VALUES ($1, $2, $3)
+	`
+	for _, signer := range mw.Signers {
+		if _, err := d.db.ExecContext(ctx, signerQuery, mw.ID, signer.UserID, signer.PublicKey); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetMultisigWallet retrieves a multisig wallet and its co-signers by address
+func (d *Database) GetMultisigWallet(ctx context.Context, walletAddress string) (*MultisigWallet, error) {
+	query := `
+		SELECT id, wallet_address, threshold, created_by, created_at
+		FROM multisig_wallets WHERE wallet_address = $1
+	`
+
+	mw := &MultisigWallet{}
+	err := d.db.QueryRowContext(ctx, query, walletAddress).Scan(
+		&mw.ID, &mw.WalletAddress, &mw.Threshold, &mw.CreatedBy, &mw.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signerQuery := `
+		SELECT s.user_id, u.wallet_id, s.public_key
+		FROM multisig_signers s JOIN users u ON u.id = s.user_id
+		WHERE s.multisig_wallet_id = $1
+		ORDER BY s.public_key
+	`
+
+	rows, err := d.db.QueryContext(ctx, signerQuery, mw.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		signer := &MultisigSigner{}
+		if err := rows.Scan(&signer.UserID, &signer.WalletID, &signer.PublicKey); err != nil {
+			return nil, err
+		}
+		mw.Signers = append(mw.Signers, signer)
+	}
+
+	return mw, rows.Err()
+}
+
+// GetMultisigWalletAddressesBySigner lists the addresses of multisig wallets
+// on which the user is a co-signer
+func (d *Database) GetMultisigWalletAddressesBySigner(ctx context.Context, userID string) ([]string, error) {
+	query := `
+		SELECT m.wallet_address
+		FROM multisig_wallets m JOIN multisig_signers s ON s.multisig_wallet_id = m.id
+		WHERE s.user_id = $1
+		ORDER BY m.created_at DESC
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var addresses []string
+	for rows.Next() {
+		var address string
+		if err := rows.Scan(&address); err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+
+	return addresses, rows.Err()
+}
+
+// CreateMultisigProposal creates a spend proposal
+func (d *Database) CreateMultisigProposal(ctx context.Context, p *MultisigProposal) error {
+	query := `
+		INSERT INTO multisig_proposals (wallet_address, receiver_wallet, amount, fee, note, timestamp, proposed_by, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	return d.db.QueryRowContext(ctx, query,
+		p.WalletAddress, p.ReceiverWallet, p.Amount, p.Fee, p.Note, p.Timestamp, p.ProposedBy, p.Status,
+	).Scan(&p.ID, &p.CreatedAt)
+}
+
+const multisigProposalColumns = `id, wallet_address, receiver_wallet, amount, fee, note, timestamp, proposed_by, status,
+	transaction_hash, COALESCE(error, ''), created_at`
+
+// GetMultisigProposal retrieves a proposal and its approvals
+func (d *Database) GetMultisigProposal(ctx context.Context, proposalID string) (*MultisigProposal, error) {
+	query := `SELECT ` + multisigProposalColumns + `
+		FROM multisig_proposals WHERE id = $1
+	`
+
+	p := &MultisigProposal{}
+	var note sql.NullString
+	err := d.db.QueryRowContext(ctx, query, proposalID).Scan(
+		&p.ID, &p.WalletAddress, &p.ReceiverWallet, &p.Amount, &p.Fee, &note,
+		&p.Timestamp, &p.ProposedBy, &p.Status, &p.TransactionHash, &p.Error, &p.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.Note = note.String
+
+	approvals, err := d.getMultisigApprovals(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	p.Approvals = approvals
+
+	return p, nil
+}
+
+// GetMultisigProposalsByWallet lists proposals for a multisig wallet
+func (d *Database) GetMultisigProposalsByWallet(ctx context.Context, walletAddress string, status string) ([]*MultisigProposal, error) {
+	query := `SELECT ` + multisigProposalColumns + `
+		FROM multisig_proposals
+		WHERE wallet_address = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+	`
+
+	return d.queryMultisigProposals(ctx, query, walletAddress, status)
+}
+
+// GetStaleMultisigProposals lists proposals claimed for broadcast before
+// claimedBefore that never finished, such as when the server stopped
+// mid-broadcast
+func (d *Database) GetStaleMultisigProposals(ctx context.Context, claimedBefore time.Time) ([]*MultisigProposal, error) {
+	query := `SELECT ` + multisigProposalColumns + `
+		FROM multisig_proposals
+		WHERE status = 'broadcasting' AND broadcasting_since < $1
+		ORDER BY created_at
+	`
+
+	return d.queryMultisigProposals(ctx, query, claimedBefore)
+}
+
+// queryMultisigProposals runs a proposal query and scans the rows with their
+// approvals
+func (d *Database) queryMultisigProposals(ctx context.Context, query string, args ...interface{}) ([]*MultisigProposal, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var proposals []*MultisigProposal
+	for rows.Next() {
+		p := &MultisigProposal{}
+		var note sql.NullString
+		err := rows.Scan(
+			&p.ID, &p.WalletAddress, &p.ReceiverWallet, &p.Amount, &p.Fee, &note,
+			&p.Timestamp, &p.ProposedBy, &p.Status, &p.TransactionHash, &p.Error, &p.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		p.Note = note.String
+		proposals = append(proposals, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, p := range proposals {
+		if p.Approvals, err = d.getMultisigApprovals(ctx, p.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return proposals, nil
+}
+
+// CreateMultisigApproval records a co-signer's signature on a proposal,
+// replacing any earlier approval by the same user
+func (d *Database) CreateMultisigApproval(ctx context.Context, a *MultisigApproval) error {
+	query := `
+		INSERT INTO multisig_approvals (proposal_id, user_id, public_key, signature)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (proposal_id, user_id) DO UPDATE SET signature = EXCLUDED.signature, created_at = NOW()
+		RETURNING created_at
+	`
+
+	return d.db.QueryRowContext(ctx, query,
+		a.ProposalID, a.UserID, a.PublicKey, a.Signature,
+	).Scan(&a.CreatedAt)
+}
+
+// UpdateMultisigProposalStatus moves a proposal from one status to another,
+// setting its resulting transaction hash. It only transitions proposals still
+// in the from status and reports whether a row was updated. Moving to
+// broadcasting records when the proposal was claimed.
+func (d *Database) UpdateMultisigProposalStatus(ctx context.Context, proposalID, from, to, txHash string) (bool, error) {
+	query := `
+		UPDATE multisig_proposals
+		SET status = $1, transaction_hash = NULLIF($2, ''),
+		    broadcasting_since = CASE WHEN $5 THEN NOW() END
+		WHERE id = $3 AND status = $4
+	`
+
+	result, err := d.db.ExecContext(ctx, query, to, txHash, proposalID, from, to == ProposalBroadcasting)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// FailMultisigProposal marks a proposal being broadcast as failed with the
+// reason, and reports whether it was still broadcasting
+func (d *Database) FailMultisigProposal(ctx context.Context, proposalID, errMsg string) (bool, error) {
+	query := `
+		UPDATE multisig_proposals SET status = 'failed', error = $1, broadcasting_since = NULL
+		WHERE id = $2 AND status = 'broadcasting'
+	`
+
+	result, err := d.db.ExecContext(ctx, query, errMsg, proposalID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// getMultisigApprovals lists the approvals on a proposal
+func (d *Database) getMultisigApprovals(ctx context.Context, proposalID string) ([]*MultisigApproval, error) {
+	query := `
+		SELECT proposal_id, user_id, public_key, signature, created_at
+		FROM multisig_approvals WHERE proposal_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	approvals := []*MultisigApproval{}
+	for rows.Next() {
+		a := &MultisigApproval{}
+		if err := rows.Scan(&a.ProposalID, &a.UserID, &a.PublicKey, &a.Signature, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, a)
+	}
+
+	return approvals, rows.Err()
+}
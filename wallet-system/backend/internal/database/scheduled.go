@@ -0,0 +1,134 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const scheduledTransferColumns = `id, user_id, sender_wallet, receiver_wallet, amount, fee, note, timestamp,
+	execute_at, lock_until, signature, status, transaction_hash, error, created_at, executed_at`
+
+// CreateScheduledTransfer stores a pre-authorised transfer
+func (d *Database) CreateScheduledTransfer(ctx context.Context, st *ScheduledTransfer) error {
+	query := `
+		INSERT INTO scheduled_transfers (user_id, sender_wallet, receiver_wallet, amount, fee, note, timestamp, execute_at, lock_until, signature, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at
+	`
+
+	return d.db.QueryRowContext(ctx, query,
+		st.UserID, st.SenderWallet, st.ReceiverWallet, st.Amount, st.Fee, st.Note,
+		st.Timestamp, st.ExecuteAt, st.LockUntil, st.Signature, st.Status,
+	).Scan(&st.ID, &st.CreatedAt)
+}
+
+// GetScheduledTransfersByUser lists a user's scheduled transfers, soonest first
+func (d *Database) GetScheduledTransfersByUser(ctx context.Context, userID string) ([]*ScheduledTransfer, error) {
+	query := `SELECT ` + scheduledTransferColumns + `
+		FROM scheduled_transfers WHERE user_id = $1
+		ORDER BY execute_at ASC
+	`
+
+	return d.queryScheduledTransfers(ctx, query, userID)
+}
+
+// ClaimDueScheduledTransfers marks up to limit pending transfers due at now
+// as executing and returns them. Rows locked by another instance are skipped.
+func (d *Database) ClaimDueScheduledTransfers(ctx context.Context, now time.Time, limit int) ([]*ScheduledTransfer, error) {
+	query := `
+		UPDATE scheduled_transfers SET status = 'executing', executing_since = NOW()
+		WHERE id IN (
+			SELECT id FROM scheduled_transfers
+			WHERE status = 'pending' AND execute_at <= $1
+			ORDER BY execute_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + scheduledTransferColumns
+
+	return d.queryScheduledTransfers(ctx, query, now, limit)
+}
+
+// GetStaleScheduledTransfers lists transfers claimed for execution before
+// claimedBefore that never completed, such as when the server stopped mid-run
+func (d *Database) GetStaleScheduledTransfers(ctx context.Context, claimedBefore time.Time) ([]*ScheduledTransfer, error) {
+	query := `SELECT ` + scheduledTransferColumns + `
+		FROM scheduled_transfers
+		WHERE status = 'executing' AND executing_since < $1
+		ORDER BY execute_at
+	`
+
+	return d.queryScheduledTransfers(ctx, query, claimedBefore)
+}
+
+// RequeueScheduledTransfer returns a transfer still executing to pending, so
+// the next run retries it, and reports whether it was requeued
+func (d *Database) RequeueScheduledTransfer(ctx context.Context, id string) (bool, error) {
+	query := `
+		UPDATE scheduled_transfers SET status = 'pending', executing_since = NULL
+		WHERE id = $1 AND status = 'executing'
+	`
+
+	result, err := d.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// CompleteScheduledTransfer records the outcome of executing a transfer
+func (d *Database) CompleteScheduledTransfer(ctx context.Context, id, status, txHash, errMsg string) error {
+	query := `
+		UPDATE scheduled_transfers
+		SET status = $1, transaction_hash = NULLIF($2, ''), error = NULLIF($3, ''), executed_at = NOW(), executing_since = NULL
+		WHERE id = $4
+	`
+
+	_, err := d.db.ExecContext(ctx, query, status, txHash, errMsg, id)
+	return err
+}
+
+// CancelScheduledTransfer cancels a user's pending transfer and reports
+// whether it was still pending
+func (d *Database) CancelScheduledTransfer(ctx context.Context, id, userID string) (bool, error) {
+	query := `
+		UPDATE scheduled_transfers SET status = 'cancelled'
+		WHERE id = $1 AND user_id = $2 AND status = 'pending'
+	`
+
+	result, err := d.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// queryScheduledTransfers runs a scheduled transfer query and scans the rows
+func (d *Database) queryScheduledTransfers(ctx context.Context, query string, args ...interface{}) ([]*ScheduledTransfer, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []*ScheduledTransfer
+	for rows.Next() {
+		st := &ScheduledTransfer{}
+		var note, errMsg sql.NullString
+		err := rows.Scan(
+			&st.ID, &st.UserID, &st.SenderWallet, &st.ReceiverWallet, &st.Amount, &st.Fee, &note, &st.Timestamp,
+			&st.ExecuteAt, &st.LockUntil, &st.Signature, &st.Status, &st.TransactionHash, &errMsg, &st.CreatedAt, &st.ExecutedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		st.Note = note.String
+		st.Error = errMsg.String
+		transfers = append(transfers, st)
+	}
+
+	return transfers, rows.Err()
+}
@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const sessionColumns = `
+	id, user_id, refresh_hash, COALESCE(previous_hash, ''), COALESCE(user_agent, ''), COALESCE(ip_address, ''),
+	created_at, last_used_at, expires_at, revoked_at
+`
+
+func scanSession(row interface{ Scan(...interface{}) error }) (*Session, error) {
+	s := &Session{}
+	var revokedAt sql.NullTime
+	err := row.Scan(&s.ID, &s.UserID, &s.RefreshHash, &s.PreviousHash, &s.UserAgent, &s.IPAddress,
+		&s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &revokedAt)
+	if err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		s.RevokedAt = &revokedAt.Time
+	}
+	return s, nil
+}
+
+// CreateSession records a new login
+func (d *Database) CreateSession(ctx context.Context, session *Session) error {
+	query := `
+		INSERT INTO sessions (user_id, refresh_hash, user_agent, ip_address, expires_at)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5)
+		RETURNING id, created_at, last_used_at
+	`
+
+	return d.db.QueryRowContext(ctx, query,
+		session.UserID, session.RefreshHash, session.UserAgent, session.IPAddress, session.ExpiresAt,
+	).Scan(&session.ID, &session.CreatedAt, &session.LastUsedAt)
+}
+
+// GetSessionByRefreshHash returns the session whose current or previous
+// refresh token has hash, or nil
+func (d *Database) GetSessionByRefreshHash(ctx context.Context, hash string) (*Session, error) {
+	session, err := scanSession(d.db.QueryRowContext(ctx,
+		`SELECT `+sessionColumns+` FROM sessions WHERE refresh_hash = $1 OR previous_hash = $1`, hash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return session, err
+}
+
+// RotateSession replaces a session's refresh token, reporting false if the
+// token was already rotated by a concurrent refresh
+func (d *Database) RotateSession(ctx context.Context, id, oldHash, newHash, userAgent, ipAddress string, expiresAt time.Time) (bool, error) {
+	result, err := d.db.ExecContext(ctx, `
+		UPDATE sessions
+		SET refresh_hash = $3, previous_hash = $2, last_used_at = NOW(), expires_at = $4,
+			user_agent = COALESCE(NULLIF($5, ''), user_agent), ip_address = COALESCE(NULLIF($6, ''), ip_address)
+		WHERE id = $1 AND refresh_hash = $2 AND revoked_at IS NULL
+	`, id, oldHash, newHash, expiresAt, userAgent, ipAddress)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// IsSessionActive reports whether a session exists, is unrevoked and has
+// not expired
+func (d *Database) IsSessionActive(ctx context.Context, id string) (bool, error) {
+	var active bool
+	err := d.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM sessions WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW())
+	`, id).Scan(&active)
+	return active, err
+}
+
+// GetActiveSessions lists a user's unrevoked, unexpired sessions, most
+// recently used first
+func (d *Database) GetActiveSessions(ctx context.Context, userID string) ([]*Session, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT `+sessionColumns+`
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_used_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// RevokeSession ends one of a user's sessions, reporting whether it was active
+func (d *Database) RevokeSession(ctx context.Context, id, userID string) (bool, error) {
+	result, err := d.db.ExecContext(ctx, `
+		UPDATE sessions SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// RevokeUserSessions ends all of a user's sessions and returns how many
+func (d *Database) RevokeUserSessions(ctx context.Context, userID string) (int64, error) {
+	result, err := d.db.ExecContext(ctx, `
+		UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteSessionsBefore removes sessions that expired or were revoked before
+// cutoff
+func (d *Database) DeleteSessionsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := d.db.ExecContext(ctx, `
+		DELETE FROM sessions WHERE expires_at < $1 OR revoked_at < $1
+	`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -3,65 +3,114 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+// ErrSignatureReused is returned when a transaction signed over the same data
+// was already recorded
+var ErrSignatureReused = errors.New("signature was already used")
+
+// querier runs statements on the connection pool or within a transaction
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 // Database manages all database operations
 type Database struct {
-	db *sql.DB
+	pool *sql.DB
+	db   querier
+}
+
+// PoolOptions size the connection pool. Zero lifetimes keep connections
+// open indefinitely.
+type PoolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
 }
 
 // NewDatabase creates a new database connection
-func NewDatabase(databaseURL string) (*Database, error) {
+func NewDatabase(databaseURL string, pool PoolOptions) (*Database, error) {
 	db, err := sql.Open("postgres", databaseURL)
 	if err != nil {
 		return nil, err
 	}
 
 	if err = db.Ping(); err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
 
-	return &Database{db: db}, nil
+	return &Database{pool: db, db: db}, nil
 }
 
 // Close closes the database connection
 func (d *Database) Close() error {
-	return d.db.Close()
+	return d.pool.Close()
+}
+
+// InTx runs fn with a Database whose statements share one transaction,
+// committed if fn returns nil and rolled back otherwise. Rows locked within
+// fn stay locked until then. Called within a transaction, fn joins it.
+func (d *Database) InTx(ctx context.Context, fn func(tx *Database) error) error {
+	if _, ok := d.db.(*sql.Tx); ok {
+		return fn(d)
+	}
+
+	tx, err := d.pool.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&Database{pool: d.pool, db: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // CreateUser creates a new user
 func (d *Database) CreateUser(ctx context.Context, user *User) error {
 	query := `
-		INSERT INTO users (email, full_name, cnic, wallet_id, public_key, encrypted_private_key, is_verified)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO users (email, full_name, cnic, wallet_id, public_key, encrypted_private_key, is_verified, custody_mode)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)
 		RETURNING id, created_at, updated_at
 	`
 
+	if user.CustodyMode == "" {
+		user.CustodyMode = CustodyCustodial
+	}
+
 	return d.db.QueryRowContext(ctx, query,
 		user.Email, user.FullName, user.CNIC, user.WalletID,
-		user.PublicKey, user.EncryptedPrivateKey, user.IsVerified,
+		user.PublicKey, user.EncryptedPrivateKey, user.IsVerified, user.CustodyMode,
 	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
 }
 
 // GetUserByEmail retrieves a user by email
 func (d *Database) GetUserByEmail(ctx context.Context, email string) (*User, error) {
 	query := `
-		SELECT id, email, full_name, cnic, wallet_id, public_key, encrypted_private_key, is_verified, created_at, updated_at
+		SELECT id, email, full_name, cnic, wallet_id, public_key, COALESCE(encrypted_private_key, ''), is_verified, role, tier, custody_mode, created_at, updated_at
 		FROM users WHERE email = $1
 	`
 
 	user := &User{}
 	err := d.db.QueryRowContext(ctx, query, email).Scan(
 		&user.ID, &user.Email, &user.FullName, &user.CNIC, &user.WalletID,
-		&user.PublicKey, &user.EncryptedPrivateKey, &user.IsVerified, &user.CreatedAt, &user.UpdatedAt,
+		&user.PublicKey, &user.EncryptedPrivateKey, &user.IsVerified, &user.Role, &user.Tier, &user.CustodyMode, &user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -73,14 +122,14 @@ func (d *Database) GetUserByEmail(ctx context.Context, email string) (*User, err
 // GetUserByWalletID retrieves a user by wallet ID
 func (d *Database) GetUserByWalletID(ctx context.Context, walletID string) (*User, error) {
 	query := `
-		SELECT id, email, full_name, cnic, wallet_id, public_key, encrypted_private_key, is_verified, created_at, updated_at
+		SELECT id, email, full_name, cnic, wallet_id, public_key, COALESCE(encrypted_private_key, ''), is_verified, role, tier, custody_mode, created_at, updated_at
 		FROM users WHERE wallet_id = $1
 	`
 
 	user := &User{}
 	err := d.db.QueryRowContext(ctx, query, walletID).Scan(
 		&user.ID, &user.Email, &user.FullName, &user.CNIC, &user.WalletID,
-		&user.PublicKey, &user.EncryptedPrivateKey, &user.IsVerified, &user.CreatedAt, &user.UpdatedAt,
+		&user.PublicKey, &user.EncryptedPrivateKey, &user.IsVerified, &user.Role, &user.Tier, &user.CustodyMode, &user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -92,14 +141,14 @@ func (d *Database) GetUserByWalletID(ctx context.Context, walletID string) (*Use
 // GetUserByID retrieves a user by ID
 func (d *Database) GetUserByID(ctx context.Context, userID string) (*User, error) {
 	query := `
-		SELECT id, email, full_name, cnic, wallet_id, public_key, encrypted_private_key, is_verified, created_at, updated_at
+		SELECT id, email, full_name, cnic, wallet_id, public_key, COALESCE(encrypted_private_key, ''), is_verified, role, tier, custody_mode, created_at, updated_at
 		FROM users WHERE id = $1
 	`
 
 	user := &User{}
 	err := d.db.QueryRowContext(ctx, query, userID).Scan(
 		&user.ID, &user.Email, &user.FullName, &user.CNIC, &user.WalletID,
-		&user.PublicKey, &user.EncryptedPrivateKey, &user.IsVerified, &user.CreatedAt, &user.UpdatedAt,
+		&user.PublicKey, &user.EncryptedPrivateKey, &user.IsVerified, &user.Role, &user.Tier, &user.CustodyMode, &user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -115,23 +164,76 @@ func (d *Database) UpdateUserVerification(ctx context.Context, userID string, is
 	return err
 }
 
+// GetUsers returns users ordered by creation, optionally only those with role
+func (d *Database) GetUsers(ctx context.Context, role string, limit, offset int) ([]*User, error) {
+	query := `
+		SELECT id, email, full_name, cnic, wallet_id, public_key, COALESCE(encrypted_private_key, ''), is_verified, role, tier, custody_mode, created_at, updated_at
+		FROM users
+		WHERE $1 = '' OR role = $1
+		ORDER BY created_at
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, role, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*User
+	for rows.Next() {
+		user := &User{}
+		err := rows.Scan(
+			&user.ID, &user.Email, &user.FullName, &user.CNIC, &user.WalletID,
+			&user.PublicKey, &user.EncryptedPrivateKey, &user.IsVerified, &user.Role, &user.Tier, &user.CustodyMode, &user.CreatedAt, &user.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// CountUsersByRole returns how many users hold role
+func (d *Database) CountUsersByRole(ctx context.Context, role string) (int, error) {
+	var count int
+	err := d.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE role = $1`, role).Scan(&count)
+	return count, err
+}
+
+// UpdateUserRole sets a user's role, reporting whether the user exists
+func (d *Database) UpdateUserRole(ctx context.Context, userID, role string) (bool, error) {
+	result, err := d.db.ExecContext(ctx, `UPDATE users SET role = $1, updated_at = NOW() WHERE id = $2`, role, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
 // CreateWallet creates a new wallet
 func (d *Database) CreateWallet(ctx context.Context, wallet *Wallet) error {
 	query := `
-		INSERT INTO wallets (user_id, wallet_address, balance_cache, last_updated)
-		VALUES ($1, $2, $3, NOW())
-		RETURNING id
+		INSERT INTO wallets (user_id, wallet_address, balance_cache, last_updated, wallet_type)
+		VALUES ($1, $2, $3, NOW(), $4)
+		RETURNING id, status
 	`
 
+	if wallet.WalletType == "" {
+		wallet.WalletType = WalletTypeStandard
+	}
+
 	return d.db.QueryRowContext(ctx, query,
-		wallet.UserID, wallet.WalletAddress, wallet.BalanceCache,
-	).Scan(&wallet.ID)
+		wallet.UserID, wallet.WalletAddress, wallet.BalanceCache, wallet.WalletType,
+	).Scan(&wallet.ID, &wallet.Status)
 }
 
 // GetWalletsByUserID retrieves all wallets for a user
 func (d *Database) GetWalletsByUserID(ctx context.Context, userID string) ([]*Wallet, error) {
 	query := `
-		SELECT id, user_id, wallet_address, balance_cache, last_updated, zakat_deducted_this_month
+		SELECT id, user_id, wallet_address, balance_cache, last_updated, zakat_deducted_this_month, wallet_type, status, COALESCE(status_reason, '')
 		FROM wallets WHERE user_id = $1
 	`
 
@@ -145,7 +247,8 @@ func (d *Database) GetWalletsByUserID(ctx context.Context, userID string) ([]*Wa
 	for rows.Next() {
 		wallet := &Wallet{}
 		err := rows.Scan(
-			&wallet.ID, &wallet.UserID, &wallet.WalletAddress, &wallet.BalanceCache, &wallet.LastUpdated, &wallet.ZakatDeducted,
+			&wallet.ID, &wallet.UserID, &wallet.WalletAddress, &wallet.BalanceCache, &wallet.LastUpdated, &wallet.ZakatDeducted, &wallet.WalletType,
+			&wallet.Status, &wallet.StatusReason,
 		)
 		if err != nil {
 			return nil, err
@@ -158,14 +261,36 @@ func (d *Database) GetWalletsByUserID(ctx context.Context, userID string) ([]*Wa
 
 // GetWalletByAddress retrieves a wallet by address
 func (d *Database) GetWalletByAddress(ctx context.Context, address string) (*Wallet, error) {
+	return d.getWallet(ctx, address, "")
+}
+
+// GetWalletForUser retrieves a wallet the user owns or co-signs as a
+// multisig signer, or nil if there is no such wallet
+func (d *Database) GetWalletForUser(ctx context.Context, address, userID string) (*Wallet, error) {
+	return d.getWallet(ctx, address, `
+		AND (user_id = $2 OR EXISTS (
+			SELECT 1 FROM multisig_wallets m JOIN multisig_signers s ON s.multisig_wallet_id = m.id
+			WHERE m.wallet_address = wallets.wallet_address AND s.user_id = $2
+		))`, userID)
+}
+
+// LockWallet retrieves a wallet by address and locks its row until the
+// transaction ends. Use it within InTx.
+func (d *Database) LockWallet(ctx context.Context, address string) (*Wallet, error) {
+	return d.getWallet(ctx, address, " FOR UPDATE")
+}
+
+// getWallet retrieves a wallet by address, appending clause and its
+// arguments to the query
+func (d *Database) getWallet(ctx context.Context, address, clause string, args ...interface{}) (*Wallet, error) {
 	query := `
-		SELECT id, user_id, wallet_address, balance_cache, last_updated, zakat_deducted_this_month
-		FROM wallets WHERE wallet_address = $1
-	`
+		SELECT id, user_id, wallet_address, balance_cache, last_updated, zakat_deducted_this_month, wallet_type, status, COALESCE(status_reason, '')
+		FROM wallets WHERE wallet_address = $1` + clause
 
 	wallet := &Wallet{}
-	err := d.db.QueryRowContext(ctx, query, address).Scan(
-		&wallet.ID, &wallet.UserID, &wallet.WalletAddress, &wallet.BalanceCache, &wallet.LastUpdated, &wallet.ZakatDeducted,
+	err := d.db.QueryRowContext(ctx, query, append([]interface{}{address}, args...)...).Scan(
+		&wallet.ID, &wallet.UserID, &wallet.WalletAddress, &wallet.BalanceCache, &wallet.LastUpdated, &wallet.ZakatDeducted, &wallet.WalletType,
+		&wallet.Status, &wallet.StatusReason,
 	)
 
 	if err == sql.ErrNoRows {
@@ -181,31 +306,100 @@ func (d *Database) UpdateWalletBalance(ctx context.Context, walletAddress string
 	return err
 }
 
-// CreateTransaction creates a new transaction record
+// UpdateWalletStatus sets a wallet's status and the reason for it,
+// reporting whether the wallet exists
+func (d *Database) UpdateWalletStatus(ctx context.Context, walletAddress, status, reason string) (bool, error) {
+	query := `UPDATE wallets SET status = $1, status_reason = NULLIF($2, '') WHERE wallet_address = $3`
+	result, err := d.db.ExecContext(ctx, query, status, reason, walletAddress)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetWalletUTXOBalances returns every wallet's cached balance alongside the
+// sum of its unspent outputs
+func (d *Database) GetWalletUTXOBalances(ctx context.Context) ([]*WalletBalance, error) {
+	query := `
+		SELECT w.wallet_address, w.balance_cache,
+			COALESCE(SUM(u.amount) FILTER (WHERE NOT u.is_spent), 0),
+			COUNT(u.id)
+		FROM wallets w
+		LEFT JOIN utxos u ON u.wallet_address = w.wallet_address
+		GROUP BY w.wallet_address, w.balance_cache
+		ORDER BY w.wallet_address
+	`
+
+	rows, err := d.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []*WalletBalance
+	for rows.Next() {
+		b := &WalletBalance{}
+		if err := rows.Scan(&b.WalletAddress, &b.BalanceCache, &b.UTXOBalance, &b.UTXOCount); err != nil {
+			return nil, err
+		}
+		balances = append(balances, b)
+	}
+	return balances, rows.Err()
+}
+
+// CreateTransaction creates a new transaction record. It returns
+// ErrSignatureReused if tx has the signing hash of a recorded transaction.
 func (d *Database) CreateTransaction(ctx context.Context, tx *Transaction) error {
 	query := `
-		INSERT INTO transactions (transaction_hash, sender_wallet, receiver_wallet, amount, fee, note, signature, status, transaction_type)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO transactions (transaction_hash, sender_wallet, receiver_wallet, amount, fee, note, signature, status, transaction_type, lock_time, lock_until, signed_at, signing_hash)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NULLIF($13, ''))
 		RETURNING id, created_at
 	`
 
-	return d.db.QueryRowContext(ctx, query,
+	err := d.db.QueryRowContext(ctx, query,
 		tx.TransactionHash, tx.SenderWallet, tx.ReceiverWallet, tx.Amount,
-		tx.Fee, tx.Note, tx.Signature, tx.Status, tx.TransactionType,
+		tx.Fee, tx.Note, tx.Signature, tx.Status, tx.TransactionType, tx.LockTime, tx.LockUntil, tx.SignedAt, tx.SigningHash,
 	).Scan(&tx.ID, &tx.CreatedAt)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_transactions_signing_hash" {
+		return ErrSignatureReused
+	}
+	return err
+}
+
+// SigningHashUsed reports whether a transaction with the signing hash was
+// already recorded
+func (d *Database) SigningHashUsed(ctx context.Context, signingHash string) (bool, error) {
+	txHash, err := d.GetTransactionHashBySigningHash(ctx, signingHash)
+	return txHash != "", err
+}
+
+// GetTransactionHashBySigningHash returns the hash of the transaction recorded
+// with the signing hash, or "" if there is none
+func (d *Database) GetTransactionHashBySigningHash(ctx context.Context, signingHash string) (string, error) {
+	var txHash string
+	err := d.db.QueryRowContext(ctx,
+		`SELECT transaction_hash FROM transactions WHERE signing_hash = $1`, signingHash,
+	).Scan(&txHash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return txHash, err
 }
 
 // GetTransactionByHash retrieves a transaction by hash
 func (d *Database) GetTransactionByHash(ctx context.Context, hash string) (*Transaction, error) {
 	query := `
-		SELECT id, transaction_hash, block_hash, sender_wallet, receiver_wallet, amount, fee, note, signature, status, created_at, transaction_type
+		SELECT id, transaction_hash, block_hash, sender_wallet, receiver_wallet, amount, fee, note, signature, status, created_at, transaction_type, lock_time, lock_until, signed_at
 		FROM transactions WHERE transaction_hash = $1
 	`
 
 	tx := &Transaction{}
 	err := d.db.QueryRowContext(ctx, query, hash).Scan(
 		&tx.ID, &tx.TransactionHash, &tx.BlockHash, &tx.SenderWallet, &tx.ReceiverWallet,
-		&tx.Amount, &tx.Fee, &tx.Note, &tx.Signature, &tx.Status, &tx.CreatedAt, &tx.TransactionType,
+		&tx.Amount, &tx.Fee, &tx.Note, &tx.Signature, &tx.Status, &tx.CreatedAt, &tx.TransactionType, &tx.LockTime, &tx.LockUntil, &tx.SignedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -217,7 +411,7 @@ func (d *Database) GetTransactionByHash(ctx context.Context, hash string) (*Tran
 // GetTransactionsByWallet retrieves all transactions for a wallet
 func (d *Database) GetTransactionsByWallet(ctx context.Context, walletAddress string, limit int, offset int) ([]*Transaction, error) {
 	query := `
-		SELECT id, transaction_hash, block_hash, sender_wallet, receiver_wallet, amount, fee, note, signature, status, created_at, transaction_type
+		SELECT id, transaction_hash, block_hash, sender_wallet, receiver_wallet, amount, fee, note, signature, status, created_at, transaction_type, lock_time, lock_until, signed_at
 		FROM transactions
 		WHERE sender_wallet = $1 OR receiver_wallet = $1
 		ORDER BY created_at DESC
@@ -235,7 +429,7 @@ func (d *Database) GetTransactionsByWallet(ctx context.Context, walletAddress st
 		tx := &Transaction{}
 		err := rows.Scan(
 			&tx.ID, &tx.TransactionHash, &tx.BlockHash, &tx.SenderWallet, &tx.ReceiverWallet,
-			&tx.Amount, &tx.Fee, &tx.Note, &tx.Signature, &tx.Status, &tx.CreatedAt, &tx.TransactionType,
+			&tx.Amount, &tx.Fee, &tx.Note, &tx.Signature, &tx.Status, &tx.CreatedAt, &tx.TransactionType, &tx.LockTime, &tx.LockUntil, &tx.SignedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -282,24 +476,48 @@ func (d *Database) GetBlockByHash(ctx context.Context, hash string) (*Block, err
 // CreateUTXO creates a new UTXO
 func (d *Database) CreateUTXO(ctx context.Context, utxo *UTXO) error {
 	query := `
-		INSERT INTO utxos (transaction_hash, output_index, wallet_address, amount, is_spent)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO utxos (transaction_hash, output_index, wallet_address, amount, is_spent, lock_until)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, created_at
 	`
 
 	return d.db.QueryRowContext(ctx, query,
-		utxo.TransactionHash, utxo.OutputIndex, utxo.WalletAddress, utxo.Amount, utxo.IsSpent,
+		utxo.TransactionHash, utxo.OutputIndex, utxo.WalletAddress, utxo.Amount, utxo.IsSpent, utxo.LockUntil,
 	).Scan(&utxo.ID, &utxo.CreatedAt)
 }
 
 // GetUTXOsByWallet retrieves all UTXOs for a wallet
 func (d *Database) GetUTXOsByWallet(ctx context.Context, walletAddress string) ([]*UTXO, error) {
 	query := `
-		SELECT id, transaction_hash, output_index, wallet_address, amount, is_spent, spent_in_transaction, created_at
+		SELECT id, transaction_hash, output_index, wallet_address, amount, is_spent, spent_in_transaction, created_at, lock_until
 		FROM utxos WHERE wallet_address = $1 AND is_spent = false
 	`
 
-	rows, err := d.db.QueryContext(ctx, query, walletAddress)
+	return d.queryUTXOs(ctx, query, walletAddress)
+}
+
+// GetSpendableUTXOsByWallet retrieves the unspent UTXOs for a wallet whose
+// lock_until has passed at the given chain height and Unix time. Lock values
+// below 500000000 are block heights, larger values are timestamps. Within
+// InTx the rows stay locked until the transaction ends.
+func (d *Database) GetSpendableUTXOsByWallet(ctx context.Context, walletAddress string, height int64, now int64) ([]*UTXO, error) {
+	query := `
+		SELECT id, transaction_hash, output_index, wallet_address, amount, is_spent, spent_in_transaction, created_at, lock_until
+		FROM utxos
+		WHERE wallet_address = $1 AND is_spent = false
+		  AND (lock_until <= 0
+		       OR (lock_until < 500000000 AND lock_until <= $2)
+		       OR (lock_until >= 500000000 AND lock_until <= $3))
+		ORDER BY created_at
+		FOR UPDATE
+	`
+
+	return d.queryUTXOs(ctx, query, walletAddress, height, now)
+}
+
+// queryUTXOs runs a UTXO query and scans the rows
+func (d *Database) queryUTXOs(ctx context.Context, query string, args ...interface{}) ([]*UTXO, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -311,7 +529,7 @@ func (d *Database) GetUTXOsByWallet(ctx context.Context, walletAddress string) (
 		var spentIn sql.NullString
 		err := rows.Scan(
 			&utxo.ID, &utxo.TransactionHash, &utxo.OutputIndex, &utxo.WalletAddress,
-			&utxo.Amount, &utxo.IsSpent, &spentIn, &utxo.CreatedAt,
+			&utxo.Amount, &utxo.IsSpent, &spentIn, &utxo.CreatedAt, &utxo.LockUntil,
 		)
 		if err != nil {
 			return nil, err
@@ -341,33 +559,30 @@ func (d *Database) MarkUTXOAsSpent(ctx context.Context, txHash string, outputInd
 // CreateZakatTransaction creates a zakat transaction record
 func (d *Database) CreateZakatTransaction(ctx context.Context, zt *ZakatTransaction) error {
 	query := `
-		INSERT INTO zakat_transactions (wallet_address, amount, zakat_percentage, transaction_hash, month_year)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO zakat_transactions (wallet_address, amount, zakat_percentage, sadaqah_amount, transaction_hash, month_year)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
 		RETURNING id, created_at
 	`
 
 	return d.db.QueryRowContext(ctx, query,
-		zt.WalletAddress, zt.Amount, zt.ZakatPercentage, zt.TransactionHash, zt.MonthYear,
+		zt.WalletAddress, zt.Amount, zt.ZakatPercentage, zt.SadaqahAmount, zt.TransactionHash, zt.MonthYear,
 	).Scan(&zt.ID, &zt.CreatedAt)
 }
 
-// CreateSystemLog creates a system log entry
-func (d *Database) CreateSystemLog(ctx context.Context, log *SystemLog) error {
-	query := `
-		INSERT INTO system_logs (log_type, message, wallet_address, ip_address, user_agent)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, created_at
-	`
-
-	return d.db.QueryRowContext(ctx, query,
-		log.LogType, log.Message, log.WalletAddress, log.IPAddress, log.UserAgent,
-	).Scan(&log.ID, &log.CreatedAt)
+// nullJSON stores empty JSON as NULL
+func nullJSON(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return []byte(raw)
 }
 
 // GetSystemLogs retrieves system logs with optional filtering
 func (d *Database) GetSystemLogs(ctx context.Context, logType string, limit int, offset int) ([]SystemLog, error) {
 	query := `
-		SELECT id, log_type, message, wallet_address, ip_address, user_agent, created_at
+		SELECT id, log_type, message, COALESCE(actor_id::text, ''), COALESCE(wallet_address, ''),
+			COALESCE(host(ip_address), ''), COALESCE(user_agent, ''), COALESCE(request_id, ''),
+			before_state, after_state, COALESCE(sequence, 0), COALESCE(prev_hash, ''), COALESCE(entry_hash, ''), created_at
 		FROM system_logs
 	`
 	args := []interface{}{}
@@ -401,44 +616,51 @@ func (d *Database) GetSystemLogs(ctx context.Context, logType string, limit int,
 	var logs []SystemLog
 	for rows.Next() {
 		var log SystemLog
-		if err := rows.Scan(&log.ID, &log.LogType, &log.Message, &log.WalletAddress, &log.IPAddress, &log.UserAgent, &log.CreatedAt); err != nil {
+		var before, after []byte
+		if err := rows.Scan(&log.ID, &log.LogType, &log.Message, &log.ActorID, &log.WalletAddress,
+			&log.IPAddress, &log.UserAgent, &log.RequestID, &before, &after,
+			&log.Sequence, &log.PrevHash, &log.EntryHash, &log.CreatedAt); err != nil {
 			return nil, err
 		}
+		log.Before, log.After = before, after
 		logs = append(logs, log)
 	}
 
 	return logs, rows.Err()
 }
 
-// GetSystemLogStats retrieves statistics about system logs
+// GetSystemLogStats retrieves statistics about system logs: the totals the
+// dashboard shows and a count for every log type
 func (d *Database) GetSystemLogStats(ctx context.Context) (map[string]interface{}, error) {
-	query := `
-		SELECT 
-			COUNT(*) as total_logs,
-			COUNT(CASE WHEN log_type = 'TRANSACTION_CREATED' THEN 1 END) as transaction_logs,
-			COUNT(CASE WHEN log_type = 'BLOCK_MINED' THEN 1 END) as block_logs,
-			COUNT(CASE WHEN log_type = 'ZAKAT_DEDUCTED' THEN 1 END) as zakat_logs,
-			COUNT(CASE WHEN log_type = 'ERROR' THEN 1 END) as error_logs,
-			COUNT(CASE WHEN log_type = 'AUTH' THEN 1 END) as auth_logs
-		FROM system_logs
-	`
-
-	var stats map[string]interface{} = make(map[string]interface{})
-	var totalLogs, transactionLogs, blockLogs, zakatLogs, errorLogs, authLogs int
-
-	err := d.db.QueryRowContext(ctx, query).Scan(
-		&totalLogs, &transactionLogs, &blockLogs, &zakatLogs, &errorLogs, &authLogs,
-	)
+	rows, err := d.db.QueryContext(ctx, `SELECT log_type, COUNT(*) FROM system_logs GROUP BY log_type`)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	stats["total_logs"] = totalLogs
-	stats["transaction_logs"] = transactionLogs
-	stats["block_logs"] = blockLogs
-	stats["zakat_logs"] = zakatLogs
-	stats["error_logs"] = errorLogs
-	stats["auth_logs"] = authLogs
+	byType := make(map[string]int)
+	total := 0
+	for rows.Next() {
+		var logType string
+		var count int
+		if err := rows.Scan(&logType, &count); err != nil {
+			return nil, err
+		}
+		byType[logType] = count
+		total += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]interface{})
+	stats["total_logs"] = total
+	stats["transaction_logs"] = byType[LogTransactionCreated]
+	stats["block_logs"] = byType[LogBlockMined]
+	stats["zakat_logs"] = byType[LogZakatDeducted]
+	stats["error_logs"] = byType[LogError]
+	stats["auth_logs"] = byType[LogAuth]
+	stats["by_type"] = byType
 
 	return stats, nil
 }
@@ -517,7 +739,7 @@ func (d *Database) GetBlocks(ctx context.Context, limit int, offset int) ([]*Blo
 // GetTransactionsByBlockHash retrieves transactions for a block
 func (d *Database) GetTransactionsByBlockHash(ctx context.Context, blockHash string, limit int, offset int) ([]*Transaction, error) {
 	query := `
-		SELECT id, transaction_hash, block_hash, sender_wallet, receiver_wallet, amount, fee, signature, status, transaction_type, note, created_at
+		SELECT id, transaction_hash, block_hash, sender_wallet, receiver_wallet, amount, fee, signature, status, transaction_type, note, created_at, lock_time, lock_until, signed_at
 		FROM transactions
 		WHERE block_hash = $1
 		ORDER BY created_at DESC
@@ -535,7 +757,7 @@ func (d *Database) GetTransactionsByBlockHash(ctx context.Context, blockHash str
 		tx := &Transaction{}
 		err := rows.Scan(
 			&tx.ID, &tx.TransactionHash, &tx.BlockHash, &tx.SenderWallet, &tx.ReceiverWallet,
-			&tx.Amount, &tx.Fee, &tx.Signature, &tx.Status, &tx.TransactionType, &tx.Note, &tx.CreatedAt,
+			&tx.Amount, &tx.Fee, &tx.Signature, &tx.Status, &tx.TransactionType, &tx.Note, &tx.CreatedAt, &tx.LockTime, &tx.LockUntil, &tx.SignedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -549,7 +771,7 @@ func (d *Database) GetTransactionsByBlockHash(ctx context.Context, blockHash str
 // GetTransactionsByStatus retrieves transactions by status
 func (d *Database) GetTransactionsByStatus(ctx context.Context, status string, limit int) ([]*Transaction, error) {
 	query := `
-		SELECT id, transaction_hash, block_hash, sender_wallet, receiver_wallet, amount, fee, signature, status, transaction_type, note, created_at
+		SELECT id, transaction_hash, block_hash, sender_wallet, receiver_wallet, amount, fee, signature, status, transaction_type, note, created_at, lock_time, lock_until, signed_at
 		FROM transactions
 		WHERE status = $1
 		ORDER BY created_at ASC
@@ -567,7 +789,7 @@ func (d *Database) GetTransactionsByStatus(ctx context.Context, status string, l
 		tx := &Transaction{}
 		err := rows.Scan(
 			&tx.ID, &tx.TransactionHash, &tx.BlockHash, &tx.SenderWallet, &tx.ReceiverWallet,
-			&tx.Amount, &tx.Fee, &tx.Signature, &tx.Status, &tx.TransactionType, &tx.Note, &tx.CreatedAt,
+			&tx.Amount, &tx.Fee, &tx.Signature, &tx.Status, &tx.TransactionType, &tx.Note, &tx.CreatedAt, &tx.LockTime, &tx.LockUntil, &tx.SignedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -590,7 +812,19 @@ func (d *Database) UpdateTransactionStatus(ctx context.Context, txHash string, s
 	return err
 }
 
+// Stats returns connection pool statistics
+func (d *Database) Stats() sql.DBStats {
+	return d.pool.Stats()
+}
+
+// CountTransactionsByStatus counts transactions with the given status
+func (d *Database) CountTransactionsByStatus(ctx context.Context, status string) (int64, error) {
+	var count int64
+	err := d.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM transactions WHERE status = $1`, status).Scan(&count)
+	return count, err
+}
+
 // Ping checks the database connection
 func (d *Database) Ping(ctx context.Context) error {
-	return d.db.PingContext(ctx)
+	return d.pool.PingContext(ctx)
 }
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// GetUserTOTP returns a user's authenticator enrolment, or nil if none
+func (d *Database) GetUserTOTP(ctx context.Context, userID string) (*UserTOTP, error) {
+	t := &UserTOTP{}
+	var enabledAt sql.NullTime
+	err := d.db.QueryRowContext(ctx, `
+		SELECT user_id, encrypted_secret, enabled, last_step, enabled_at, created_at
+		FROM user_totp WHERE user_id = $1
+	`, userID).Scan(&t.UserID, &t.EncryptedSecret, &t.Enabled, &t.LastStep, &enabledAt, &t.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if enabledAt.Valid {
+		t.EnabledAt = &enabledAt.Time
+	}
+	return t, nil
+}
+
+// SaveUserTOTPSecret starts or restarts an enrolment with a new secret,
+// reporting false if the user already has an enabled one
+func (d *Database) SaveUserTOTPSecret(ctx context.Context, userID, encryptedSecret string) (bool, error) {
+	result, err := d.db.ExecContext(ctx, `
+		INSERT INTO user_totp (user_id, encrypted_secret, enabled, last_step)
+		VALUES ($1, $2, FALSE, 0)
+		ON CONFLICT (user_id) DO UPDATE
+		SET encrypted_secret = EXCLUDED.encrypted_secret, last_step = 0, created_at = NOW()
+		WHERE user_totp.enabled = FALSE
+	`, userID, encryptedSecret)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// EnableUserTOTP confirms an enrolment with the step of its first code
+func (d *Database) EnableUserTOTP(ctx context.Context, userID string, step int64) (bool, error) {
+	result, err := d.db.ExecContext(ctx, `
+		UPDATE user_totp SET enabled = TRUE, enabled_at = NOW(), last_step = $2
+		WHERE user_id = $1 AND enabled = FALSE
+	`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// UseTOTPStep records that a code of step was used, reporting false if that
+// step or a later one was already used, so each code is accepted only once
+func (d *Database) UseTOTPStep(ctx context.Context, userID string, step int64) (bool, error) {
+	result, err := d.db.ExecContext(ctx, `
+		UPDATE user_totp SET last_step = $2
+		WHERE user_id = $1 AND enabled = TRUE AND last_step < $2
+	`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteUserTOTP removes a user's authenticator enrolment
+func (d *Database) DeleteUserTOTP(ctx context.Context, userID string) error {
+	_, err := d.db.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID)
+	return err
+}
+
+// CreateOTPCode stores a new code, replacing the user's outstanding codes for
+// the same purpose and clearing their used and expired ones
+func (d *Database) CreateOTPCode(ctx context.Context, code *OTPCode) error {
+	_, err := d.db.ExecContext(ctx, `
+		DELETE FROM otp_codes
+		WHERE user_id = $1 AND (purpose = $2 OR consumed_at IS NOT NULL OR expires_at < NOW())
+	`, code.UserID, code.Purpose)
+	if err != nil {
+		return err
+	}
+
+	return d.db.QueryRowContext(ctx, `
+		INSERT INTO otp_codes (user_id, purpose, code_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, code.UserID, code.Purpose, code.CodeHash, code.ExpiresAt).Scan(&code.ID, &code.CreatedAt)
+}
+
+// GetActiveOTPCode returns a user's unused code for a purpose, or nil
+func (d *Database) GetActiveOTPCode(ctx context.Context, userID, purpose string) (*OTPCode, error) {
+	code := &OTPCode{}
+	err := d.db.QueryRowContext(ctx, `
+		SELECT id, user_id, purpose, code_hash, attempts, expires_at, created_at
+		FROM otp_codes
+		WHERE user_id = $1 AND purpose = $2 AND consumed_at IS NULL
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, userID, purpose).Scan(&code.ID, &code.UserID, &code.Purpose, &code.CodeHash,
+		&code.Attempts, &code.ExpiresAt, &code.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return code, err
+}
+
+// RecordOTPAttempt counts a wrong guess against a code
+func (d *Database) RecordOTPAttempt(ctx context.Context, id string) error {
+	_, err := d.db.ExecContext(ctx, `UPDATE otp_codes SET attempts = attempts + 1 WHERE id = $1`, id)
+	return err
+}
+
+// ConsumeOTPCode marks a code used, reporting false if it already was
+func (d *Database) ConsumeOTPCode(ctx context.Context, id string) (bool, error) {
+	result, err := d.db.ExecContext(ctx, `
+		UPDATE otp_codes SET consumed_at = NOW() WHERE id = $1 AND consumed_at IS NULL
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// CreateUnlockToken stores a private key sealed under an unlock token, keyed
+// by the token's hash
+func (d *Database) CreateUnlockToken(ctx context.Context, tokenHash, userID, sealedKey string, expiresAt time.Time) error {
+	_, err := d.db.ExecContext(ctx, `
+		INSERT INTO unlock_tokens (token_hash, user_id, sealed_key, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`, tokenHash, userID, sealedKey, expiresAt)
+	return err
+}
+
+// GetUnlockToken returns the key sealed under the unexpired unlock token
+// with tokenHash issued to userID, or "" if there is none
+func (d *Database) GetUnlockToken(ctx context.Context, tokenHash, userID string) (string, error) {
+	var sealedKey string
+	err := d.db.QueryRowContext(ctx, `
+		SELECT sealed_key FROM unlock_tokens
+		WHERE token_hash = $1 AND user_id = $2 AND expires_at > NOW()
+	`, tokenHash, userID).Scan(&sealedKey)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return sealedKey, err
+}
+
+// DeleteUnlockTokensBefore removes unlock tokens that expired before cutoff
+func (d *Database) DeleteUnlockTokensBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := d.db.ExecContext(ctx, `DELETE FROM unlock_tokens WHERE expires_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
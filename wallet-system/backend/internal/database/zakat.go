@@ -0,0 +1,398 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// GetWalletsPendingZakat returns wallets that are not closed and have no
+// zakat deduction recorded for monthYear, excluding the pool wallet itself.
+// Frozen wallets are included so a run can record why it skipped them.
+func (d *Database) GetWalletsPendingZakat(ctx context.Context, monthYear, poolWallet string) ([]*Wallet, error) {
+	query := `
+		SELECT w.id, w.user_id, w.wallet_address, w.balance_cache, w.last_updated, w.zakat_deducted_this_month, w.wallet_type,
+			w.status, COALESCE(w.status_reason, '')
+		FROM wallets w
+		WHERE w.wallet_address <> $2 AND w.status <> 'closed'
+		AND NOT EXISTS (
+			SELECT 1 FROM zakat_transactions z
+			WHERE z.wallet_address = w.wallet_address AND z.month_year = $1
+		)
+		ORDER BY w.wallet_address
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, monthYear, poolWallet)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var wallets []*Wallet
+	for rows.Next() {
+		wallet := &Wallet{}
+		err := rows.Scan(
+			&wallet.ID, &wallet.UserID, &wallet.WalletAddress, &wallet.BalanceCache, &wallet.LastUpdated, &wallet.ZakatDeducted, &wallet.WalletType,
+			&wallet.Status, &wallet.StatusReason,
+		)
+		if err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, wallet)
+	}
+
+	return wallets, rows.Err()
+}
+
+// ReserveZakatTransaction inserts the zakat row for a wallet and month before
+// any funds move. It returns false when the month is already reserved, which
+// keeps deductions idempotent across concurrent or repeated runs.
+func (d *Database) ReserveZakatTransaction(ctx context.Context, zt *ZakatTransaction) (bool, error) {
+	query := `
+		INSERT INTO zakat_transactions (wallet_address, amount, zakat_percentage, sadaqah_amount, month_year)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (wallet_address, month_year) DO NOTHING
+		RETURNING id, created_at
+	`
+
+	err := d.db.QueryRowContext(ctx, query,
+		zt.WalletAddress, zt.Amount, zt.ZakatPercentage, zt.SadaqahAmount, zt.MonthYear,
+	).Scan(&zt.ID, &zt.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ReleaseZakatTransaction removes a reservation whose transfer never happened
+func (d *Database) ReleaseZakatTransaction(ctx context.Context, id string) error {
+	_, err := d.db.ExecContext(ctx, `DELETE FROM zakat_transactions WHERE id = $1 AND transaction_hash IS NULL`, id)
+	return err
+}
+
+// LinkZakatTransaction records the on-chain transaction of a zakat deduction
+// and flags the wallet as deducted for the month
+func (d *Database) LinkZakatTransaction(ctx context.Context, id, walletAddress, txHash string) error {
+	if _, err := d.db.ExecContext(ctx, `UPDATE zakat_transactions SET transaction_hash = $1 WHERE id = $2`, txHash, id); err != nil {
+		return err
+	}
+	_, err := d.db.ExecContext(ctx, `UPDATE wallets SET zakat_deducted_this_month = true WHERE wallet_address = $1`, walletAddress)
+	return err
+}
+
+// GetUnlinkedZakatTransactions returns reservations for monthYear made
+// before the given time that never got a transaction hash, e.g. because a run
+// stopped midway
+func (d *Database) GetUnlinkedZakatTransactions(ctx context.Context, monthYear string, before time.Time) ([]*ZakatTransaction, error) {
+	query := `
+		SELECT id, wallet_address, amount, zakat_percentage, month_year, created_at
+		FROM zakat_transactions
+		WHERE month_year = $1 AND transaction_hash IS NULL AND created_at < $2
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, monthYear, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*ZakatTransaction
+	for rows.Next() {
+		zt := &ZakatTransaction{}
+		if err := rows.Scan(&zt.ID, &zt.WalletAddress, &zt.Amount, &zt.ZakatPercentage, &zt.MonthYear, &zt.CreatedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, zt)
+	}
+
+	return records, rows.Err()
+}
+
+// FindZakatTransferHash returns the hash of the zakat transfer with the given
+// note sent from walletAddress, or "" if none exists
+func (d *Database) FindZakatTransferHash(ctx context.Context, walletAddress, note string) (string, error) {
+	query := `
+		SELECT transaction_hash FROM transactions
+		WHERE sender_wallet = $1 AND transaction_type = 'zakat' AND note = $2
+		ORDER BY created_at DESC LIMIT 1
+	`
+
+	var hash string
+	err := d.db.QueryRowContext(ctx, query, walletAddress, note).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return hash, err
+}
+
+// ResetZakatDeductedFlags clears zakat_deducted_this_month on wallets without
+// a deduction recorded for monthYear, so the flag follows the current month
+func (d *Database) ResetZakatDeductedFlags(ctx context.Context, monthYear string) error {
+	query := `
+		UPDATE wallets SET zakat_deducted_this_month = false
+		WHERE zakat_deducted_this_month = true
+		AND wallet_address NOT IN (
+			SELECT wallet_address FROM zakat_transactions
+			WHERE month_year = $1 AND transaction_hash IS NOT NULL
+		)
+	`
+
+	_, err := d.db.ExecContext(ctx, query, monthYear)
+	return err
+}
+
+// GetZakatHawl returns the hawl tracking row of a wallet, or nil if none
+func (d *Database) GetZakatHawl(ctx context.Context, walletAddress string) (*ZakatHawl, error) {
+	query := `
+		SELECT wallet_address, hawl_start, last_paid_at, updated_at
+		FROM zakat_hawl WHERE wallet_address = $1
+	`
+
+	hawl := &ZakatHawl{}
+	err := d.db.QueryRowContext(ctx, query, walletAddress).Scan(
+		&hawl.WalletAddress, &hawl.HawlStart, &hawl.LastPaidAt, &hawl.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return hawl, nil
+}
+
+// SetZakatHawlStart records when a wallet's current hawl began
+func (d *Database) SetZakatHawlStart(ctx context.Context, walletAddress string, hawlStart *time.Time) error {
+	query := `
+		INSERT INTO zakat_hawl (wallet_address, hawl_start, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (wallet_address) DO UPDATE SET hawl_start = EXCLUDED.hawl_start, updated_at = NOW()
+	`
+
+	_, err := d.db.ExecContext(ctx, query, walletAddress, hawlStart)
+	return err
+}
+
+// SetZakatHawlPaid closes a hawl at paidAt, which also starts the next one
+func (d *Database) SetZakatHawlPaid(ctx context.Context, walletAddress string, paidAt time.Time) error {
+	query := `
+		INSERT INTO zakat_hawl (wallet_address, hawl_start, last_paid_at, updated_at)
+		VALUES ($1, $2, $2, NOW())
+		ON CONFLICT (wallet_address) DO UPDATE SET hawl_start = $2, last_paid_at = $2, updated_at = NOW()
+	`
+
+	_, err := d.db.ExecContext(ctx, query, walletAddress, paidAt)
+	return err
+}
+
+// GetWalletBalanceHistory returns every credit and debit of a wallet derived
+// from its UTXOs, oldest first. Outputs are credited and debited at the time
+// of the transaction creating and spending them, so the inputs and change of
+// one transaction share a timestamp.
+func (d *Database) GetWalletBalanceHistory(ctx context.Context, walletAddress string) ([]BalanceChange, error) {
+	query := `
+		SELECT COALESCE(t.created_at, u.created_at) AS at, u.amount
+		FROM utxos u
+		LEFT JOIN transactions t ON t.transaction_hash = u.transaction_hash
+		WHERE u.wallet_address = $1
+		UNION ALL
+		SELECT COALESCE(t.created_at, u.created_at), -u.amount
+		FROM utxos u
+		LEFT JOIN transactions t ON t.transaction_hash = u.spent_in_transaction
+		WHERE u.wallet_address = $1 AND u.is_spent = true
+		ORDER BY at
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []BalanceChange
+	for rows.Next() {
+		var change BalanceChange
+		if err := rows.Scan(&change.At, &change.Amount); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+const zakatTransactionColumns = `id, wallet_address, amount, zakat_percentage, COALESCE(sadaqah_amount, 0), COALESCE(transaction_hash, ''), month_year, created_at`
+
+// GetZakatTransactionsByWallet returns a wallet's completed zakat deductions,
+// newest first. year (YYYY) limits them to one Gregorian year when not empty.
+func (d *Database) GetZakatTransactionsByWallet(ctx context.Context, walletAddress, year string) ([]*ZakatTransaction, error) {
+	query := `SELECT ` + zakatTransactionColumns + `
+		FROM zakat_transactions
+		WHERE wallet_address = $1 AND transaction_hash IS NOT NULL
+		AND ($2 = '' OR month_year LIKE $2 || '-%')
+		ORDER BY month_year DESC
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, walletAddress, year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*ZakatTransaction
+	for rows.Next() {
+		zt := &ZakatTransaction{}
+		err := rows.Scan(&zt.ID, &zt.WalletAddress, &zt.Amount, &zt.ZakatPercentage, &zt.SadaqahAmount, &zt.TransactionHash, &zt.MonthYear, &zt.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, zt)
+	}
+
+	return records, rows.Err()
+}
+
+// GetZakatTransactionByID returns a zakat deduction, or nil if none exists
+func (d *Database) GetZakatTransactionByID(ctx context.Context, id string) (*ZakatTransaction, error) {
+	query := `SELECT ` + zakatTransactionColumns + ` FROM zakat_transactions WHERE id = $1`
+
+	zt := &ZakatTransaction{}
+	err := d.db.QueryRowContext(ctx, query, id).Scan(
+		&zt.ID, &zt.WalletAddress, &zt.Amount, &zt.ZakatPercentage, &zt.SadaqahAmount, &zt.TransactionHash, &zt.MonthYear, &zt.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return zt, nil
+}
+
+// GetZakatMonthTotals sums completed zakat deductions, including sadaqah,
+// per month, newest first
+func (d *Database) GetZakatMonthTotals(ctx context.Context) ([]ZakatMonthTotal, error) {
+	query := `
+		SELECT month_year, COUNT(*), COALESCE(SUM(amount + COALESCE(sadaqah_amount, 0)), 0)
+		FROM zakat_transactions
+		WHERE transaction_hash IS NOT NULL
+		GROUP BY month_year
+		ORDER BY month_year DESC
+	`
+
+	rows, err := d.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []ZakatMonthTotal
+	for rows.Next() {
+		var total ZakatMonthTotal
+		if err := rows.Scan(&total.MonthYear, &total.Count, &total.Amount); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+
+	return totals, rows.Err()
+}
+
+// CreateZakatRecipient registers a recipient of zakat pool disbursements
+func (d *Database) CreateZakatRecipient(ctx context.Context, recipient *ZakatRecipient) error {
+	query := `
+		INSERT INTO zakat_recipients (name, wallet_address, description)
+		VALUES ($1, $2, $3)
+		RETURNING id, is_active, created_at
+	`
+
+	return d.db.QueryRowContext(ctx, query,
+		recipient.Name, recipient.WalletAddress, recipient.Description,
+	).Scan(&recipient.ID, &recipient.IsActive, &recipient.CreatedAt)
+}
+
+// GetZakatRecipients lists registered recipients by name
+func (d *Database) GetZakatRecipients(ctx context.Context) ([]*ZakatRecipient, error) {
+	query := `
+		SELECT id, name, wallet_address, COALESCE(description, ''), is_active, created_at
+		FROM zakat_recipients ORDER BY name
+	`
+
+	rows, err := d.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []*ZakatRecipient
+	for rows.Next() {
+		r := &ZakatRecipient{}
+		if err := rows.Scan(&r.ID, &r.Name, &r.WalletAddress, &r.Description, &r.IsActive, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, r)
+	}
+
+	return recipients, rows.Err()
+}
+
+// GetZakatRecipient returns a recipient, or nil if none exists
+func (d *Database) GetZakatRecipient(ctx context.Context, id string) (*ZakatRecipient, error) {
+	query := `
+		SELECT id, name, wallet_address, COALESCE(description, ''), is_active, created_at
+		FROM zakat_recipients WHERE id = $1
+	`
+
+	r := &ZakatRecipient{}
+	err := d.db.QueryRowContext(ctx, query, id).Scan(&r.ID, &r.Name, &r.WalletAddress, &r.Description, &r.IsActive, &r.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetZakatDisbursements lists transfers from the pool wallet to registered
+// recipients, newest first
+func (d *Database) GetZakatDisbursements(ctx context.Context, poolWallet string, limit int) ([]*ZakatDisbursement, error) {
+	query := `
+		SELECT t.transaction_hash, r.id, r.name, r.wallet_address, t.amount, COALESCE(t.note, ''), t.created_at
+		FROM transactions t
+		JOIN zakat_recipients r ON r.wallet_address = t.receiver_wallet
+		WHERE t.sender_wallet = $1 AND t.transaction_type = 'zakat_disbursement'
+		ORDER BY t.created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := d.db.QueryContext(ctx, query, poolWallet, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var disbursements []*ZakatDisbursement
+	for rows.Next() {
+		zd := &ZakatDisbursement{}
+		err := rows.Scan(&zd.TransactionHash, &zd.RecipientID, &zd.RecipientName, &zd.WalletAddress, &zd.Amount, &zd.Note, &zd.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		disbursements = append(disbursements, zd)
+	}
+
+	return disbursements, rows.Err()
+}
+
+// GetZakatDisbursedTotal sums all transfers from the pool to recipients
+func (d *Database) GetZakatDisbursedTotal(ctx context.Context, poolWallet string) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0) FROM transactions
+		WHERE sender_wallet = $1 AND transaction_type = 'zakat_disbursement'
+	`
+
+	var total float64
+	err := d.db.QueryRowContext(ctx, query, poolWallet).Scan(&total)
+	return total, err
+}
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// GetZakatSettings returns a user's zakat settings, defaulting to opted in
+// with no sadaqah when none were saved
+func (d *Database) GetZakatSettings(ctx context.Context, userID string) (*ZakatSettings, error) {
+	query := `
+		SELECT user_id, opted_in, sadaqah_percentage, updated_at
+		FROM zakat_settings WHERE user_id = $1
+	`
+
+	settings := &ZakatSettings{}
+	err := d.db.QueryRowContext(ctx, query, userID).Scan(
+		&settings.UserID, &settings.OptedIn, &settings.SadaqahPercentage, &settings.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return &ZakatSettings{UserID: userID, OptedIn: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SaveZakatSettings creates or replaces a user's zakat settings
+func (d *Database) SaveZakatSettings(ctx context.Context, settings *ZakatSettings) error {
+	query := `
+		INSERT INTO zakat_settings (user_id, opted_in, sadaqah_percentage, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET opted_in = EXCLUDED.opted_in, sadaqah_percentage = EXCLUDED.sadaqah_percentage, updated_at = NOW()
+		RETURNING updated_at
+	`
+
+	return d.db.QueryRowContext(ctx, query,
+		settings.UserID, settings.OptedIn, settings.SadaqahPercentage,
+	).Scan(&settings.UpdatedAt)
+}
+
+// GetZakatExemption returns a user's exemption, or nil if they have none
+func (d *Database) GetZakatExemption(ctx context.Context, userID string) (*ZakatExemption, error) {
+	query := `
+		SELECT user_id, reason, COALESCE(granted_by::text, ''), created_at
+		FROM zakat_exemptions WHERE user_id = $1
+	`
+
+	exemption := &ZakatExemption{}
+	err := d.db.QueryRowContext(ctx, query, userID).Scan(
+		&exemption.UserID, &exemption.Reason, &exemption.GrantedBy, &exemption.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return exemption, nil
+}
+
+// GetZakatExemptions lists all exemptions, newest first
+func (d *Database) GetZakatExemptions(ctx context.Context) ([]*ZakatExemption, error) {
+	query := `
+		SELECT user_id, reason, COALESCE(granted_by::text, ''), created_at
+		FROM zakat_exemptions ORDER BY created_at DESC
+	`
+
+	rows, err := d.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exemptions []*ZakatExemption
+	for rows.Next() {
+		exemption := &ZakatExemption{}
+		if err := rows.Scan(&exemption.UserID, &exemption.Reason, &exemption.GrantedBy, &exemption.CreatedAt); err != nil {
+			return nil, err
+		}
+		exemptions = append(exemptions, exemption)
+	}
+
+	return exemptions, rows.Err()
+}
+
+// SaveZakatExemption creates or replaces a user's exemption
+func (d *Database) SaveZakatExemption(ctx context.Context, exemption *ZakatExemption) error {
+	query := `
+		INSERT INTO zakat_exemptions (user_id, reason, granted_by)
+		VALUES ($1, $2, NULLIF($3, '')::uuid)
+		ON CONFLICT (user_id) DO UPDATE
+		SET reason = EXCLUDED.reason, granted_by = EXCLUDED.granted_by, created_at = NOW()
+		RETURNING created_at
+	`
+
+	return d.db.QueryRowContext(ctx, query,
+		exemption.UserID, exemption.Reason, exemption.GrantedBy,
+	).Scan(&exemption.CreatedAt)
+}
+
+// DeleteZakatExemption removes a user's exemption and reports whether one
+// existed
+func (d *Database) DeleteZakatExemption(ctx context.Context, userID string) (bool, error) {
+	result, err := d.db.ExecContext(ctx, `DELETE FROM zakat_exemptions WHERE user_id = $1`, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// CreateZakatDeclaration stores a declared asset or liability
+func (d *Database) CreateZakatDeclaration(ctx context.Context, declaration *ZakatDeclaration) error {
+	query := `
+		INSERT INTO zakat_declarations (user_id, wallet_address, kind, description, amount)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	return d.db.QueryRowContext(ctx, query,
+		declaration.UserID, declaration.WalletAddress, declaration.Kind, declaration.Description, declaration.Amount,
+	).Scan(&declaration.ID, &declaration.CreatedAt)
+}
+
+// GetZakatDeclarationsByUser lists a user's declarations, newest first
+func (d *Database) GetZakatDeclarationsByUser(ctx context.Context, userID string) ([]*ZakatDeclaration, error) {
+	query := `
+		SELECT id, user_id, wallet_address, kind, description, amount, created_at
+		FROM zakat_declarations WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	return d.queryZakatDeclarations(ctx, query, userID)
+}
+
+// GetZakatDeclarationsByWallet lists the declarations made against a wallet
+func (d *Database) GetZakatDeclarationsByWallet(ctx context.Context, walletAddress string) ([]*ZakatDeclaration, error) {
+	query := `
+		SELECT id, user_id, wallet_address, kind, description, amount, created_at
+		FROM zakat_declarations WHERE wallet_address = $1
+		ORDER BY created_at DESC
+	`
+
+	return d.queryZakatDeclarations(ctx, query, walletAddress)
+}
+
+// DeleteZakatDeclaration removes one of a user's declarations and reports
+// whether it existed
+func (d *Database) DeleteZakatDeclaration(ctx context.Context, id, userID string) (bool, error) {
+	result, err := d.db.ExecContext(ctx, `DELETE FROM zakat_declarations WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// queryZakatDeclarations runs a declaration query and scans the rows
+func (d *Database) queryZakatDeclarations(ctx context.Context, query string, args ...interface{}) ([]*ZakatDeclaration, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var declarations []*ZakatDeclaration
+	for rows.Next() {
+		zd := &ZakatDeclaration{}
+		err := rows.Scan(&zd.ID, &zd.UserID, &zd.WalletAddress, &zd.Kind, &zd.Description, &zd.Amount, &zd.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		declarations = append(declarations, zd)
+	}
+
+	return declarations, rows.Err()
+}
@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"time"
+
+	"crypto-wallet-backend/internal/blockchain"
+)
+
+// namespace prefixes every metric name
+const namespace = "wallet_"
+
+// blockIntervalBuckets are upper bounds in seconds between consecutive
+// blocks; mining is tuned for about a minute
+var blockIntervalBuckets = []float64{5, 15, 30, 60, 120, 300, 600, 1800, 3600, 21600}
+
+// Metrics are the node's metrics, updated by the handlers and services and
+// read from the database and chain on each scrape
+type Metrics struct {
+	*Registry
+
+	HTTPRequests   *Counter   // method, route, status
+	HTTPDuration   *Histogram // method, route
+	BlocksMined    *Counter   // source
+	BlockInterval  *Histogram
+	HashRate       *Gauge
+	Transfers      *Counter // type
+	TransferVolume *Counter // type
+	ZakatWallets   *Counter // outcome
+	ZakatRuns      *Counter // status
+	ZakatDeducted  *Counter
+}
+
+// New creates the node's metrics in a fresh registry
+func New() *Metrics {
+	r := NewRegistry()
+	return &Metrics{
+		Registry: r,
+		HTTPRequests: r.NewCounter(namespace+"http_requests_total",
+			"HTTP requests by method, route and status.", "method", "route", "status"),
+		HTTPDuration: r.NewHistogram(namespace+"http_request_duration_seconds",
+			"HTTP request latency by method and route.", DefaultBuckets, "method", "route"),
+		BlocksMined: r.NewCounter(namespace+"blocks_mined_total",
+			"Blocks mined by this node, by source: transactions or audit_anchor.", "source"),
+		BlockInterval: r.NewHistogram(namespace+"block_interval_seconds",
+			"Seconds between the timestamps of consecutive blocks mined by this node.", blockIntervalBuckets),
+		HashRate: r.NewGauge(namespace+"mining_hash_rate",
+			"Hashes per second achieved mining the latest block."),
+		Transfers: r.NewCounter(namespace+"transfers_total",
+			"Accepted transfers by transaction type.", "type"),
+		TransferVolume: r.NewCounter(namespace+"transfer_volume_total",
+			"Amount transferred by transaction type, excluding fees.", "type"),
+		ZakatWallets: r.NewCounter(namespace+"zakat_wallets_total",
+			"Wallets processed by zakat runs, by outcome.", "outcome"),
+		ZakatRuns: r.NewCounter(namespace+"zakat_runs_total",
+			"Zakat runs by status: succeeded or failed.", "status"),
+		ZakatDeducted: r.NewCounter(namespace+"zakat_deducted_total",
+			"Amount deducted as zakat and sadaqah."),
+	}
+}
+
+// ObserveBlock records a mined block: its source, the interval since the
+// previous block and the hash rate achieved mining it
+func (m *Metrics) ObserveBlock(source string, block, previous *blockchain.Block, pow *blockchain.ProofOfWork) {
+	m.BlocksMined.Inc(source)
+	if previous != nil && block.Timestamp >= previous.Timestamp {
+		m.BlockInterval.Observe(float64(block.Timestamp - previous.Timestamp))
+	}
+	if pow != nil && pow.Duration > 0 {
+		m.HashRate.Set(float64(pow.Hashes) / pow.Duration.Seconds())
+	}
+}
+
+// ObserveTransfer records an accepted transfer
+func (m *Metrics) ObserveTransfer(txType string, amount float64) {
+	m.Transfers.Inc(txType)
+	m.TransferVolume.Add(amount, txType)
+}
+
+// WatchDatabase reports connection pool statistics
+func (m *Metrics) WatchDatabase(stats func() sql.DBStats) {
+	gauge := func(name, help string, value func(s sql.DBStats) float64) {
+		m.NewGaugeFunc(namespace+name, help, func(context.Context) float64 { return value(stats()) })
+	}
+	counter := func(name, help string, value func(s sql.DBStats) float64) {
+		m.NewCounterFunc(namespace+name, help, func(context.Context) float64 { return value(stats()) })
+	}
+
+	gauge("db_max_open_connections", "Maximum open database connections.",
+		func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) })
+	gauge("db_open_connections", "Open database connections.",
+		func(s sql.DBStats) float64 { return float64(s.OpenConnections) })
+	gauge("db_in_use_connections", "Database connections in use.",
+		func(s sql.DBStats) float64 { return float64(s.InUse) })
+	gauge("db_idle_connections", "Idle database connections.",
+		func(s sql.DBStats) float64 { return float64(s.Idle) })
+	counter("db_wait_count_total", "Waits for a free database connection.",
+		func(s sql.DBStats) float64 { return float64(s.WaitCount) })
+	counter("db_wait_duration_seconds_total", "Time spent waiting for a free database connection.",
+		func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() })
+	counter("db_closed_max_idle_total", "Connections closed for exceeding the idle limit.",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) })
+	counter("db_closed_max_lifetime_total", "Connections closed for exceeding their lifetime.",
+		func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) })
+}
+
+// WatchChain reports the height and difficulty of the in-memory chain
+func (m *Metrics) WatchChain(bc *blockchain.Blockchain) {
+	m.NewGaugeFunc(namespace+"chain_height", "Index of the latest block.",
+		func(context.Context) float64 { return float64(bc.Height()) })
+	m.NewGaugeFunc(namespace+"chain_difficulty", "Difficulty of the latest block.",
+		func(context.Context) float64 {
+			latest := bc.GetLatestBlock()
+			if latest == nil {
+				return math.NaN()
+			}
+			return float64(latest.Difficulty)
+		})
+}
+
+// WatchMempool reports the number of transactions awaiting a block, as
+// counted by pending. A failed count is reported as NaN.
+func (m *Metrics) WatchMempool(pending func(ctx context.Context) (int64, error)) {
+	m.NewGaugeFunc(namespace+"mempool_size", "Transactions awaiting a block.",
+		func(ctx context.Context) float64 {
+			ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+			defer cancel()
+			n, err := pending(ctx)
+			if err != nil {
+				return math.NaN()
+			}
+			return float64(n)
+		})
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"crypto-wallet-backend/internal/database"
+)
+
+// AdminService manages user roles and wallet holds
+type AdminService struct {
+	db    *database.Database
+	audit *AuditService
+}
+
+// NewAdminService creates a new admin service
+func NewAdminService(db *database.Database) *AdminService {
+	return &AdminService{db: db, audit: NewAuditService(db)}
+}
+
+// BootstrapAdmin promotes the user registered with email to admin while no
+// admin exists, so a fresh deployment has someone to promote the rest. It
+// reports whether a promotion happened.
+func (as *AdminService) BootstrapAdmin(ctx context.Context, email string) (bool, error) {
+	if email == "" {
+		return false, nil
+	}
+
+	admins, err := as.db.CountUsersByRole(ctx, database.RoleAdmin)
+	if err != nil || admins > 0 {
+		return false, err
+	}
+
+	user, err := as.db.GetUserByEmail(ctx, strings.TrimSpace(email))
+	if err != nil || user == nil {
+		return false, err
+	}
+
+	if _, err := as.db.UpdateUserRole(ctx, user.ID, database.RoleAdmin); err != nil {
+		return false, err
+	}
+
+	_ = as.audit.Record(ctx, AuditEvent{
+		Type:          database.LogRoleChanged,
+		Message:       fmt.Sprintf("User %s bootstrapped as the initial admin", user.ID),
+		WalletAddress: user.WalletID,
+		Before:        map[string]string{"role": user.Role},
+		After:         map[string]string{"role": database.RoleAdmin},
+	})
+	return true, nil
+}
+
+// SetRole changes a user's role on behalf of actorID. Admins cannot change
+// their own role, so the last admin cannot demote themselves by accident.
+func (as *AdminService) SetRole(ctx context.Context, actorID, userID, role string) (*database.User, error) {
+	if !database.IsValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	if actorID == userID {
+		return nil, ErrOwnRole
+	}
+
+	user, err := as.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if user.Role == role {
+		return user, nil
+	}
+
+	if _, err := as.db.UpdateUserRole(ctx, userID, role); err != nil {
+		return nil, err
+	}
+
+	// Tokens carry the role they were issued with, so end the user's
+	// sessions and have them sign in again under the new role
+	if _, err := as.db.RevokeUserSessions(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	_ = as.audit.Record(ctx, AuditEvent{
+		Type:          database.LogRoleChanged,
+		Message:       fmt.Sprintf("User %s changed from %s to %s by %s", userID, user.Role, role, actorID),
+		ActorID:       actorID,
+		WalletAddress: user.WalletID,
+		Before:        map[string]string{"role": user.Role},
+		After:         map[string]string{"role": role},
+	})
+
+	user.Role = role
+	return user, nil
+}
+
+// FreezeWallet stops a wallet from sending funds until it is unfrozen
+func (as *AdminService) FreezeWallet(ctx context.Context, actorID, walletAddress, reason string) (*database.Wallet, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, ErrReasonRequired
+	}
+	return as.setWalletStatus(ctx, actorID, walletAddress, database.WalletFrozen, reason, database.LogWalletFrozen)
+}
+
+// UnfreezeWallet lets a frozen wallet send funds again
+func (as *AdminService) UnfreezeWallet(ctx context.Context, actorID, walletAddress, reason string) (*database.Wallet, error) {
+	return as.setWalletStatus(ctx, actorID, walletAddress, database.WalletActive, reason, database.LogWalletUnfrozen)
+}
+
+// CloseWallet permanently stops a wallet from sending or receiving funds
+func (as *AdminService) CloseWallet(ctx context.Context, actorID, walletAddress, reason string) (*database.Wallet, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, ErrReasonRequired
+	}
+	return as.setWalletStatus(ctx, actorID, walletAddress, database.WalletClosed, reason, database.LogWalletClosed)
+}
+
+// setWalletStatus moves a wallet to status. Closed wallets stay closed.
+func (as *AdminService) setWalletStatus(ctx context.Context, actorID, walletAddress, status, reason, logType string) (*database.Wallet, error) {
+	wallet, err := as.db.GetWalletByAddress(ctx, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil {
+		return nil, ErrWalletNotFound
+	}
+	if wallet.Status == database.WalletClosed {
+		return nil, ErrWalletClosed
+	}
+
+	if _, err := as.db.UpdateWalletStatus(ctx, walletAddress, status, reason); err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("Wallet %s set to %s by %s", walletAddress, status, actorID)
+	if reason != "" {
+		message += ": " + reason
+	}
+	_ = as.audit.Record(ctx, AuditEvent{
+		Type:          logType,
+		Message:       message,
+		ActorID:       actorID,
+		WalletAddress: walletAddress,
+		Before:        map[string]string{"status": wallet.Status},
+		After:         map[string]string{"status": status, "reason": reason},
+	})
+
+	return as.db.GetWalletByAddress(ctx, walletAddress)
+}
+
+// SetTier moves a user to another limit tier
+func (as *AdminService) SetTier(ctx context.Context, actorID, userID, tier string) (*database.User, error) {
+	limits, err := as.db.GetTransferLimit(ctx, tier)
+	if err != nil {
+		return nil, err
+	}
+	if limits == nil {
+		return nil, ErrUnknownTier
+	}
+
+	user, err := as.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if user.Tier == tier {
+		return user, nil
+	}
+
+	if _, err := as.db.UpdateUserTier(ctx, userID, tier); err != nil {
+		return nil, err
+	}
+
+	_ = as.audit.Record(ctx, AuditEvent{
+		Type:          database.LogTierChanged,
+		Message:       fmt.Sprintf("User %s moved from tier %s to %s by %s", userID, user.Tier, tier, actorID),
+		ActorID:       actorID,
+		WalletAddress: user.WalletID,
+		Before:        map[string]string{"tier": user.Tier},
+		After:         map[string]string{"tier": tier},
+	})
+
+	user.Tier = tier
+	return user, nil
+}
+
+// SaveLimits creates or replaces a tier's transfer limits
+func (as *AdminService) SaveLimits(ctx context.Context, actorID string, limits *database.TransferLimit) error {
+	if limits.Tier == "" {
+		return ErrUnknownTier
+	}
+	if limits.PerTransaction < 0 || limits.Daily < 0 || limits.Monthly < 0 {
+		return ErrInvalidLimit
+	}
+
+	previous, err := as.db.GetTransferLimit(ctx, limits.Tier)
+	if err != nil {
+		return err
+	}
+	if err := as.db.SaveTransferLimit(ctx, limits); err != nil {
+		return err
+	}
+
+	event := AuditEvent{
+		Type: database.LogLimitsChanged,
+		Message: fmt.Sprintf("Tier %s limits set to %.8f per transaction, %.8f daily, %.8f monthly by %s",
+			limits.Tier, limits.PerTransaction, limits.Daily, limits.Monthly, actorID),
+		ActorID: actorID,
+		After:   limits,
+	}
+	if previous != nil {
+		event.Before = previous
+	}
+	_ = as.audit.Record(ctx, event)
+	return nil
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"crypto-wallet-backend/internal/blockchain"
+	"crypto-wallet-backend/internal/database"
+	"crypto-wallet-backend/internal/utils"
+)
+
+// auditVerifyBatch is how many entries Verify reads at a time
+const auditVerifyBatch = 1000
+
+// AuditEvent is a state change to record. Before and After are the changed
+// values, marshalled to JSON; either may be nil.
+type AuditEvent struct {
+	Type          string
+	Message       string
+	ActorID       string
+	WalletAddress string
+	Before        interface{}
+	After         interface{}
+}
+
+// AuditService records audit events in system_logs, stamped with the actor,
+// address, user agent and ID of the request that caused them
+type AuditService struct {
+	db *database.Database
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(db *database.Database) *AuditService {
+	return &AuditService{db: db}
+}
+
+// Record writes event. Request details come from ctx; an ActorID set on the
+// event wins over the authenticated user, for changes made on someone's
+// behalf before they have a session.
+func (as *AuditService) Record(ctx context.Context, event AuditEvent) error {
+	info := utils.RequestInfoFrom(ctx)
+	entry := &database.SystemLog{
+		LogType:       event.Type,
+		Message:       event.Message,
+		ActorID:       event.ActorID,
+		WalletAddress: event.WalletAddress,
+		IPAddress:     info.IPAddress,
+		UserAgent:     info.UserAgent,
+		RequestID:     info.RequestID,
+	}
+	if entry.ActorID == "" {
+		entry.ActorID = info.ActorID
+	}
+
+	var err error
+	if entry.Before, err = marshalAuditValue(event.Before); err != nil {
+		return err
+	}
+	if entry.After, err = marshalAuditValue(event.After); err != nil {
+		return err
+	}
+
+	return as.db.CreateSystemLog(ctx, entry)
+}
+
+func marshalAuditValue(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// AuditBreak is the first point at which the audit log stops verifying
+type AuditBreak struct {
+	Sequence int64  `json:"sequence"`
+	LogID    string `json:"log_id,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// AuditVerification is the outcome of walking the audit log. Entries written
+// before the log was chained cannot be verified and are only counted.
+type AuditVerification struct {
+	Valid          bool        `json:"valid"`
+	Checked        int64       `json:"checked"`
+	HeadSequence   int64       `json:"head_sequence"`
+	HeadHash       string      `json:"head_hash,omitempty"`
+	Unchained      int64       `json:"unchained"`
+	AnchorsChecked int         `json:"anchors_checked"`
+	LastAnchored   int64       `json:"last_anchored_sequence"`
+	FirstBreak     *AuditBreak `json:"first_break,omitempty"`
+	VerifiedAt     time.Time   `json:"verified_at"`
+}
+
+// Verify walks the audit log from the first chained entry, checking that
+// sequence numbers are contiguous, that each entry links to the hash of the
+// one before and that its hash matches its content. Anchored entries must
+// match the hash sealed in their block, and the block must still be valid.
+// It stops at the first broken link.
+//
+// Deleting entries after the last anchor is indistinguishable from them
+// never having been written, so anchor the log often.
+func (as *AuditService) Verify(ctx context.Context) (*AuditVerification, error) {
+	result := &AuditVerification{}
+	var err error
+	if result.Unchained, err = as.db.CountUnchainedSystemLogs(ctx); err != nil {
+		return nil, err
+	}
+
+	anchorList, err := as.db.GetAuditAnchors(ctx)
+	if err != nil {
+		return nil, err
+	}
+	anchors := make(map[int64][]database.AuditAnchor)
+	for _, anchor := range anchorList {
+		anchors[anchor.Sequence] = append(anchors[anchor.Sequence], anchor)
+	}
+
+	prevHash := database.GenesisAuditHash
+	var sequence int64
+	for result.FirstBreak == nil {
+		batch, err := as.db.GetChainedSystemLogs(ctx, sequence, auditVerifyBatch)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for i := range batch {
+			entry := &batch[i]
+			if reason, err := as.checkEntry(ctx, entry, sequence+1, prevHash, anchors[entry.Sequence]); err != nil {
+				return nil, err
+			} else if reason != "" {
+				result.FirstBreak = &AuditBreak{Sequence: entry.Sequence, LogID: entry.ID, Reason: reason}
+				break
+			}
+			result.Checked++
+			result.AnchorsChecked += len(anchors[entry.Sequence])
+			if len(anchors[entry.Sequence]) > 0 {
+				result.LastAnchored = entry.Sequence
+			}
+			sequence, prevHash = entry.Sequence, entry.EntryHash
+		}
+	}
+	result.HeadSequence = sequence
+	if sequence > 0 {
+		result.HeadHash = prevHash
+	}
+
+	// An anchor past the end of the log means anchored entries were removed
+	if result.FirstBreak == nil {
+		for _, anchor := range anchorList {
+			if anchor.Sequence > sequence {
+				result.FirstBreak = &AuditBreak{
+					Sequence: sequence + 1,
+					Reason:   fmt.Sprintf("log ends at entry %d but entry %d is anchored in block %d", sequence, anchor.Sequence, anchor.BlockIndex),
+				}
+				break
+			}
+		}
+	}
+
+	result.Valid = result.FirstBreak == nil
+	result.VerifiedAt = time.Now()
+	return result, nil
+}
+
+// checkEntry returns why entry breaks the chain, or "" if it does not
+func (as *AuditService) checkEntry(ctx context.Context, entry *database.SystemLog, sequence int64, prevHash string, anchors []database.AuditAnchor) (string, error) {
+	if entry.Sequence != sequence {
+		return fmt.Sprintf("entries %d to %d are missing", sequence, entry.Sequence-1), nil
+	}
+	if entry.PrevHash != prevHash {
+		return "previous hash does not match the entry before", nil
+	}
+	hash, err := entry.CalculateHash()
+	if err != nil {
+		return fmt.Sprintf("entry cannot be hashed: %v", err), nil
+	}
+	if hash != entry.EntryHash {
+		return "entry hash does not match its content", nil
+	}
+
+	for _, anchor := range anchors {
+		if anchor.EntryHash != entry.EntryHash {
+			return fmt.Sprintf("entry hash does not match the hash anchored in block %d", anchor.BlockIndex), nil
+		}
+		reason, err := as.checkAnchorBlock(ctx, anchor)
+		if err != nil || reason != "" {
+			return reason, err
+		}
+	}
+	return "", nil
+}
+
+// checkAnchorBlock returns why the block an anchor points at no longer seals
+// it, or "" if it does
+func (as *AuditService) checkAnchorBlock(ctx context.Context, anchor database.AuditAnchor) (string, error) {
+	block, err := as.db.GetBlockByHash(ctx, anchor.BlockHash)
+	if err != nil {
+		return "", err
+	}
+	if block == nil {
+		return fmt.Sprintf("anchor block %s is missing", anchor.BlockHash), nil
+	}
+
+	if block.MerkleRoot != blockchain.CalculateMerkleRoot([]string{AuditAnchorTxID(anchor.Sequence, anchor.EntryHash)}) {
+		return fmt.Sprintf("merkle root of anchor block %d does not commit to the entry", block.BlockIndex), nil
+	}
+	header := &blockchain.Block{
+		Index:        block.BlockIndex,
+		Timestamp:    block.Timestamp,
+		PreviousHash: block.PreviousHash,
+		Nonce:        block.Nonce,
+		MerkleRoot:   block.MerkleRoot,
+		Difficulty:   block.Difficulty,
+	}
+	hash := header.CalculateHash()
+	if hash != block.Hash || !strings.HasPrefix(hash, strings.Repeat("0", block.Difficulty)) {
+		return fmt.Sprintf("anchor block %d fails proof of work", block.BlockIndex), nil
+	}
+	return "", nil
+}
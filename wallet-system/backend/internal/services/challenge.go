@@ -0,0 +1,76 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"crypto-wallet-backend/internal/crypto"
+)
+
+// ChallengeTTL is how long a proof-of-possession challenge stays valid
+const ChallengeTTL = 5 * time.Minute
+
+// challenge is an outstanding proof-of-possession challenge for a public key
+type challenge struct {
+	publicKey string
+	expiresAt time.Time
+}
+
+// ChallengeService issues single-use challenges that a client signs with its
+// own private key to prove it holds the key behind a public key
+type ChallengeService struct {
+	mu         sync.Mutex
+	challenges map[string]*challenge
+}
+
+// NewChallengeService creates a new challenge service
+func NewChallengeService() *ChallengeService {
+	return &ChallengeService{challenges: make(map[string]*challenge)}
+}
+
+// Issue creates a challenge bound to publicKey
+func (cs *ChallengeService) Issue(publicKey string) (string, time.Time, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(ChallengeTTL)
+	text := fmt.Sprintf("crypto-wallet-auth:%s:%s:%d",
+		crypto.GenerateWalletID(publicKey), hex.EncodeToString(nonce), expiresAt.Unix())
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	now := time.Now()
+	for id, c := range cs.challenges {
+		if now.After(c.expiresAt) {
+			delete(cs.challenges, id)
+		}
+	}
+	cs.challenges[text] = &challenge{publicKey: publicKey, expiresAt: expiresAt}
+
+	return text, expiresAt, nil
+}
+
+// Verify consumes the challenge and checks that signature is a valid
+// signature over it by the private key matching publicKey
+func (cs *ChallengeService) Verify(publicKey, text, signature string) error {
+	cs.mu.Lock()
+	c, ok := cs.challenges[text]
+	delete(cs.challenges, text)
+	cs.mu.Unlock()
+
+	if !ok || c.publicKey != publicKey || time.Now().After(c.expiresAt) {
+		return ErrInvalidChallenge
+	}
+
+	valid, err := crypto.VerifySignature(text, signature, publicKey)
+	if err != nil || !valid {
+		return ErrInvalidChallenge
+	}
+
+	return nil
+}
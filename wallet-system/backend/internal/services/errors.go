@@ -0,0 +1,59 @@
+package services
+
+import "errors"
+
+var (
+	ErrInvalidPassword     = errors.New("invalid password")
+	ErrInvalidUnlockToken  = errors.New("invalid or expired unlock token")
+	ErrSignatureExpired    = errors.New("signature timestamp outside the accepted window")
+	ErrWalletOwnerMissing  = errors.New("wallet owner not found")
+	ErrInvalidChallenge    = errors.New("invalid or expired challenge")
+	ErrNonCustodialWallet  = errors.New("wallet is non-custodial; transfers must be signed by the client")
+	ErrSignerNotFound      = errors.New("co-signer wallet not found")
+	ErrMultisigExists      = errors.New("multisig wallet already exists")
+	ErrNotMultisigSigner   = errors.New("user is not a co-signer of this wallet")
+	ErrProposalNotFound    = errors.New("proposal not found")
+	ErrProposalNotPending  = errors.New("proposal is no longer pending")
+	ErrMissingCredentials  = errors.New("a signature, password or unlock token is required")
+	ErrZakatNotFound       = errors.New("zakat deduction not found")
+	ErrRecipientNotFound   = errors.New("zakat recipient not found")
+	ErrRecipientInactive   = errors.New("zakat recipient is inactive")
+	ErrInvalidSadaqah      = errors.New("sadaqah percentage must be between 0 and 100")
+	ErrInvalidDeclaration  = errors.New("declaration kind must be asset or liability with a positive amount")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrInvalidRole         = errors.New("role must be user, auditor or admin")
+	ErrOwnRole             = errors.New("admins cannot change their own role")
+	ErrReasonRequired      = errors.New("a reason is required")
+	ErrWalletNotFound      = errors.New("wallet not found")
+	ErrWalletFrozen        = errors.New("wallet is frozen")
+	ErrWalletClosed        = errors.New("wallet is closed")
+	ErrReceiverClosed      = errors.New("receiving wallet is closed")
+	ErrPerTransactionLimit = errors.New("amount exceeds the per-transaction limit")
+	ErrDailyLimit          = errors.New("daily transfer limit exceeded")
+	ErrMonthlyLimit        = errors.New("monthly transfer limit exceeded")
+	ErrUnknownTier         = errors.New("unknown limit tier")
+	ErrInvalidLimit        = errors.New("limits must not be negative")
+	ErrAlreadyVerified     = errors.New("user is already verified")
+	ErrKYCPending          = errors.New("a verification request is already awaiting review")
+	ErrInvalidKYC          = errors.New("invalid verification details")
+	ErrCNICMismatch        = errors.New("CNIC does not match the one registered")
+	ErrInvalidDocument     = errors.New("documents must be JPEG, PNG or PDF files")
+	ErrDocumentTooLarge    = errors.New("document exceeds the upload size limit")
+	ErrKYCNotFound         = errors.New("verification request not found")
+	ErrKYCNotPending       = errors.New("verification request has already been reviewed")
+	ErrOwnSubmission       = errors.New("reviewers cannot review their own verification request")
+	ErrInvalidOTP          = errors.New("invalid verification code")
+	ErrOTPExpired          = errors.New("verification code has expired or was not requested")
+	ErrOTPAttempts         = errors.New("too many wrong codes; request a new one")
+	ErrOTPTooSoon          = errors.New("a code was sent recently; wait before requesting another")
+	ErrTOTPEnabled         = errors.New("authenticator app is already enabled")
+	ErrTOTPNotEnrolled     = errors.New("authenticator app enrolment has not been started")
+	ErrUseAuthenticator    = errors.New("use the code from your authenticator app")
+	ErrSecondFactor        = errors.New("a verification code is required")
+	ErrInvalidToken        = errors.New("invalid or expired token")
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used; the session has been revoked")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrMiningStopped       = errors.New("mining stopped: the server is shutting down")
+	ErrInsufficientFunds   = errors.New("insufficient funds")
+)
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"crypto-wallet-backend/internal/database"
+	"crypto-wallet-backend/internal/utils"
+)
+
+// KYCMaxDocumentBytes caps the size of one uploaded document
+const KYCMaxDocumentBytes = 5 << 20
+
+// kycMinimumAge is the age at which NADRA issues a CNIC
+const kycMinimumAge = 18
+
+// kycDocumentTypes maps the accepted document content types, detected from
+// the file itself rather than trusted from the client, to file extensions
+var kycDocumentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+// kycDocumentKinds are the document kinds a submission may carry and
+// whether each is required
+var kycDocumentKinds = map[string]bool{
+	database.KYCDocCNICFront: true,
+	database.KYCDocCNICBack:  true,
+	database.KYCDocSelfie:    false,
+}
+
+// KYCService handles identity verification requests and their review.
+// Documents are stored on local disk under uploadDir, one directory per user.
+type KYCService struct {
+	db        *database.Database
+	audit     *AuditService
+	uploadDir string
+}
+
+// NewKYCService creates a new KYC service
+func NewKYCService(db *database.Database, uploadDir string) *KYCService {
+	return &KYCService{db: db, audit: NewAuditService(db), uploadDir: uploadDir}
+}
+
+// KYCRequest is a user's identity details and documents
+type KYCRequest struct {
+	CNIC        string
+	FullName    string
+	FatherName  string
+	DateOfBirth string
+	Gender      string
+	Documents   []KYCUpload
+}
+
+// KYCUpload is one document of a KYCRequest
+type KYCUpload struct {
+	Kind   string
+	Reader io.Reader
+}
+
+// KYCStatus is a user's verification state and latest request
+type KYCStatus struct {
+	IsVerified bool                    `json:"is_verified"`
+	Submission *database.KYCSubmission `json:"submission,omitempty"`
+}
+
+// Submit validates a user's details, stores their documents and queues the
+// request for review. The CNIC must match the one the user registered with.
+func (ks *KYCService) Submit(ctx context.Context, userID string, req KYCRequest) (*database.KYCSubmission, error) {
+	user, err := ks.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if user.IsVerified {
+		return nil, ErrAlreadyVerified
+	}
+
+	latest, err := ks.db.GetLatestKYCSubmission(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.Status == database.KYCPending {
+		return nil, ErrKYCPending
+	}
+
+	sub, err := validateKYC(user, req)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, upload := range req.Documents {
+		doc, err := ks.storeDocument(userID, upload)
+		if err != nil {
+			ks.removeDocuments(sub.Documents)
+			return nil, err
+		}
+		sub.Documents = append(sub.Documents, doc)
+	}
+
+	if err := ks.db.CreateKYCSubmission(ctx, sub); err != nil {
+		ks.removeDocuments(sub.Documents)
+		return nil, err
+	}
+
+	_ = ks.audit.Record(ctx, AuditEvent{
+		Type:          database.LogKYCSubmitted,
+		Message:       fmt.Sprintf("User %s submitted verification request %s", userID, sub.ID),
+		WalletAddress: user.WalletID,
+		After:         map[string]string{"submission_id": sub.ID, "status": sub.Status},
+	})
+	return sub, nil
+}
+
+// validateKYC checks a request's details and document kinds and returns the
+// submission to record
+func validateKYC(user *database.User, req KYCRequest) (*database.KYCSubmission, error) {
+	cnic, err := utils.ParseCNIC(req.CNIC)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKYC, err)
+	}
+	if registered, err := utils.ParseCNIC(user.CNIC); err == nil && registered.Number != cnic.Number {
+		return nil, ErrCNICMismatch
+	}
+
+	gender := strings.ToLower(strings.TrimSpace(req.Gender))
+	if gender != utils.GenderMale && gender != utils.GenderFemale {
+		return nil, fmt.Errorf("%w: gender must be male or female", ErrInvalidKYC)
+	}
+	if err := utils.ValidateCNICGender(cnic, gender); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKYC, err)
+	}
+
+	fullName := utils.SanitizeInput(req.FullName)
+	if fullName == "" {
+		return nil, fmt.Errorf("%w: full name is required", ErrInvalidKYC)
+	}
+
+	dob, err := time.Parse("2006-01-02", req.DateOfBirth)
+	if err != nil {
+		return nil, fmt.Errorf("%w: date of birth must be YYYY-MM-DD", ErrInvalidKYC)
+	}
+	if dob.AddDate(kycMinimumAge, 0, 0).After(time.Now()) {
+		return nil, fmt.Errorf("%w: applicants must be at least %d", ErrInvalidKYC, kycMinimumAge)
+	}
+
+	seen := make(map[string]bool)
+	for _, upload := range req.Documents {
+		if _, ok := kycDocumentKinds[upload.Kind]; !ok {
+			return nil, fmt.Errorf("%w: unknown document kind %q", ErrInvalidKYC, upload.Kind)
+		}
+		if seen[upload.Kind] {
+			return nil, fmt.Errorf("%w: more than one %s document", ErrInvalidKYC, upload.Kind)
+		}
+		seen[upload.Kind] = true
+	}
+	for kind, required := range kycDocumentKinds {
+		if required && !seen[kind] {
+			return nil, fmt.Errorf("%w: %s document is required", ErrInvalidKYC, kind)
+		}
+	}
+
+	return &database.KYCSubmission{
+		UserID:      user.ID,
+		CNIC:        cnic.Number,
+		FullName:    fullName,
+		FatherName:  utils.SanitizeInput(req.FatherName),
+		DateOfBirth: dob.Format("2006-01-02"),
+		Gender:      gender,
+		Region:      cnic.Region,
+	}, nil
+}
+
+// storeDocument writes an upload to the user's directory under a random name,
+// checking its type from its first bytes and hashing it as it is copied
+func (ks *KYCService) storeDocument(userID string, upload KYCUpload) (*database.KYCDocument, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(upload.Reader, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		if err == io.EOF {
+			return nil, ErrInvalidDocument
+		}
+		return nil, err
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	ext, ok := kycDocumentTypes[contentType]
+	if !ok {
+		return nil, ErrInvalidDocument
+	}
+
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return nil, err
+	}
+	relPath := filepath.Join(filepath.Base(userID), hex.EncodeToString(name)+ext)
+	fullPath := filepath.Join(ks.uploadDir, relPath)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o700); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.New()
+	body := io.MultiReader(bytes.NewReader(head), io.LimitReader(upload.Reader, KYCMaxDocumentBytes+1-int64(n)))
+	size, err := io.Copy(io.MultiWriter(file, hash), body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && size > KYCMaxDocumentBytes {
+		err = ErrDocumentTooLarge
+	}
+	if err != nil {
+		os.Remove(fullPath)
+		return nil, err
+	}
+
+	return &database.KYCDocument{
+		Kind:        upload.Kind,
+		StoragePath: relPath,
+		ContentType: contentType,
+		SizeBytes:   size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// removeDocuments deletes stored files of a submission that was not recorded
+func (ks *KYCService) removeDocuments(docs []*database.KYCDocument) {
+	for _, doc := range docs {
+		os.Remove(filepath.Join(ks.uploadDir, doc.StoragePath))
+	}
+}
+
+// GetStatus returns a user's verification state and latest request
+func (ks *KYCService) GetStatus(ctx context.Context, userID string) (*KYCStatus, error) {
+	user, err := ks.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	sub, err := ks.db.GetLatestKYCSubmission(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &KYCStatus{IsVerified: user.IsVerified, Submission: sub}, nil
+}
+
+// GetSubmissions lists requests oldest first, optionally only with status
+func (ks *KYCService) GetSubmissions(ctx context.Context, status string, limit, offset int) ([]*database.KYCSubmission, error) {
+	return ks.db.GetKYCSubmissions(ctx, status, limit, offset)
+}
+
+// GetSubmission returns a request with its documents
+func (ks *KYCService) GetSubmission(ctx context.Context, id string) (*database.KYCSubmission, error) {
+	sub, err := ks.db.GetKYCSubmission(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, ErrKYCNotFound
+	}
+	return sub, nil
+}
+
+// GetDocument returns a request's document and the path of its stored file
+func (ks *KYCService) GetDocument(ctx context.Context, submissionID, documentID string) (*database.KYCDocument, string, error) {
+	sub, err := ks.GetSubmission(ctx, submissionID)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, doc := range sub.Documents {
+		if doc.ID == documentID {
+			return doc, filepath.Join(ks.uploadDir, doc.StoragePath), nil
+		}
+	}
+	return nil, "", ErrKYCNotFound
+}
+
+// Approve marks a pending request approved and the user verified, which
+// lifts them from the unverified transfer limits to their tier's
+func (ks *KYCService) Approve(ctx context.Context, reviewerID, id, notes string) (*database.KYCSubmission, error) {
+	sub, err := ks.review(ctx, reviewerID, id, database.KYCApproved, notes)
+	if err != nil {
+		return nil, err
+	}
+	if err := ks.db.UpdateUserVerification(ctx, sub.UserID, true); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Reject marks a pending request rejected. Notes are required so the user
+// knows what to fix before submitting again.
+func (ks *KYCService) Reject(ctx context.Context, reviewerID, id, notes string) (*database.KYCSubmission, error) {
+	if strings.TrimSpace(notes) == "" {
+		return nil, ErrReasonRequired
+	}
+	return ks.review(ctx, reviewerID, id, database.KYCRejected, notes)
+}
+
+// review records a decision on a pending request
+func (ks *KYCService) review(ctx context.Context, reviewerID, id, status, notes string) (*database.KYCSubmission, error) {
+	sub, err := ks.GetSubmission(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub.UserID == reviewerID {
+		return nil, ErrOwnSubmission
+	}
+
+	notes = strings.TrimSpace(notes)
+	reviewed, err := ks.db.ReviewKYCSubmission(ctx, id, status, notes, reviewerID)
+	if err != nil {
+		return nil, err
+	}
+	if !reviewed {
+		return nil, ErrKYCNotPending
+	}
+
+	now := time.Now()
+	sub.Status = status
+	sub.ReviewNotes = notes
+	sub.ReviewedBy = &reviewerID
+	sub.ReviewedAt = &now
+
+	logType := database.LogKYCRejected
+	if status == database.KYCApproved {
+		logType = database.LogKYCApproved
+	}
+	_ = ks.audit.Record(ctx, AuditEvent{
+		Type:    logType,
+		Message: fmt.Sprintf("Verification request %s of user %s %s by %s", id, sub.UserID, status, reviewerID),
+		ActorID: reviewerID,
+		Before:  map[string]string{"submission_id": id, "status": database.KYCPending},
+		After:   map[string]string{"submission_id": id, "status": status, "notes": notes},
+	})
+	return sub, nil
+}
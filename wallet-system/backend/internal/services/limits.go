@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"crypto-wallet-backend/internal/blockchain"
+	"crypto-wallet-backend/internal/database"
+)
+
+// TransferUsage reports a user's tier limits and how much of them is used.
+// Days and months run in UTC.
+type TransferUsage struct {
+	Tier          string                  `json:"tier"`
+	IsVerified    bool                    `json:"is_verified"`
+	Limits        *database.TransferLimit `json:"limits,omitempty"`
+	SentToday     float64                 `json:"sent_today"`
+	SentThisMonth float64                 `json:"sent_this_month"`
+}
+
+// isSystemTransfer reports whether a transaction type is moved by the system
+// rather than the wallet owner. System transfers are exempt from limits.
+func isSystemTransfer(txType string) bool {
+	return txType == "zakat" || txType == "zakat_disbursement"
+}
+
+// limitTier returns the tier whose limits apply to a user. Users who have not
+// passed KYC are held to the unverified tier whatever tier they are assigned.
+func limitTier(user *database.User) string {
+	if !user.IsVerified {
+		return database.TierUnverified
+	}
+	return user.Tier
+}
+
+// limitPeriods returns the start of the current UTC day and month
+func limitPeriods(now time.Time) (day, month time.Time) {
+	now = now.UTC()
+	day = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	month = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return day, month
+}
+
+// GetTransferUsage returns a user's limits and outgoing totals
+func (ts *TransactionService) GetTransferUsage(ctx context.Context, userID string) (*TransferUsage, error) {
+	user, err := ts.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	tier := limitTier(user)
+	limits, err := ts.db.GetTransferLimit(ctx, tier)
+	if err != nil {
+		return nil, err
+	}
+
+	day, month := limitPeriods(time.Now())
+	usage := &TransferUsage{Tier: tier, IsVerified: user.IsVerified, Limits: limits}
+	if usage.SentToday, err = ts.db.GetOutgoingTotal(ctx, userID, day); err != nil {
+		return nil, err
+	}
+	if usage.SentThisMonth, err = ts.db.GetOutgoingTotal(ctx, userID, month); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// checkTransfer enforces wallet statuses and the sending user's tier limits
+// within db's transaction. It locks the sender's wallet and owner rows, so
+// transfers counted against the same limits run one at a time. Refusals are
+// returned as refusedError.
+func (ts *TransactionService) checkTransfer(ctx context.Context, db *database.Database, tx database.Transaction) error {
+	sender, err := db.LockWallet(ctx, tx.SenderWallet)
+	if err != nil {
+		return fmt.Errorf("failed to fetch sender wallet: %w", err)
+	}
+	if sender != nil {
+		switch sender.Status {
+		case database.WalletFrozen:
+			return refusedError{fmt.Errorf("%w: %s", ErrWalletFrozen, sender.StatusReason)}
+		case database.WalletClosed:
+			return refusedError{ErrWalletClosed}
+		}
+	}
+
+	receiver, err := db.GetWalletByAddress(ctx, tx.ReceiverWallet)
+	if err != nil {
+		return fmt.Errorf("failed to fetch receiver wallet: %w", err)
+	}
+	if receiver != nil && receiver.Status == database.WalletClosed {
+		return refusedError{ErrReceiverClosed}
+	}
+
+	if sender == nil || isSystemTransfer(tx.TransactionType) {
+		return nil
+	}
+
+	if err := db.LockUser(ctx, sender.UserID); err != nil {
+		return fmt.Errorf("failed to lock wallet owner: %w", err)
+	}
+	user, err := db.GetUserByID(ctx, sender.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch wallet owner: %w", err)
+	}
+	if user == nil {
+		return nil
+	}
+	tier := limitTier(user)
+	limits, err := db.GetTransferLimit(ctx, tier)
+	if err != nil {
+		return fmt.Errorf("failed to fetch transfer limits: %w", err)
+	}
+	if limits == nil {
+		return nil
+	}
+
+	if limits.PerTransaction > 0 && tx.Amount > limits.PerTransaction {
+		return refusedError{fmt.Errorf("%w: %.8f exceeds the %s tier cap of %.8f",
+			ErrPerTransactionLimit, tx.Amount, tier, limits.PerTransaction)}
+	}
+
+	day, month := limitPeriods(time.Now())
+	periods := []struct {
+		limit float64
+		since time.Time
+		err   error
+		name  string
+	}{
+		{limits.Daily, day, ErrDailyLimit, "daily"},
+		{limits.Monthly, month, ErrMonthlyLimit, "monthly"},
+	}
+	for _, p := range periods {
+		if p.limit <= 0 {
+			continue
+		}
+		sent, err := db.GetOutgoingTotal(ctx, user.ID, p.since)
+		if err != nil {
+			return fmt.Errorf("failed to total outgoing transfers: %w", err)
+		}
+		if sent+tx.Amount > p.limit {
+			return refusedError{fmt.Errorf("%w: %.8f already sent, %.8f more would exceed the %s tier %s limit of %.8f",
+				p.err, sent, tx.Amount, tier, p.name, p.limit)}
+		}
+	}
+
+	return nil
+}
+
+// refusedError carries the reason checkTransfer refused a transfer. It is
+// recorded once the transfer's database transaction has rolled back.
+type refusedError struct {
+	error
+}
+
+func (e refusedError) Unwrap() error {
+	return e.error
+}
+
+// isTransferRefusal reports whether CreateTransaction refused a transfer
+// before recording anything, so it can be retried once the cause is fixed
+func isTransferRefusal(err error) bool {
+	var refused refusedError
+	return errors.As(err, &refused) ||
+		errors.Is(err, ErrInsufficientFunds) ||
+		errors.Is(err, blockchain.ErrTransactionNotFinal)
+}
+
+// recordRefusal records why a transfer was blocked
+func (ts *TransactionService) recordRefusal(ctx context.Context, tx database.Transaction, err error) {
+	_ = ts.audit.Record(ctx, AuditEvent{
+		Type:          database.LogTransferBlocked,
+		Message:       fmt.Sprintf("Transfer of %.8f from %s to %s refused: %v", tx.Amount, tx.SenderWallet, tx.ReceiverWallet, err),
+		WalletAddress: tx.SenderWallet,
+	})
+}
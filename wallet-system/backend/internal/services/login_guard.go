@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"crypto-wallet-backend/internal/database"
+)
+
+// LoginFailureWindow is how long a failed login counts towards a lockout
+const LoginFailureWindow = 24 * time.Hour
+
+// LoginLockoutPolicy sets when failed logins lock an email address. After
+// MaxFailures failures within LoginFailureWindow the address is locked for
+// BaseLockout, doubling with each further failure up to MaxLockout.
+type LoginLockoutPolicy struct {
+	MaxFailures int
+	BaseLockout time.Duration
+	MaxLockout  time.Duration
+}
+
+// LoginGuard counts failed logins per email address and locks addresses
+// that fail too often, recording each login as an AUTH system log
+type LoginGuard struct {
+	db     *database.Database
+	audit  *AuditService
+	policy LoginLockoutPolicy
+}
+
+// NewLoginGuard creates a login guard. A policy with MaxFailures of 0 or
+// less never locks.
+func NewLoginGuard(db *database.Database, policy LoginLockoutPolicy) *LoginGuard {
+	return &LoginGuard{db: db, audit: NewAuditService(db), policy: policy}
+}
+
+// LockedFor returns how much longer logins for email are refused, or 0
+func (lg *LoginGuard) LockedFor(ctx context.Context, email string) (time.Duration, error) {
+	failure, err := lg.db.GetLoginFailure(ctx, normalizeLoginEmail(email))
+	if err != nil {
+		return 0, err
+	}
+	if failure == nil || failure.LockedUntil == nil {
+		return 0, nil
+	}
+	if remaining := time.Until(*failure.LockedUntil); remaining > 0 {
+		return remaining, nil
+	}
+	return 0, nil
+}
+
+// Fail records a failed login for email and returns how long the address is
+// now locked, or 0. user is the account the address belongs to, if any.
+func (lg *LoginGuard) Fail(ctx context.Context, email string, user *database.User, reason string) (time.Duration, error) {
+	email = normalizeLoginEmail(email)
+	failures, err := lg.db.RecordLoginFailure(ctx, email, time.Now().Add(-LoginFailureWindow))
+	if err != nil {
+		return 0, err
+	}
+
+	event := AuditEvent{
+		Type:    database.LogAuth,
+		Message: fmt.Sprintf("Failed login for %s: %s (%d recent failures)", email, reason, failures),
+		After:   map[string]interface{}{"outcome": "failed", "email": email, "reason": reason, "failures": failures},
+	}
+	if user != nil {
+		event.ActorID, event.WalletAddress = user.ID, user.WalletID
+	}
+	_ = lg.audit.Record(ctx, event)
+
+	lockout := lg.lockout(failures)
+	if lockout == 0 {
+		return 0, nil
+	}
+	if err := lg.db.LockLogin(ctx, email, time.Now().Add(lockout)); err != nil {
+		return 0, err
+	}
+	event.Message = fmt.Sprintf("Logins for %s locked for %s after %d failures", email, lockout, failures)
+	event.After = map[string]interface{}{"outcome": "locked", "email": email, "failures": failures, "lockout_seconds": int(lockout.Seconds())}
+	_ = lg.audit.Record(ctx, event)
+	return lockout, nil
+}
+
+// Succeed records a completed login and forgets the user's failed logins
+func (lg *LoginGuard) Succeed(ctx context.Context, user *database.User) error {
+	_ = lg.audit.Record(ctx, AuditEvent{
+		Type:          database.LogAuth,
+		Message:       fmt.Sprintf("User %s logged in", user.ID),
+		ActorID:       user.ID,
+		WalletAddress: user.WalletID,
+		After:         map[string]string{"outcome": "succeeded", "email": normalizeLoginEmail(user.Email)},
+	})
+	return lg.db.ClearLoginFailures(ctx, normalizeLoginEmail(user.Email))
+}
+
+// lockout returns how long that many recent failures lock an address for
+func (lg *LoginGuard) lockout(failures int) time.Duration {
+	if lg.policy.MaxFailures <= 0 || failures < lg.policy.MaxFailures {
+		return 0
+	}
+	lockout := lg.policy.BaseLockout
+	for i := lg.policy.MaxFailures; i < failures && lockout < lg.policy.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > lg.policy.MaxLockout {
+		lockout = lg.policy.MaxLockout
+	}
+	return lockout
+}
+
+// normalizeLoginEmail keys failures case-insensitively, so varying the case
+// of an address does not reset its count
+func normalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
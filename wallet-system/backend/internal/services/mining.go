@@ -2,20 +2,54 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
 	"crypto-wallet-backend/internal/blockchain"
 	"crypto-wallet-backend/internal/database"
-	"fmt"
+	"crypto-wallet-backend/internal/metrics"
 )
 
 // MiningService handles mining operations
 type MiningService struct {
-	db *database.Database
-	bc *blockchain.Blockchain
+	db      *database.Database
+	bc      *blockchain.Blockchain
+	logger  *slog.Logger
+	metrics *metrics.Metrics
+
+	// stopped is cancelled by Stop to abandon proof of work in progress
+	stopped context.Context
+	stop    context.CancelFunc
 }
 
 // NewMiningService creates a new mining service
-func NewMiningService(db *database.Database, bc *blockchain.Blockchain) *MiningService {
-	return &MiningService{db: db, bc: bc}
+func NewMiningService(db *database.Database, bc *blockchain.Blockchain, logger *slog.Logger, m *metrics.Metrics) *MiningService {
+	stopped, stop := context.WithCancel(context.Background())
+	return &MiningService{db: db, bc: bc, logger: logger, metrics: m, stopped: stopped, stop: stop}
+}
+
+// Stop abandons proof of work in progress and refuses to start more. Blocks
+// are only stored once mined, so nothing is left half written.
+func (ms *MiningService) Stop() {
+	ms.stop()
+}
+
+// Mine runs proof of work on block until it is found, ctx is done or the
+// service is stopped, which returns ErrMiningStopped
+func (ms *MiningService) Mine(ctx context.Context, block *blockchain.Block) (*blockchain.ProofOfWork, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer context.AfterFunc(ms.stopped, cancel)()
+
+	pow := blockchain.NewProofOfWork(block)
+	if err := pow.MineContext(ctx); err != nil {
+		if ms.stopped.Err() != nil {
+			return nil, ErrMiningStopped
+		}
+		return nil, err
+	}
+	return pow, nil
 }
 
 // MineBlock mines a new block
@@ -30,7 +64,8 @@ func (ms *MiningService) MineBlock(ctx context.Context, minerAddress string) (*b
 	newDifficulty := blockchain.AdjustDifficulty(
 		lastBlock.Difficulty,
 		lastBlock,
-		ms.bc.GetLatestBlock().Timestamp,
+		time.Now().Unix(),
+		ms.bc.Params.TargetBlockTime,
 	)
 
 	// Create new block
@@ -43,8 +78,9 @@ func (ms *MiningService) MineBlock(ctx context.Context, minerAddress string) (*b
 	newBlock.MinedBy = minerAddress
 
 	// Mine the block
-	pow := blockchain.NewProofOfWork(newBlock)
-	pow.Mine()
+	if _, err := ms.Mine(ctx, newBlock); err != nil {
+		return nil, err
+	}
 
 	// Add to blockchain
 	if err := ms.bc.AddBlock(newBlock); err != nil {
@@ -70,6 +106,83 @@ func (ms *MiningService) MineBlock(ctx context.Context, minerAddress string) (*b
 	return newBlock, nil
 }
 
+// auditAnchorMiner is recorded as the miner of audit anchor blocks
+const auditAnchorMiner = "audit-anchor"
+
+// AuditAnchorTxID is the single transaction ID of the block anchoring the
+// audit log entry with that sequence number and hash, so the block's merkle
+// root commits to the entry
+func AuditAnchorTxID(sequence int64, entryHash string) string {
+	return fmt.Sprintf("audit-anchor:%d:%s", sequence, entryHash)
+}
+
+// AnchorAuditLog mines a block sealing the current head of the audit log, so
+// rewriting the log up to there would also mean rewriting the chain. It
+// returns nil if the head is already anchored or the log is empty.
+func (ms *MiningService) AnchorAuditLog(ctx context.Context) (*database.AuditAnchor, error) {
+	head, err := ms.db.GetAuditHead(ctx)
+	if err != nil || head == nil {
+		return nil, err
+	}
+	latest, err := ms.db.GetLatestAuditAnchor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.Sequence >= head.Sequence {
+		return nil, nil
+	}
+
+	lastBlock := ms.bc.GetLatestBlock()
+	if lastBlock == nil {
+		return nil, fmt.Errorf("no genesis block found")
+	}
+
+	ms.logger.DebugContext(ctx, "Mining audit anchor block", "sequence", head.Sequence, "index", lastBlock.Index+1)
+	txID := AuditAnchorTxID(head.Sequence, head.EntryHash)
+	newBlock := &blockchain.Block{
+		Index:        lastBlock.Index + 1,
+		Timestamp:    time.Now().Unix(),
+		Transactions: []blockchain.Transaction{{ID: txID, Timestamp: time.Now().Unix()}},
+		PreviousHash: lastBlock.Hash,
+		MerkleRoot:   blockchain.CalculateMerkleRoot([]string{txID}),
+		Difficulty:   lastBlock.Difficulty,
+		MinedBy:      auditAnchorMiner,
+	}
+	pow, err := ms.Mine(ctx, newBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	dbBlock := &database.Block{
+		BlockIndex:   newBlock.Index,
+		Timestamp:    newBlock.Timestamp,
+		PreviousHash: newBlock.PreviousHash,
+		Hash:         newBlock.Hash,
+		Nonce:        newBlock.Nonce,
+		MerkleRoot:   newBlock.MerkleRoot,
+		Difficulty:   newBlock.Difficulty,
+		MinedBy:      newBlock.MinedBy,
+	}
+	if err := ms.db.CreateBlock(ctx, dbBlock); err != nil {
+		return nil, err
+	}
+	if err := ms.bc.AddBlock(newBlock); err != nil {
+		return nil, err
+	}
+	ms.metrics.ObserveBlock("audit_anchor", newBlock, lastBlock, pow)
+
+	anchor := &database.AuditAnchor{
+		Sequence:   head.Sequence,
+		EntryHash:  head.EntryHash,
+		BlockHash:  newBlock.Hash,
+		BlockIndex: newBlock.Index,
+	}
+	if err := ms.db.CreateAuditAnchor(ctx, anchor); err != nil {
+		return nil, err
+	}
+	return anchor, nil
+}
+
 // ValidateBlock validates a block
 func (ms *MiningService) ValidateBlock(block *blockchain.Block) bool {
 	pow := blockchain.NewProofOfWork(block)
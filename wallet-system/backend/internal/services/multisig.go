@@ -0,0 +1,336 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"crypto-wallet-backend/internal/blockchain"
+	"crypto-wallet-backend/internal/crypto"
+	"crypto-wallet-backend/internal/database"
+)
+
+// staleBroadcastAfter is how long a proposal may stay broadcasting before it
+// is presumed abandoned by a server that stopped mid-broadcast
+const staleBroadcastAfter = 15 * time.Minute
+
+// SignerCredentials carries one way for a co-signer to approve a proposal:
+// their password or unlock token for a server-side signature, or a signature
+// they produced themselves
+type SignerCredentials struct {
+	Password    string
+	UnlockToken string
+	Signature   string
+}
+
+// MultisigService manages M-of-N wallets and their spend proposals
+type MultisigService struct {
+	db                 *database.Database
+	signingService     *SigningService
+	transactionService *TransactionService
+}
+
+// NewMultisigService creates a new multisig service
+func NewMultisigService(db *database.Database, signingService *SigningService, transactionService *TransactionService) *MultisigService {
+	return &MultisigService{
+		db:                 db,
+		signingService:     signingService,
+		transactionService: transactionService,
+	}
+}
+
+// CreateWallet creates an M-of-N wallet from the creator and the users owning
+// signerWalletIDs. The creator is always a co-signer.
+func (ms *MultisigService) CreateWallet(ctx context.Context, creatorID string, threshold int, signerWalletIDs []string) (*database.MultisigWallet, error) {
+	creator, err := ms.db.GetUserByID(ctx, creatorID)
+	if err != nil {
+		return nil, err
+	}
+	if creator == nil {
+		return nil, ErrSignerNotFound
+	}
+
+	signers := []*database.MultisigSigner{{UserID: creator.ID, WalletID: creator.WalletID, PublicKey: creator.PublicKey}}
+	for _, walletID := range signerWalletIDs {
+		if walletID == creator.WalletID {
+			continue
+		}
+		user, err := ms.db.GetUserByWalletID(ctx, walletID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, fmt.Errorf("%w: %s", ErrSignerNotFound, walletID)
+		}
+		signers = append(signers, &database.MultisigSigner{UserID: user.ID, WalletID: user.WalletID, PublicKey: user.PublicKey})
+	}
+
+	publicKeys := make([]string, len(signers))
+	for i, signer := range signers {
+		publicKeys[i] = signer.PublicKey
+	}
+
+	policy, err := blockchain.NewMultisigPolicy(threshold, publicKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	address := policy.Address()
+	existing, err := ms.db.GetWalletByAddress(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrMultisigExists
+	}
+
+	if err := ms.db.CreateWallet(ctx, &database.Wallet{
+		UserID:        creatorID,
+		WalletAddress: address,
+		WalletType:    database.WalletTypeMultisig,
+	}); err != nil {
+		return nil, err
+	}
+
+	mw := &database.MultisigWallet{
+		WalletAddress: address,
+		Threshold:     threshold,
+		CreatedBy:     creatorID,
+		Signers:       signers,
+	}
+	if err := ms.db.CreateMultisigWallet(ctx, mw); err != nil {
+		return nil, err
+	}
+
+	return mw, nil
+}
+
+// GetWallet returns a multisig wallet if the user is one of its co-signers
+func (ms *MultisigService) GetWallet(ctx context.Context, userID, walletAddress string) (*database.MultisigWallet, error) {
+	mw, err := ms.db.GetMultisigWallet(ctx, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	if mw == nil || signerFor(mw, userID) == nil {
+		return nil, ErrNotMultisigSigner
+	}
+	return mw, nil
+}
+
+// Propose creates a pending spend from a multisig wallet
+func (ms *MultisigService) Propose(ctx context.Context, userID, walletAddress, receiverWallet string, amount, fee float64, note string) (*database.MultisigProposal, error) {
+	if _, err := ms.GetWallet(ctx, userID, walletAddress); err != nil {
+		return nil, err
+	}
+
+	proposal := &database.MultisigProposal{
+		WalletAddress:  walletAddress,
+		ReceiverWallet: receiverWallet,
+		Amount:         amount,
+		Fee:            fee,
+		Note:           note,
+		Timestamp:      time.Now().Unix(),
+		ProposedBy:     userID,
+		Status:         database.ProposalPending,
+	}
+	if err := ms.db.CreateMultisigProposal(ctx, proposal); err != nil {
+		return nil, err
+	}
+	proposal.Approvals = []*database.MultisigApproval{}
+
+	return proposal, nil
+}
+
+// Approve adds the user's signature to a pending proposal
+func (ms *MultisigService) Approve(ctx context.Context, userID, proposalID string, creds SignerCredentials) (*database.MultisigProposal, error) {
+	proposal, mw, err := ms.pendingProposal(ctx, userID, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	signer := signerFor(mw, userID)
+
+	tx := proposalTransaction(proposal)
+	switch {
+	case creds.Password != "":
+		err = ms.signingService.SignWithPassword(ctx, userID, creds.Password, tx)
+	case creds.UnlockToken != "":
+		err = ms.signingService.SignWithUnlockToken(ctx, userID, creds.UnlockToken, tx)
+	case creds.Signature != "":
+		tx.Signature = creds.Signature
+		if valid, verr := crypto.VerifySignature(tx.SigningData(), creds.Signature, signer.PublicKey); verr != nil || !valid {
+			err = blockchain.ErrInvalidSignature
+		}
+	default:
+		err = ErrMissingCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ms.db.CreateMultisigApproval(ctx, &database.MultisigApproval{
+		ProposalID: proposal.ID,
+		UserID:     userID,
+		PublicKey:  signer.PublicKey,
+		Signature:  tx.Signature,
+	}); err != nil {
+		return nil, err
+	}
+
+	return ms.db.GetMultisigProposal(ctx, proposal.ID)
+}
+
+// Broadcast verifies that a proposal has reached its threshold and submits
+// the resulting transaction, returning its hash
+func (ms *MultisigService) Broadcast(ctx context.Context, userID, proposalID string) (string, error) {
+	proposal, mw, err := ms.pendingProposal(ctx, userID, proposalID)
+	if err != nil {
+		return "", err
+	}
+
+	publicKeys := make([]string, len(mw.Signers))
+	for i, signer := range mw.Signers {
+		publicKeys[i] = signer.PublicKey
+	}
+	policy, err := blockchain.NewMultisigPolicy(mw.Threshold, publicKeys)
+	if err != nil {
+		return "", err
+	}
+
+	tx := proposalTransaction(proposal)
+	for _, approval := range proposal.Approvals {
+		tx.Signatures = append(tx.Signatures, blockchain.TxSignature{
+			PublicKey: approval.PublicKey,
+			Signature: approval.Signature,
+		})
+	}
+
+	tx.Multisig = policy
+	if err := tx.VerifyMultisig(policy); err != nil {
+		return "", err
+	}
+
+	signatures, err := json.Marshal(tx.Signatures)
+	if err != nil {
+		return "", err
+	}
+
+	// Claim the proposal before spending, so concurrent broadcasts cannot
+	// both submit its transaction
+	claimed, err := ms.db.UpdateMultisigProposalStatus(ctx, proposal.ID, database.ProposalPending, database.ProposalBroadcasting, "")
+	if err != nil {
+		return "", err
+	}
+	if !claimed {
+		return "", ErrProposalNotPending
+	}
+
+	txHash, err := ms.transactionService.CreateTransaction(ctx, database.Transaction{
+		SenderWallet:    tx.SenderWallet,
+		ReceiverWallet:  tx.ReceiverWallet,
+		Amount:          tx.Amount,
+		Fee:             tx.Fee,
+		Note:            tx.Note,
+		Signature:       string(signatures),
+		TransactionType: "multisig",
+		SignedAt:        tx.Timestamp,
+		SigningHash:     SigningHash(tx),
+	})
+	if err != nil {
+		// A refused transfer recorded nothing, so the proposal goes back to
+		// pending to be retried. Any other failure may have moved funds, so
+		// the proposal is failed rather than reopened for another spend.
+		var rerr error
+		if isTransferRefusal(err) {
+			_, rerr = ms.db.UpdateMultisigProposalStatus(context.WithoutCancel(ctx), proposal.ID,
+				database.ProposalBroadcasting, database.ProposalPending, "")
+		} else {
+			_, rerr = ms.db.FailMultisigProposal(context.WithoutCancel(ctx), proposal.ID, err.Error())
+		}
+		if rerr != nil {
+			return "", errors.Join(err, fmt.Errorf("release proposal: %w", rerr))
+		}
+		return "", err
+	}
+
+	if _, err := ms.db.UpdateMultisigProposalStatus(ctx, proposal.ID, database.ProposalBroadcasting, database.ProposalBroadcast, txHash); err != nil {
+		return "", fmt.Errorf("transaction %s was created but the proposal was not marked broadcast: %w", txHash, err)
+	}
+
+	return txHash, nil
+}
+
+// RecoverStale settles proposals left broadcasting by a server that stopped
+// mid-broadcast. Those whose transaction was recorded are marked broadcast and
+// the others return to pending.
+func (ms *MultisigService) RecoverStale(ctx context.Context) (broadcast, reopened int, err error) {
+	stale, err := ms.db.GetStaleMultisigProposals(ctx, time.Now().Add(-staleBroadcastAfter))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, proposal := range stale {
+		txHash, err := ms.db.GetTransactionHashBySigningHash(ctx, SigningHash(proposalTransaction(proposal)))
+		if err != nil {
+			return broadcast, reopened, err
+		}
+
+		to := database.ProposalPending
+		if txHash != "" {
+			to = database.ProposalBroadcast
+		}
+		moved, err := ms.db.UpdateMultisigProposalStatus(ctx, proposal.ID, database.ProposalBroadcasting, to, txHash)
+		if err != nil {
+			return broadcast, reopened, err
+		}
+		switch {
+		case !moved:
+		case txHash != "":
+			broadcast++
+		default:
+			reopened++
+		}
+	}
+	return broadcast, reopened, nil
+}
+
+// pendingProposal loads a pending proposal and its wallet, checking that the
+// user co-signs it
+func (ms *MultisigService) pendingProposal(ctx context.Context, userID, proposalID string) (*database.MultisigProposal, *database.MultisigWallet, error) {
+	proposal, err := ms.db.GetMultisigProposal(ctx, proposalID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if proposal == nil {
+		return nil, nil, ErrProposalNotFound
+	}
+
+	mw, err := ms.GetWallet(ctx, userID, proposal.WalletAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if proposal.Status != database.ProposalPending {
+		return nil, nil, ErrProposalNotPending
+	}
+
+	return proposal, mw, nil
+}
+
+// proposalTransaction builds the canonical transaction co-signers sign
+func proposalTransaction(p *database.MultisigProposal) *blockchain.Transaction {
+	tx := blockchain.NewTransaction(p.WalletAddress, p.ReceiverWallet, p.Amount, p.Fee, p.Note)
+	tx.Timestamp = p.Timestamp
+	return tx
+}
+
+// signerFor returns the user's co-signer entry on the wallet, if any
+func signerFor(mw *database.MultisigWallet, userID string) *database.MultisigSigner {
+	for _, signer := range mw.Signers {
+		if signer.UserID == userID {
+			return signer
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Nisab weights in grams as used by most contemporary fatwa councils
+const (
+	GoldNisabGrams   = 87.48
+	SilverNisabGrams = 612.36
+)
+
+// Nisab sources
+const (
+	NisabSourceFixed  = "fixed"
+	NisabSourceGold   = "gold"
+	NisabSourceSilver = "silver"
+)
+
+// MetalPrices is the content of the local gold/silver price feed file.
+// Prices are per gram in wallet coins.
+type MetalPrices struct {
+	GoldPerGram   float64   `json:"gold_per_gram"`
+	SilverPerGram float64   `json:"silver_per_gram"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Nisab is the threshold in force for an assessment and where it came from
+type Nisab struct {
+	Amount   float64   `json:"amount"`
+	Source   string    `json:"source"`
+	PricedAt time.Time `json:"priced_at,omitempty"`
+}
+
+// NisabProvider resolves the current nisab from a fixed amount or from the
+// price feed file, which is re-read on each call so it can be refreshed
+// without a restart
+type NisabProvider struct {
+	source    string
+	fixed     float64
+	priceFile string
+}
+
+// NewNisabProvider creates a nisab provider. source is one of fixed, gold or
+// silver; priceFile is required for gold and silver.
+func NewNisabProvider(source string, fixed float64, priceFile string) (*NisabProvider, error) {
+	switch source {
+	case NisabSourceFixed:
+		if fixed < 0 {
+			return nil, fmt.Errorf("nisab amount cannot be negative")
+		}
+	case NisabSourceGold, NisabSourceSilver:
+		if priceFile == "" {
+			return nil, fmt.Errorf("a price file is required for %s nisab", source)
+		}
+	default:
+		return nil, fmt.Errorf("unknown nisab source %q", source)
+	}
+
+	return &NisabProvider{source: source, fixed: fixed, priceFile: priceFile}, nil
+}
+
+// Current returns the nisab in force now
+func (np *NisabProvider) Current() (*Nisab, error) {
+	if np.source == NisabSourceFixed {
+		return &Nisab{Amount: np.fixed, Source: NisabSourceFixed}, nil
+	}
+
+	data, err := os.ReadFile(np.priceFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read price file: %w", err)
+	}
+	var prices MetalPrices
+	if err := json.Unmarshal(data, &prices); err != nil {
+		return nil, fmt.Errorf("invalid price file: %w", err)
+	}
+
+	nisab := &Nisab{Source: np.source, PricedAt: prices.UpdatedAt}
+	if np.source == NisabSourceGold {
+		nisab.Amount = prices.GoldPerGram * GoldNisabGrams
+	} else {
+		nisab.Amount = prices.SilverPerGram * SilverNisabGrams
+	}
+	if nisab.Amount <= 0 {
+		return nil, fmt.Errorf("price file has no %s price", np.source)
+	}
+
+	return nisab, nil
+}
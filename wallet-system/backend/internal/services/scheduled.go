@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"crypto-wallet-backend/internal/blockchain"
+	"crypto-wallet-backend/internal/crypto"
+	"crypto-wallet-backend/internal/database"
+)
+
+// scheduledBatchSize limits how many due transfers one run submits
+const scheduledBatchSize = 50
+
+// staleExecutionAfter is how long a transfer may stay executing before it is
+// presumed abandoned by a server that stopped mid-run
+const staleExecutionAfter = 15 * time.Minute
+
+// ScheduledTransferService stores pre-authorised transfers and submits them
+// once their execution time arrives
+type ScheduledTransferService struct {
+	db                 *database.Database
+	transactionService *TransactionService
+	logger             *slog.Logger
+}
+
+// NewScheduledTransferService creates a new scheduled transfer service
+func NewScheduledTransferService(db *database.Database, transactionService *TransactionService, logger *slog.Logger) *ScheduledTransferService {
+	return &ScheduledTransferService{db: db, transactionService: transactionService, logger: logger}
+}
+
+// Schedule stores a transfer whose transaction has already been signed with
+// LockTime set to its execution time
+func (ss *ScheduledTransferService) Schedule(ctx context.Context, userID string, tx *blockchain.Transaction) (*database.ScheduledTransfer, error) {
+	executeAt := time.Unix(tx.LockTime, 0)
+	if tx.LockTime < blockchain.LockTimeThreshold || !executeAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: execution time must be in the future", blockchain.ErrInvalidTransaction)
+	}
+
+	st := &database.ScheduledTransfer{
+		UserID:         userID,
+		SenderWallet:   tx.SenderWallet,
+		ReceiverWallet: tx.ReceiverWallet,
+		Amount:         tx.Amount,
+		Fee:            tx.Fee,
+		Note:           tx.Note,
+		Timestamp:      tx.Timestamp,
+		ExecuteAt:      executeAt,
+		LockUntil:      tx.LockUntil,
+		Signature:      tx.Signature,
+		Status:         database.ScheduledPending,
+	}
+	if err := ss.db.CreateScheduledTransfer(ctx, st); err != nil {
+		return nil, err
+	}
+
+	return st, nil
+}
+
+// ProcessDue submits every transfer whose execution time has passed and
+// returns how many succeeded and failed
+func (ss *ScheduledTransferService) ProcessDue(ctx context.Context) (executed int, failed int, err error) {
+	if err := ss.recoverStale(ctx); err != nil {
+		return 0, 0, err
+	}
+
+	for {
+		due, err := ss.db.ClaimDueScheduledTransfers(ctx, time.Now(), scheduledBatchSize)
+		if err != nil {
+			return executed, failed, err
+		}
+		if len(due) == 0 {
+			return executed, failed, nil
+		}
+
+		for _, st := range due {
+			txHash, err := ss.execute(ctx, st)
+			if err != nil {
+				failed++
+				ss.logger.WarnContext(ctx, "Scheduled transfer failed", "scheduled_transfer_id", st.ID, "wallet", st.SenderWallet, "error", err)
+				if cerr := ss.db.CompleteScheduledTransfer(ctx, st.ID, database.ScheduledFailed, "", err.Error()); cerr != nil {
+					return executed, failed, cerr
+				}
+				continue
+			}
+
+			executed++
+			if cerr := ss.db.CompleteScheduledTransfer(ctx, st.ID, database.ScheduledExecuted, txHash, ""); cerr != nil {
+				return executed, failed, cerr
+			}
+		}
+	}
+}
+
+// recoverStale settles transfers left executing by a server that stopped
+// mid-run. Those whose transaction was recorded are marked executed; the rest
+// return to pending and are retried.
+func (ss *ScheduledTransferService) recoverStale(ctx context.Context) error {
+	stale, err := ss.db.GetStaleScheduledTransfers(ctx, time.Now().Add(-staleExecutionAfter))
+	if err != nil {
+		return err
+	}
+
+	for _, st := range stale {
+		txHash, err := ss.db.GetTransactionHashBySigningHash(ctx, SigningHash(scheduledTransaction(st)))
+		if err != nil {
+			return err
+		}
+
+		if txHash != "" {
+			ss.logger.WarnContext(ctx, "Recovered executed scheduled transfer", "scheduled_transfer_id", st.ID, "transaction_hash", txHash)
+			if err := ss.db.CompleteScheduledTransfer(ctx, st.ID, database.ScheduledExecuted, txHash, ""); err != nil {
+				return err
+			}
+			continue
+		}
+
+		ss.logger.WarnContext(ctx, "Requeued stale scheduled transfer", "scheduled_transfer_id", st.ID, "wallet", st.SenderWallet)
+		if _, err := ss.db.RequeueScheduledTransfer(ctx, st.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execute re-verifies the stored signature against the wallet owner's key
+// and submits the transaction
+func (ss *ScheduledTransferService) execute(ctx context.Context, st *database.ScheduledTransfer) (string, error) {
+	tx := scheduledTransaction(st)
+
+	wallet, err := ss.db.GetWalletByAddress(ctx, st.SenderWallet)
+	if err != nil {
+		return "", err
+	}
+	if wallet == nil {
+		return "", blockchain.ErrInvalidWallet
+	}
+	owner, err := ss.db.GetUserByID(ctx, wallet.UserID)
+	if err != nil {
+		return "", err
+	}
+	if owner == nil {
+		return "", ErrWalletOwnerMissing
+	}
+
+	if valid, err := crypto.VerifySignature(tx.SigningData(), st.Signature, owner.PublicKey); err != nil || !valid {
+		return "", blockchain.ErrInvalidSignature
+	}
+
+	return ss.transactionService.CreateTransaction(ctx, database.Transaction{
+		SenderWallet:   tx.SenderWallet,
+		ReceiverWallet: tx.ReceiverWallet,
+		Amount:         tx.Amount,
+		Fee:            tx.Fee,
+		Note:           tx.Note,
+		Signature:      st.Signature,
+		LockTime:       tx.LockTime,
+		LockUntil:      tx.LockUntil,
+		SignedAt:       tx.Timestamp,
+		SigningHash:    SigningHash(tx),
+	})
+}
+
+// scheduledTransaction rebuilds the transaction a scheduled transfer's owner
+// signed
+func scheduledTransaction(st *database.ScheduledTransfer) *blockchain.Transaction {
+	tx := blockchain.NewTransaction(st.SenderWallet, st.ReceiverWallet, st.Amount, st.Fee, st.Note)
+	tx.Timestamp = st.Timestamp
+	tx.LockTime = st.ExecuteAt.Unix()
+	tx.LockUntil = st.LockUntil
+	return tx
+}
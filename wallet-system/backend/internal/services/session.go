@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"crypto-wallet-backend/internal/database"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TokenTypeAccess marks session access tokens. Tokens of any other type,
+// such as login challenges, are refused where an access token is expected.
+const TokenTypeAccess = "access"
+
+// SessionService issues HS256 access tokens and rotating refresh tokens.
+// Tokens name their signing key in the kid header; new tokens use the
+// active key, and tokens signed by any configured key verify, so a key can
+// be rotated by adding a new one, making it active and dropping the old one
+// once its tokens have expired. Refresh tokens are stored only as hashes.
+type SessionService struct {
+	db          *database.Database
+	audit       *AuditService
+	keys        map[string][]byte
+	activeKeyID string
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+}
+
+// NewSessionService creates a session service signing with keys[activeKeyID]
+func NewSessionService(db *database.Database, keys map[string]string, activeKeyID string, accessTTL, refreshTTL time.Duration) (*SessionService, error) {
+	if _, ok := keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("active JWT key %q is not configured", activeKeyID)
+	}
+	ss := &SessionService{
+		db:          db,
+		audit:       NewAuditService(db),
+		keys:        make(map[string][]byte, len(keys)),
+		activeKeyID: activeKeyID,
+		accessTTL:   accessTTL,
+		refreshTTL:  refreshTTL,
+	}
+	for kid, secret := range keys {
+		if secret == "" {
+			return nil, fmt.Errorf("JWT key %q is empty", kid)
+		}
+		ss.keys[kid] = []byte(secret)
+	}
+	return ss, nil
+}
+
+// TokenPair is what a client holds for a session
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	SessionID    string `json:"session_id"`
+	UserID       string `json:"user_id"`
+}
+
+// Sign signs claims with the active key, naming it in the kid header
+func (ss *SessionService) Sign(claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = ss.activeKeyID
+	return token.SignedString(ss.keys[ss.activeKeyID])
+}
+
+// Parse verifies a token's signature and expiry and returns its claims. Only
+// HS256 is accepted, whatever the token header says, and the kid must name
+// a configured key.
+func (ss *SessionService) Parse(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := ss.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	// Tokens without an expiry would never lapse
+	claims := token.Claims.(jwt.MapClaims)
+	if exp, err := claims.GetExpirationTime(); err != nil || exp == nil {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// Authenticate checks an access token and that its session is still active
+func (ss *SessionService) Authenticate(ctx context.Context, tokenString string) (jwt.MapClaims, error) {
+	claims, err := ss.Parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if typ, _ := claims["typ"].(string); typ != TokenTypeAccess {
+		return nil, ErrInvalidToken
+	}
+	sessionID, _ := claims["sid"].(string)
+	if sessionID == "" {
+		return nil, ErrInvalidToken
+	}
+
+	active, err := ss.db.IsSessionActive(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// CreateSession starts a session for an authenticated user
+func (ss *SessionService) CreateSession(ctx context.Context, user *database.User, userAgent, ipAddress string) (*TokenPair, error) {
+	refreshToken, refreshHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &database.Session{
+		UserID:      user.ID,
+		RefreshHash: refreshHash,
+		UserAgent:   truncate(userAgent, 512),
+		IPAddress:   ipAddress,
+		ExpiresAt:   time.Now().Add(ss.refreshTTL),
+	}
+	if err := ss.db.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return ss.tokenPair(user, session.ID, refreshToken)
+}
+
+// Refresh exchanges a refresh token for a new pair, rotating the refresh
+// token. Presenting a token that was already rotated means it was copied,
+// so the whole session is revoked.
+func (ss *SessionService) Refresh(ctx context.Context, refreshToken, userAgent, ipAddress string) (*TokenPair, error) {
+	oldHash := hashRefreshToken(refreshToken)
+	session, err := ss.db.GetSessionByRefreshHash(ctx, oldHash)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if session.RefreshHash != oldHash {
+		return nil, ss.revokeReused(ctx, session)
+	}
+
+	user, err := ss.db.GetUserByID(ctx, session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	newToken, newHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := ss.db.RotateSession(ctx, session.ID, oldHash, newHash, truncate(userAgent, 512), ipAddress, time.Now().Add(ss.refreshTTL))
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		return nil, ss.revokeReused(ctx, session)
+	}
+
+	return ss.tokenPair(user, session.ID, newToken)
+}
+
+// revokeReused ends a session whose old refresh token was replayed
+func (ss *SessionService) revokeReused(ctx context.Context, session *database.Session) error {
+	if _, err := ss.db.RevokeSession(ctx, session.ID, session.UserID); err != nil {
+		return err
+	}
+	_ = ss.audit.Record(ctx, AuditEvent{
+		Type:    database.LogSessionReuse,
+		Message: fmt.Sprintf("Refresh token of session %s for user %s was reused; session revoked", session.ID, session.UserID),
+		ActorID: session.UserID,
+		Before:  map[string]string{"session_id": session.ID, "ip_address": session.IPAddress, "user_agent": session.UserAgent},
+	})
+	return ErrRefreshTokenReused
+}
+
+// GetSessions lists a user's active sessions
+func (ss *SessionService) GetSessions(ctx context.Context, userID string) ([]*database.Session, error) {
+	return ss.db.GetActiveSessions(ctx, userID)
+}
+
+// Revoke ends one of a user's sessions
+func (ss *SessionService) Revoke(ctx context.Context, userID, sessionID string) error {
+	revoked, err := ss.db.RevokeSession(ctx, sessionID, userID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeAll ends every session of a user and returns how many
+func (ss *SessionService) RevokeAll(ctx context.Context, userID string) (int64, error) {
+	return ss.db.RevokeUserSessions(ctx, userID)
+}
+
+// tokenPair signs an access token for a session and pairs it with the
+// session's refresh token
+func (ss *SessionService) tokenPair(user *database.User, sessionID, refreshToken string) (*TokenPair, error) {
+	now := time.Now()
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := ss.Sign(jwt.MapClaims{
+		"sub":   user.ID,
+		"email": user.Email,
+		"role":  user.Role,
+		"sid":   sessionID,
+		"jti":   hex.EncodeToString(jti),
+		"typ":   TokenTypeAccess,
+		"iat":   now.Unix(),
+		"exp":   now.Add(ss.accessTTL).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(ss.accessTTL.Seconds()),
+		SessionID:    sessionID,
+		UserID:       user.ID,
+	}, nil
+}
+
+// newRefreshToken returns a random refresh token and the hash to store
+func newRefreshToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashRefreshToken(token), nil
+}
+
+// hashRefreshToken hashes a refresh token for storage. The token is 256
+// random bits, so a plain hash cannot be reversed.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// truncate shortens s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"crypto-wallet-backend/internal/blockchain"
+	"crypto-wallet-backend/internal/crypto"
+	"crypto-wallet-backend/internal/database"
+)
+
+const (
+	// UnlockTokenTTL is how long an unlock token can be used to sign transfers
+	UnlockTokenTTL = 5 * time.Minute
+
+	// MaxSignatureAge bounds how far a client-supplied transaction timestamp
+	// may drift from the server clock
+	MaxSignatureAge = 10 * time.Minute
+)
+
+// SigningService signs transfers with a user's custodial key and verifies
+// signatures produced by clients. Unlock tokens are kept in the database,
+// each holding the key re-encrypted under the token; the plaintext key is
+// never kept between requests.
+type SigningService struct {
+	db *database.Database
+}
+
+// NewSigningService creates a new signing service
+func NewSigningService(db *database.Database) *SigningService {
+	return &SigningService{db: db}
+}
+
+// Unlock checks the user's password and returns a token that can sign
+// transfers until it expires
+func (ss *SigningService) Unlock(ctx context.Context, userID, password string) (string, time.Time, error) {
+	user, err := ss.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if user == nil {
+		return "", time.Time{}, ErrWalletOwnerMissing
+	}
+	if user.IsNonCustodial() {
+		return "", time.Time{}, ErrNonCustodialWallet
+	}
+
+	key, err := crypto.DecryptPrivateKeyBytes(user.EncryptedPrivateKey, password)
+	if err != nil {
+		return "", time.Time{}, ErrInvalidPassword
+	}
+	defer crypto.ZeroBytes(key)
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	sealedKey, err := crypto.EncryptPrivateKeyBytes(key, token)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(UnlockTokenTTL)
+
+	if err := ss.db.CreateUnlockToken(ctx, crypto.SHA256Hash(token), userID, sealedKey, expiresAt); err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+// SignWithPassword decrypts the owner's private key with their password and
+// signs the transaction, setting its Signature and PublicKey
+func (ss *SigningService) SignWithPassword(ctx context.Context, ownerID, password string, tx *blockchain.Transaction) error {
+	user, err := ss.db.GetUserByID(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrWalletOwnerMissing
+	}
+	if user.IsNonCustodial() {
+		return ErrNonCustodialWallet
+	}
+
+	key, err := crypto.DecryptPrivateKeyBytes(user.EncryptedPrivateKey, password)
+	if err != nil {
+		return ErrInvalidPassword
+	}
+
+	return ss.sign(user, key, tx)
+}
+
+// SignWithUnlockToken signs the transaction with the key sealed under a token
+// previously issued by Unlock
+func (ss *SigningService) SignWithUnlockToken(ctx context.Context, ownerID, token string, tx *blockchain.Transaction) error {
+	sealedKey, err := ss.db.GetUnlockToken(ctx, crypto.SHA256Hash(token), ownerID)
+	if err != nil {
+		return err
+	}
+	if sealedKey == "" {
+		return ErrInvalidUnlockToken
+	}
+
+	user, err := ss.db.GetUserByID(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrWalletOwnerMissing
+	}
+
+	key, err := crypto.DecryptPrivateKeyBytes(sealedKey, token)
+	if err != nil {
+		return ErrInvalidUnlockToken
+	}
+
+	return ss.sign(user, key, tx)
+}
+
+// VerifyClientSignature checks a signature produced by the client against the
+// wallet owner's public key. A signature that already moved funds is refused
+// with database.ErrSignatureReused.
+func (ss *SigningService) VerifyClientSignature(ctx context.Context, ownerID string, tx *blockchain.Transaction) error {
+	drift := time.Since(time.Unix(tx.Timestamp, 0))
+	if drift > MaxSignatureAge || drift < -MaxSignatureAge {
+		return ErrSignatureExpired
+	}
+
+	used, err := ss.db.SigningHashUsed(ctx, SigningHash(tx))
+	if err != nil {
+		return err
+	}
+	if used {
+		return database.ErrSignatureReused
+	}
+
+	user, err := ss.db.GetUserByID(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrWalletOwnerMissing
+	}
+
+	valid, err := crypto.VerifySignature(tx.SigningData(), tx.Signature, user.PublicKey)
+	if err != nil || !valid {
+		return blockchain.ErrInvalidSignature
+	}
+
+	tx.PublicKey = user.PublicKey
+	return nil
+}
+
+// SigningHash identifies the data a client signed. Recorded with the
+// transaction, it lets each client signature be used once.
+func SigningHash(tx *blockchain.Transaction) string {
+	return crypto.SHA256Hash(tx.SigningData())
+}
+
+// sign signs the canonical transaction data and wipes the key afterwards
+func (ss *SigningService) sign(user *database.User, key []byte, tx *blockchain.Transaction) error {
+	defer crypto.ZeroBytes(key)
+
+	signature, err := crypto.SignTransactionWithKey(tx.SigningData(), key)
+	if err != nil {
+		return fmt.Errorf("failed to sign transaction: %w", err)
+	}
+
+	tx.Signature = signature
+	tx.PublicKey = user.PublicKey
+	return nil
+}
@@ -4,19 +4,28 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"log/slog"
 	"time"
+
+	"crypto-wallet-backend/internal/blockchain"
 	"crypto-wallet-backend/internal/database"
+	"crypto-wallet-backend/internal/metrics"
 )
 
 // TransactionService handles transaction operations
 type TransactionService struct {
-	db *database.Database
+	db      *database.Database
+	bc      *blockchain.Blockchain
+	audit   *AuditService
+	logger  *slog.Logger
+	metrics *metrics.Metrics
 }
 
 // NewTransactionService creates a new transaction service
-func NewTransactionService(db *database.Database) *TransactionService {
-	return &TransactionService{db: db}
+func NewTransactionService(db *database.Database, bc *blockchain.Blockchain, logger *slog.Logger, m *metrics.Metrics) *TransactionService {
+	return &TransactionService{db: db, bc: bc, audit: NewAuditService(db), logger: logger, metrics: m}
 }
 
 // CreateTransaction creates a new transaction
@@ -33,6 +42,62 @@ func (ts *TransactionService) CreateTransaction(ctx context.Context, tx database
 	h := sha256.Sum256([]byte(fmt.Sprintf("%s%s%d%.8f", tx.SenderWallet, tx.ReceiverWallet, time.Now().UnixNano(), tx.Amount)))
 	txHash := hex.EncodeToString(h[:])
 
+	txType := tx.TransactionType
+	if txType == "" {
+		txType = "transfer"
+	}
+
+	// Checks, UTXO selection and the transfer's records share one database
+	// transaction holding the sender's wallet and UTXO rows, so concurrent
+	// transfers can neither spend the same outputs nor overrun a limit
+	var senderBal, recvBal float64
+	err := ts.db.InTx(ctx, func(db *database.Database) error {
+		var err error
+		senderBal, recvBal, err = ts.transfer(ctx, db, tx, txHash, txType)
+		return err
+	})
+	var refused refusedError
+	if errors.As(err, &refused) {
+		ts.recordRefusal(ctx, tx, refused.error)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Log system event
+	_ = ts.audit.Record(ctx, AuditEvent{
+		Type:          database.LogTransactionCreated,
+		Message:       fmt.Sprintf("Transaction %s: %s -> %s amount %.8f fee %.8f", txHash, tx.SenderWallet, tx.ReceiverWallet, tx.Amount, tx.Fee),
+		WalletAddress: tx.SenderWallet,
+		After: map[string]interface{}{
+			"transaction_hash": txHash,
+			"type":             tx.TransactionType,
+			"receiver_wallet":  tx.ReceiverWallet,
+			"amount":           tx.Amount,
+			"fee":              tx.Fee,
+			"sender_balance":   senderBal,
+			"receiver_balance": recvBal,
+		},
+	})
+	ts.metrics.ObserveTransfer(txType, tx.Amount)
+
+	return txHash, nil
+}
+
+// transfer checks and records a transfer within db's transaction, returning
+// the resulting sender and receiver balances
+func (ts *TransactionService) transfer(ctx context.Context, db *database.Database, tx database.Transaction, txHash, txType string) (float64, float64, error) {
+	// Wallet holds and tier limits apply before any funds are selected
+	if err := ts.checkTransfer(ctx, db, tx); err != nil {
+		return 0, 0, err
+	}
+
+	// Time-locked transactions cannot be accepted before their lock time
+	height, now := ts.bc.Height(), time.Now().Unix()
+	if !blockchain.IsLockSatisfied(tx.LockTime, height, now) {
+		return 0, 0, fmt.Errorf("%w until %d", blockchain.ErrTransactionNotFinal, tx.LockTime)
+	}
+
 	// Build DB transaction record
 	dbTx := &database.Transaction{
 		TransactionHash: txHash,
@@ -43,20 +108,19 @@ func (ts *TransactionService) CreateTransaction(ctx context.Context, tx database
 		Note:            tx.Note,
 		Signature:       tx.Signature,
 		Status:          "confirmed",
-		TransactionType: "transfer",
+		TransactionType: txType,
+		LockTime:        tx.LockTime,
+		LockUntil:       tx.LockUntil,
+		SignedAt:        tx.SignedAt,
+		SigningHash:     tx.SigningHash,
 		CreatedAt:       time.Now(),
 	}
 
-	// Persist transaction record
-	if err := ts.db.CreateTransaction(ctx, dbTx); err != nil {
-		return "", fmt.Errorf("failed to create transaction: %w", err)
-	}
-
-	// Select sender UTXOs to cover amount + fee
+	// Select sender UTXOs to cover amount + fee, skipping outputs still locked
 	required := tx.Amount + tx.Fee
-	utxos, err := ts.db.GetUTXOsByWallet(ctx, tx.SenderWallet)
+	utxos, err := db.GetSpendableUTXOsByWallet(ctx, tx.SenderWallet, height, now)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch sender utxos: %w", err)
+		return 0, 0, fmt.Errorf("failed to fetch sender utxos: %w", err)
 	}
 
 	total := 0.0
@@ -69,19 +133,23 @@ func (ts *TransactionService) CreateTransaction(ctx context.Context, tx database
 		}
 	}
 
-	// If UTXOs are insufficient, try to fallback to the wallet's cached balance
-	if total < required {
-		// Diagnostic log: no or insufficient UTXOs
-		fmt.Printf("[tx] insufficient utxos: found %d utxos, total %.8f required %.8f for wallet %s\n", len(utxos), total, required, tx.SenderWallet)
-
-		wallet, werr := ts.db.GetWalletByAddress(ctx, tx.SenderWallet)
+	// If the wallet has no UTXOs at all (created before UTXO tracking), fall
+	// back to its cached balance. Wallets holding locked outputs never use the
+	// fallback, as the cache includes those locked amounts.
+	allUTXOs, err := db.GetUTXOsByWallet(ctx, tx.SenderWallet)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to fetch sender utxos: %w", err)
+	}
+	if total < required && len(allUTXOs) == 0 {
+		wallet, werr := db.GetWalletByAddress(ctx, tx.SenderWallet)
 		if werr != nil {
-			return "", fmt.Errorf("failed to fetch wallet for fallback: %w", werr)
+			return 0, 0, fmt.Errorf("failed to fetch wallet for fallback: %w", werr)
 		}
-		if wallet != nil {
-			fmt.Printf("[tx] wallet.BalanceCache for %s = %.8f\n", wallet.WalletAddress, wallet.BalanceCache)
+		if wallet == nil {
+			ts.logger.WarnContext(ctx, "Sender has no UTXOs and no wallet", "wallet", tx.SenderWallet)
 		} else {
-			fmt.Printf("[tx] wallet not found for address %s\n", tx.SenderWallet)
+			ts.logger.DebugContext(ctx, "Sender has no UTXOs; falling back to cached balance",
+				"wallet", tx.SenderWallet, "balance_cache", wallet.BalanceCache, "required", required)
 		}
 		if wallet != nil && wallet.BalanceCache >= required {
 			// Create a synthetic UTXO representing the cached balance
@@ -96,8 +164,8 @@ func (ts *TransactionService) CreateTransaction(ctx context.Context, tx database
 				IsSpent:         false,
 				CreatedAt:       time.Now(),
 			}
-			if err := ts.db.CreateUTXO(ctx, seedUTXO); err != nil {
-				return "", fmt.Errorf("failed to create seed utxo for fallback: %w", err)
+			if err := db.CreateUTXO(ctx, seedUTXO); err != nil {
+				return 0, 0, fmt.Errorf("failed to create seed utxo for fallback: %w", err)
 			}
 			used = append(used, seedUTXO)
 			total += seedUTXO.Amount
@@ -105,13 +173,19 @@ func (ts *TransactionService) CreateTransaction(ctx context.Context, tx database
 	}
 
 	if total < required {
-		return "", fmt.Errorf("insufficient funds: have %.8f required %.8f", total, required)
+		return 0, 0, fmt.Errorf("%w: have %.8f required %.8f", ErrInsufficientFunds, total, required)
+	}
+
+	// Persist the transaction record once it is known to be funded, so
+	// refused transfers do not count towards the sender's limits
+	if err := db.CreateTransaction(ctx, dbTx); err != nil {
+		return 0, 0, fmt.Errorf("failed to create transaction: %w", err)
 	}
 
 	// Mark used UTXOs as spent
 	for _, u := range used {
-		if err := ts.db.MarkUTXOAsSpent(ctx, u.TransactionHash, u.OutputIndex, txHash); err != nil {
-			return "", fmt.Errorf("failed to mark utxo spent: %w", err)
+		if err := db.MarkUTXOAsSpent(ctx, u.TransactionHash, u.OutputIndex, txHash); err != nil {
+			return 0, 0, fmt.Errorf("failed to mark utxo spent: %w", err)
 		}
 	}
 
@@ -123,9 +197,10 @@ func (ts *TransactionService) CreateTransaction(ctx context.Context, tx database
 		Amount:          tx.Amount,
 		IsSpent:         false,
 		CreatedAt:       time.Now(),
+		LockUntil:       tx.LockUntil,
 	}
-	if err := ts.db.CreateUTXO(ctx, out); err != nil {
-		return "", fmt.Errorf("failed to create receiver utxo: %w", err)
+	if err := db.CreateUTXO(ctx, out); err != nil {
+		return 0, 0, fmt.Errorf("failed to create receiver utxo: %w", err)
 	}
 
 	// Create change UTXO back to sender if any
@@ -139,44 +214,43 @@ func (ts *TransactionService) CreateTransaction(ctx context.Context, tx database
 			IsSpent:         false,
 			CreatedAt:       time.Now(),
 		}
-		if err := ts.db.CreateUTXO(ctx, changeUTXO); err != nil {
-			return "", fmt.Errorf("failed to create change utxo: %w", err)
+		if err := db.CreateUTXO(ctx, changeUTXO); err != nil {
+			return 0, 0, fmt.Errorf("failed to create change utxo: %w", err)
 		}
 	}
 
-	// Update wallet cached balances (recalculate from UTXOs)
-	// Receiver
-	recvUtxos, _ := ts.db.GetUTXOsByWallet(ctx, tx.ReceiverWallet)
-	recvBal := 0.0
-	for _, u := range recvUtxos {
-		if !u.IsSpent {
-			recvBal += u.Amount
-		}
+	// Update wallet cached balances (recalculate from UTXOs). A failed
+	// statement aborts the transaction, so errors end the transfer.
+	recvBal, err := refreshBalance(ctx, db, tx.ReceiverWallet)
+	if err != nil {
+		return 0, 0, err
+	}
+	senderBal, err := refreshBalance(ctx, db, tx.SenderWallet)
+	if err != nil {
+		return 0, 0, err
 	}
-	fmt.Printf("[tx-balance] Receiver %s: %d utxos, calculated balance = %.8f\n", tx.ReceiverWallet, len(recvUtxos), recvBal)
-	_ = ts.db.UpdateWalletBalance(ctx, tx.ReceiverWallet, recvBal)
+	ts.logger.DebugContext(ctx, "Updated cached balances", "sender", tx.SenderWallet, "sender_balance", senderBal,
+		"receiver", tx.ReceiverWallet, "receiver_balance", recvBal)
+
+	return senderBal, recvBal, nil
+}
 
-	// Sender
-	senderUtxos, _ := ts.db.GetUTXOsByWallet(ctx, tx.SenderWallet)
-	senderBal := 0.0
-	for _, u := range senderUtxos {
+// refreshBalance recalculates a wallet's cached balance from its UTXOs
+func refreshBalance(ctx context.Context, db *database.Database, walletAddress string) (float64, error) {
+	utxos, err := db.GetUTXOsByWallet(ctx, walletAddress)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch utxos of %s: %w", walletAddress, err)
+	}
+	balance := 0.0
+	for _, u := range utxos {
 		if !u.IsSpent {
-			senderBal += u.Amount
+			balance += u.Amount
 		}
-		fmt.Printf("[tx-balance] Sender UTXO: hash=%s amount=%.8f is_spent=%v\n", u.TransactionHash, u.Amount, u.IsSpent)
 	}
-	fmt.Printf("[tx-balance] Sender %s: %d utxos, calculated balance = %.8f\n", tx.SenderWallet, len(senderUtxos), senderBal)
-	_ = ts.db.UpdateWalletBalance(ctx, tx.SenderWallet, senderBal)
-
-	// Log system event
-	_ = ts.db.CreateSystemLog(ctx, &database.SystemLog{
-		LogType:       "transaction",
-		Message:       fmt.Sprintf("Transaction %s: %s -> %s amount %.8f fee %.8f", txHash, tx.SenderWallet, tx.ReceiverWallet, tx.Amount, tx.Fee),
-		WalletAddress: tx.SenderWallet,
-		CreatedAt:     time.Now(),
-	})
-
-	return txHash, nil
+	if err := db.UpdateWalletBalance(ctx, walletAddress, balance); err != nil {
+		return 0, fmt.Errorf("failed to update cached balance of %s: %w", walletAddress, err)
+	}
+	return balance, nil
 }
 
 // GetTransactionHistory retrieves transaction history for a wallet
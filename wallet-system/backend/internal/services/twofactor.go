@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"crypto-wallet-backend/internal/crypto"
+	"crypto-wallet-backend/internal/database"
+	"crypto-wallet-backend/internal/utils"
+)
+
+// Emailed code limits
+const (
+	otpLifetime       = 5 * time.Minute
+	otpMaxAttempts    = 5
+	otpResendInterval = time.Minute
+)
+
+// totpSkew is how many 30-second steps either side of now a TOTP code may
+// come from, allowing for clock drift on the user's device
+const totpSkew = 1
+
+// Second factor methods
+const (
+	FactorEmail = "email"
+	FactorTOTP  = "totp"
+)
+
+// TwoFactorPolicy decides when a second factor is required. Users with an
+// authenticator app always need it to log in; RequireForLogin extends that
+// to everyone, with emailed codes for users without an app. Transfers above
+// TransferThreshold need a code as well; 0 disables the threshold.
+type TwoFactorPolicy struct {
+	RequireForLogin   bool    `json:"require_for_login"`
+	TransferThreshold float64 `json:"transfer_threshold"`
+}
+
+// TwoFactorService issues and checks second-factor codes: authenticator app
+// (TOTP) codes for enrolled users and emailed one-time codes for the rest.
+type TwoFactorService struct {
+	db     *database.Database
+	audit  *AuditService
+	mailer utils.Mailer
+	issuer string
+	key    string
+	policy TwoFactorPolicy
+}
+
+// NewTwoFactorService creates a two-factor service. key encrypts TOTP
+// secrets at rest and keys the hashes of emailed codes; issuer names the
+// service in authenticator apps.
+func NewTwoFactorService(db *database.Database, mailer utils.Mailer, issuer, key string, policy TwoFactorPolicy) *TwoFactorService {
+	return &TwoFactorService{db: db, audit: NewAuditService(db), mailer: mailer, issuer: issuer, key: key, policy: policy}
+}
+
+// TwoFactorStatus reports a user's enrolment and the policy that applies
+type TwoFactorStatus struct {
+	TOTPEnabled bool            `json:"totp_enabled"`
+	Policy      TwoFactorPolicy `json:"policy"`
+}
+
+// TOTPEnrolment is a new authenticator secret and its provisioning URI,
+// which clients render as a QR code
+type TOTPEnrolment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// GetStatus returns a user's enrolment and the policy
+func (tfs *TwoFactorService) GetStatus(ctx context.Context, userID string) (*TwoFactorStatus, error) {
+	totp, err := tfs.db.GetUserTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &TwoFactorStatus{TOTPEnabled: totp != nil && totp.Enabled, Policy: tfs.policy}, nil
+}
+
+// LoginFactor returns the second factor a user must present to log in, or
+// "" if none is required
+func (tfs *TwoFactorService) LoginFactor(ctx context.Context, user *database.User) (string, error) {
+	return tfs.factor(ctx, user, tfs.policy.RequireForLogin)
+}
+
+// TransferFactor returns the second factor a user must present to send
+// amount, or "" if none is required
+func (tfs *TwoFactorService) TransferFactor(ctx context.Context, user *database.User, amount float64) (string, error) {
+	required := tfs.policy.TransferThreshold > 0 && amount > tfs.policy.TransferThreshold
+	if !required {
+		return "", nil
+	}
+	return tfs.factor(ctx, user, true)
+}
+
+// factor returns the user's method when a code is required, or "" if not.
+// Enrolled users always get TOTP, so an app cannot be bypassed by email.
+func (tfs *TwoFactorService) factor(ctx context.Context, user *database.User, required bool) (string, error) {
+	totp, err := tfs.db.GetUserTOTP(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+	if totp != nil && totp.Enabled {
+		return FactorTOTP, nil
+	}
+	if required {
+		return FactorEmail, nil
+	}
+	return "", nil
+}
+
+// SendCode emails a one-time code for purpose, replacing any earlier one.
+// Users with an authenticator app are told to use it instead.
+func (tfs *TwoFactorService) SendCode(ctx context.Context, user *database.User, purpose string) error {
+	totp, err := tfs.db.GetUserTOTP(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if totp != nil && totp.Enabled {
+		return ErrUseAuthenticator
+	}
+
+	active, err := tfs.db.GetActiveOTPCode(ctx, user.ID, purpose)
+	if err != nil {
+		return err
+	}
+	if active != nil && time.Since(active.CreatedAt) < otpResendInterval {
+		return ErrOTPTooSoon
+	}
+
+	code, err := crypto.GenerateOTP()
+	if err != nil {
+		return err
+	}
+	record := &database.OTPCode{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		CodeHash:  tfs.hashCode(user.ID, purpose, code),
+		ExpiresAt: time.Now().Add(otpLifetime),
+	}
+	if err := tfs.db.CreateOTPCode(ctx, record); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Your %s verification code is %s.\n\nIt expires in %d minutes. If you did not request it, change your password.",
+		purpose, code, int(otpLifetime.Minutes()))
+	if err := tfs.mailer.Send(ctx, user.Email, tfs.issuer+" verification code", body); err != nil {
+		return fmt.Errorf("failed to send verification code: %w", err)
+	}
+	return nil
+}
+
+// Verify checks a second-factor code: against the authenticator app for
+// enrolled users, otherwise against the emailed code for purpose. Each code
+// is accepted once, and emailed codes are void after otpMaxAttempts misses.
+func (tfs *TwoFactorService) Verify(ctx context.Context, user *database.User, purpose, code string) error {
+	if code == "" {
+		return ErrSecondFactor
+	}
+	if !utils.ValidateOTP(code) {
+		return ErrInvalidOTP
+	}
+
+	totp, err := tfs.db.GetUserTOTP(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if totp != nil && totp.Enabled {
+		return tfs.verifyTOTP(ctx, totp, code)
+	}
+
+	record, err := tfs.db.GetActiveOTPCode(ctx, user.ID, purpose)
+	if err != nil {
+		return err
+	}
+	if record == nil || time.Now().After(record.ExpiresAt) {
+		return ErrOTPExpired
+	}
+	if record.Attempts >= otpMaxAttempts {
+		return ErrOTPAttempts
+	}
+	if !hmac.Equal([]byte(record.CodeHash), []byte(tfs.hashCode(user.ID, purpose, code))) {
+		if err := tfs.db.RecordOTPAttempt(ctx, record.ID); err != nil {
+			return err
+		}
+		return ErrInvalidOTP
+	}
+
+	consumed, err := tfs.db.ConsumeOTPCode(ctx, record.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrOTPExpired
+	}
+	return nil
+}
+
+func (tfs *TwoFactorService) verifyTOTP(ctx context.Context, totp *database.UserTOTP, code string) error {
+	secret, err := crypto.DecryptPrivateKey(totp.EncryptedSecret, tfs.key)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+	step, ok := crypto.ValidateTOTP(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return ErrInvalidOTP
+	}
+
+	fresh, err := tfs.db.UseTOTPStep(ctx, totp.UserID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidOTP
+	}
+	return nil
+}
+
+// BeginTOTP starts an authenticator enrolment with a new secret. It takes
+// effect once ConfirmTOTP sees a code from it.
+func (tfs *TwoFactorService) BeginTOTP(ctx context.Context, user *database.User) (*TOTPEnrolment, error) {
+	secret, err := crypto.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := crypto.EncryptPrivateKey(secret, tfs.key)
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := tfs.db.SaveUserTOTPSecret(ctx, user.ID, encrypted)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, ErrTOTPEnabled
+	}
+
+	return &TOTPEnrolment{
+		Secret:          secret,
+		ProvisioningURI: crypto.TOTPProvisioningURI(tfs.issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables a started enrolment once the user shows a valid code
+func (tfs *TwoFactorService) ConfirmTOTP(ctx context.Context, user *database.User, code string) error {
+	if !utils.ValidateOTP(code) {
+		return ErrInvalidOTP
+	}
+
+	totp, err := tfs.db.GetUserTOTP(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if totp == nil {
+		return ErrTOTPNotEnrolled
+	}
+	if totp.Enabled {
+		return ErrTOTPEnabled
+	}
+
+	secret, err := crypto.DecryptPrivateKey(totp.EncryptedSecret, tfs.key)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+	step, ok := crypto.ValidateTOTP(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return ErrInvalidOTP
+	}
+	if _, err := tfs.db.EnableUserTOTP(ctx, user.ID, step); err != nil {
+		return err
+	}
+
+	_ = tfs.audit.Record(ctx, AuditEvent{
+		Type:          database.LogTwoFactorEnabled,
+		Message:       fmt.Sprintf("User %s enabled an authenticator app", user.ID),
+		ActorID:       user.ID,
+		WalletAddress: user.WalletID,
+		Before:        map[string]bool{"totp_enabled": false},
+		After:         map[string]bool{"totp_enabled": true},
+	})
+	return nil
+}
+
+// DisableTOTP removes the user's authenticator app after checking a current
+// code from it
+func (tfs *TwoFactorService) DisableTOTP(ctx context.Context, user *database.User, code string) error {
+	totp, err := tfs.db.GetUserTOTP(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if totp == nil || !totp.Enabled {
+		return ErrTOTPNotEnrolled
+	}
+	if !utils.ValidateOTP(code) {
+		return ErrInvalidOTP
+	}
+	if err := tfs.verifyTOTP(ctx, totp, code); err != nil {
+		return err
+	}
+	if err := tfs.db.DeleteUserTOTP(ctx, user.ID); err != nil {
+		return err
+	}
+
+	_ = tfs.audit.Record(ctx, AuditEvent{
+		Type:          database.LogTwoFactorDisabled,
+		Message:       fmt.Sprintf("User %s disabled their authenticator app", user.ID),
+		ActorID:       user.ID,
+		WalletAddress: user.WalletID,
+		Before:        map[string]bool{"totp_enabled": true},
+		After:         map[string]bool{"totp_enabled": false},
+	})
+	return nil
+}
+
+// hashCode keys a code's hash to the user and purpose, so a leaked table
+// row cannot be matched without the server key
+func (tfs *TwoFactorService) hashCode(userID, purpose, code string) string {
+	mac := hmac.New(sha256.New, []byte(tfs.key))
+	mac.Write([]byte(userID + ":" + purpose + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -2,11 +2,13 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"time"
+
 	"crypto-wallet-backend/internal/blockchain"
 	"crypto-wallet-backend/internal/crypto"
 	"crypto-wallet-backend/internal/database"
-	"fmt"
-	"time"
 )
 
 // WalletService handles wallet operations
@@ -28,30 +30,41 @@ func (ws *WalletService) CreateWallet(ctx context.Context, userID, email string)
 		return nil, "", err
 	}
 
+	wallet, err := ws.CreateWalletForAddress(ctx, userID, keyPair.WalletID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return wallet, keyPair.PublicKey, nil
+}
+
+// CreateWalletForAddress creates a wallet for an address derived elsewhere,
+// such as from a public key generated on the client
+func (ws *WalletService) CreateWalletForAddress(ctx context.Context, userID, walletAddress string) (*database.Wallet, error) {
 	// Create wallet in database with default balance cache
 	wallet := &database.Wallet{
 		UserID:        userID,
-		WalletAddress: keyPair.WalletID,
+		WalletAddress: walletAddress,
 		BalanceCache:  200.0,
 	}
 
 	if err := ws.db.CreateWallet(ctx, wallet); err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
 	// Create initial UTXO so the balance is spendable
-	initTxHash := fmt.Sprintf("init-%s-%d", keyPair.WalletID, time.Now().Unix())
+	initTxHash := fmt.Sprintf("init-%s-%d", walletAddress, time.Now().Unix())
 	initUTXO := &database.UTXO{
 		TransactionHash: initTxHash,
 		OutputIndex:     0,
-		WalletAddress:   keyPair.WalletID,
+		WalletAddress:   walletAddress,
 		Amount:          200.0,
 		IsSpent:         false,
 	}
 
 	if err := ws.db.CreateUTXO(ctx, initUTXO); err == nil {
 		// also add to in-memory blockchain UTXO set
-		ws.bc.AddUTXO(keyPair.WalletID, blockchain.UTXO{
+		ws.bc.AddUTXO(walletAddress, blockchain.UTXO{
 			TransactionHash: initUTXO.TransactionHash,
 			OutputIndex:     initUTXO.OutputIndex,
 			WalletAddress:   initUTXO.WalletAddress,
@@ -61,13 +74,7 @@ func (ws *WalletService) CreateWallet(ctx context.Context, userID, email string)
 		})
 	}
 
-	// Store keys (private key should be encrypted)
-	user, err := ws.db.GetUserByWalletID(ctx, keyPair.WalletID)
-	if err != nil || user == nil {
-		// Update user with wallet info (already done during user creation)
-	}
-
-	return wallet, keyPair.PublicKey, nil
+	return wallet, nil
 }
 
 // GetWalletBalance calculates the balance from UTXOs
@@ -88,6 +95,76 @@ func (ws *WalletService) GetWalletBalance(ctx context.Context, walletAddress str
 	return balance, nil
 }
 
+// Balance reconciliation outcomes
+const (
+	ReconcileUpdated     = "updated"
+	ReconcileWouldUpdate = "would_update"
+	ReconcileSkipped     = "skipped"
+)
+
+// ReconcileResult summarises a balance-cache reconciliation. Only wallets
+// whose cache was (or, in a dry run, would be) changed or that were skipped
+// are listed.
+type ReconcileResult struct {
+	DryRun  bool                   `json:"dry_run"`
+	Checked int                    `json:"checked"`
+	InSync  int                    `json:"in_sync"`
+	Updated int                    `json:"updated"`
+	Skipped int                    `json:"skipped"`
+	Wallets []*database.JobRunItem `json:"wallets"`
+}
+
+// ReconcileBalances resets each wallet's balance_cache to the sum of its
+// unspent outputs. Wallets without any outputs are skipped, since their cache
+// is the only record of their balance. With dryRun nothing is written.
+func (ws *WalletService) ReconcileBalances(ctx context.Context, dryRun bool) (*ReconcileResult, error) {
+	balances, err := ws.db.GetWalletUTXOBalances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReconcileResult{DryRun: dryRun, Wallets: []*database.JobRunItem{}}
+	for _, b := range balances {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		result.Checked++
+
+		if b.UTXOCount == 0 {
+			result.Skipped++
+			result.Wallets = append(result.Wallets, &database.JobRunItem{
+				Subject: b.WalletAddress,
+				Outcome: ReconcileSkipped,
+				Detail:  "no outputs; the cached balance is the only record",
+			})
+			continue
+		}
+
+		diff := b.UTXOBalance - b.BalanceCache
+		if math.Abs(diff) < 1e-8 {
+			result.InSync++
+			continue
+		}
+
+		item := &database.JobRunItem{
+			Subject: b.WalletAddress,
+			Outcome: ReconcileWouldUpdate,
+			Amount:  diff,
+			Detail:  fmt.Sprintf("balance_cache %.8f -> %.8f", b.BalanceCache, b.UTXOBalance),
+		}
+		if !dryRun {
+			if err := ws.db.UpdateWalletBalance(ctx, b.WalletAddress, b.UTXOBalance); err != nil {
+				return result, err
+			}
+			item.Outcome = ReconcileUpdated
+		}
+		result.Updated++
+		result.Wallets = append(result.Wallets, item)
+	}
+
+	return result, nil
+}
+
 // ValidateTransaction validates a transaction before adding it to the blockchain
 func (ws *WalletService) ValidateTransaction(ctx context.Context, tx *blockchain.Transaction) error {
 	// Check wallet exists
@@ -102,8 +179,7 @@ func (ws *WalletService) ValidateTransaction(ctx context.Context, tx *blockchain
 	}
 
 	// Verify signature
-	dataToSign := tx.SenderWallet + tx.ReceiverWallet + fmt.Sprintf("%.8f", tx.Amount)
-	isValid, err := crypto.VerifySignature(dataToSign, tx.Signature, tx.PublicKey)
+	isValid, err := crypto.VerifySignature(tx.SigningData(), tx.Signature, tx.PublicKey)
 	if err != nil || !isValid {
 		return fmt.Errorf("invalid digital signature")
 	}
@@ -2,66 +2,458 @@ package services
 
 import (
 	"context"
-	"crypto-wallet-backend/internal/database"
 	"fmt"
+	"log/slog"
+	"math"
 	"time"
+
+	"crypto-wallet-backend/internal/blockchain"
+	"crypto-wallet-backend/internal/crypto"
+	"crypto-wallet-backend/internal/database"
+	"crypto-wallet-backend/internal/utils"
 )
 
 // ZakatService handles zakat operations
 type ZakatService struct {
-	db *database.Database
+	db                 *database.Database
+	bc                 *blockchain.Blockchain
+	transactionService *TransactionService
+	audit              *AuditService
+	logger             *slog.Logger
+	nisab              *NisabProvider
+	poolWallet         string
+	percentage         float64
+
+	// systemKey signs zakat transactions on behalf of the system
+	systemKey       string
+	systemPublicKey string
+}
+
+// zakatReservationTimeout is how long a reservation may stay unlinked before
+// a later run treats its deduction as interrupted
+const zakatReservationTimeout = 10 * time.Minute
+
+// Per-wallet outcomes of a zakat run
+const (
+	ZakatOutcomeDeducted    = "deducted"
+	ZakatOutcomeSkipped     = "skipped"
+	ZakatOutcomeFailed      = "failed"
+	ZakatOutcomeWouldDeduct = "would_deduct"
+)
+
+// ZakatRunResult summarises one monthly zakat run. In a dry run nothing is
+// deducted and wallets that are due are reported as would_deduct.
+type ZakatRunResult struct {
+	MonthYear   string                 `json:"month_year"`
+	DryRun      bool                   `json:"dry_run"`
+	Deducted    int                    `json:"deducted"`
+	WouldDeduct int                    `json:"would_deduct,omitempty"`
+	Skipped     int                    `json:"skipped"`
+	Failed      int                    `json:"failed"`
+	Total       float64                `json:"total"`
+	Wallets     []*database.JobRunItem `json:"wallets"`
+}
+
+// ZakatAssessment explains whether zakat is due on a wallet and why
+type ZakatAssessment struct {
+	WalletAddress       string     `json:"wallet_address"`
+	Balance             float64    `json:"balance"`
+	Nisab               *Nisab     `json:"nisab"`
+	HawlStart           *time.Time `json:"hawl_start,omitempty"`
+	HawlStartHijri      string     `json:"hawl_start_hijri,omitempty"`
+	HawlEnd             *time.Time `json:"hawl_end,omitempty"`
+	HawlEndHijri        string     `json:"hawl_end_hijri,omitempty"`
+	OptedIn             bool       `json:"opted_in"`
+	Exempt              bool       `json:"exempt"`
+	DeclaredAssets      float64    `json:"declared_assets"`
+	DeclaredLiabilities float64    `json:"declared_liabilities"`
+	ZakatableAmount     float64    `json:"zakatable_amount"`
+	Due                 bool       `json:"due"`
+	Amount              float64    `json:"amount"`
+	Percentage          float64    `json:"percentage"`
+	SadaqahPercentage   float64    `json:"sadaqah_percentage"`
+	SadaqahAmount       float64    `json:"sadaqah_amount"`
+	TotalDeduction      float64    `json:"total_deduction"`
+	Reason              string     `json:"reason"`
+	AssessedAt          time.Time  `json:"assessed_at"`
+}
+
+// NewZakatService creates a new zakat service. systemKey is a base64 PKCS1
+// RSA private key used to sign zakat transactions; when empty an ephemeral
+// key is generated, which is only suitable for development.
+func NewZakatService(db *database.Database, bc *blockchain.Blockchain, transactionService *TransactionService, nisab *NisabProvider, poolWallet string, percentage float64, systemKey string, logger *slog.Logger) (*ZakatService, error) {
+	var publicKey string
+	if systemKey == "" {
+		keyPair, err := crypto.GenerateKeyPair()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate zakat signing key: %w", err)
+		}
+		systemKey, publicKey = keyPair.PrivateKey, keyPair.PublicKey
+	} else {
+		var err error
+		if publicKey, err = crypto.PublicKeyFromPrivateKey(systemKey); err != nil {
+			return nil, fmt.Errorf("invalid zakat signing key: %w", err)
+		}
+	}
+
+	return &ZakatService{
+		db:                 db,
+		bc:                 bc,
+		transactionService: transactionService,
+		audit:              NewAuditService(db),
+		logger:             logger,
+		nisab:              nisab,
+		poolWallet:         poolWallet,
+		percentage:         percentage,
+		systemKey:          systemKey,
+		systemPublicKey:    publicKey,
+	}, nil
+}
+
+// SystemPublicKey returns the key that verifies zakat transaction signatures
+func (zs *ZakatService) SystemPublicKey() string {
+	return zs.systemPublicKey
+}
+
+// zakatNote is the note carried by a wallet's zakat transfer for a month. It
+// lets an interrupted run find transfers that were made but not yet linked.
+func zakatNote(monthYear string) string {
+	return "Zakat " + monthYear
+}
+
+// Assess works out whether zakat is due on the wallet at now: its owner must
+// be opted in and not exempt, and its balance must have stayed at or above
+// the nisab for one full lunar year since it last rose to the nisab or zakat
+// was last paid. The hawl start is derived from the wallet's UTXO history and
+// recorded for tracking. Once the hawl completes, declared assets and
+// liabilities adjust the zakatable amount.
+func (zs *ZakatService) Assess(ctx context.Context, walletAddress string, now time.Time) (*ZakatAssessment, error) {
+	nisab, err := zs.nisab.Current()
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := zs.db.GetWalletByAddress(ctx, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil {
+		return nil, blockchain.ErrInvalidWallet
+	}
+	settings, err := zs.db.GetZakatSettings(ctx, wallet.UserID)
+	if err != nil {
+		return nil, err
+	}
+	exemption, err := zs.db.GetZakatExemption(ctx, wallet.UserID)
+	if err != nil {
+		return nil, err
+	}
+	declarations, err := zs.db.GetZakatDeclarationsByWallet(ctx, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := zs.db.GetWalletBalanceHistory(ctx, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+	hawl, err := zs.db.GetZakatHawl(ctx, walletAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replay the history, applying all changes sharing a timestamp together
+	// so a transfer's inputs and change never register as a dip
+	balance := 0.0
+	var aboveSince *time.Time
+	for i := 0; i < len(history) && !history[i].At.After(now); {
+		at := history[i].At
+		for ; i < len(history) && history[i].At.Equal(at); i++ {
+			balance += history[i].Amount
+		}
+		if balance > 0 && balance >= nisab.Amount {
+			if aboveSince == nil {
+				aboveSince = &at
+			}
+		} else {
+			aboveSince = nil
+		}
+	}
+	balance = math.Round(balance*1e8) / 1e8
+
+	assessment := &ZakatAssessment{
+		WalletAddress: walletAddress,
+		Balance:       balance,
+		Nisab:         nisab,
+		Percentage:    zs.percentage,
+		OptedIn:       settings.OptedIn,
+		Exempt:        exemption != nil,
+		AssessedAt:    now,
+	}
+	for _, d := range declarations {
+		if d.Kind == database.DeclarationLiability {
+			assessment.DeclaredLiabilities += d.Amount
+		} else {
+			assessment.DeclaredAssets += d.Amount
+		}
+	}
+	assessment.ZakatableAmount = math.Max(0, balance+assessment.DeclaredAssets-assessment.DeclaredLiabilities)
+
+	hawlStart := aboveSince
+	if hawlStart != nil && hawl != nil && hawl.LastPaidAt != nil && hawlStart.Before(*hawl.LastPaidAt) {
+		hawlStart = hawl.LastPaidAt
+	}
+	if hawl == nil || !sameTime(hawl.HawlStart, hawlStart) {
+		if err := zs.db.SetZakatHawlStart(ctx, walletAddress, hawlStart); err != nil {
+			return nil, err
+		}
+	}
+
+	// Transfers from frozen and closed wallets are refused, zakat included;
+	// their hawl is still tracked so deduction resumes once a hold is lifted
+	switch {
+	case wallet.Status == database.WalletFrozen:
+		assessment.Reason = "Not deducted: the wallet is frozen: " + wallet.StatusReason
+		return assessment, nil
+	case wallet.Status == database.WalletClosed:
+		assessment.Reason = "Not deducted: the wallet is closed"
+		return assessment, nil
+	case exemption != nil:
+		assessment.Reason = "Not due: exempted by an administrator: " + exemption.Reason
+		return assessment, nil
+	case !settings.OptedIn:
+		assessment.Reason = "Not due: the owner has opted out of zakat deduction"
+		return assessment, nil
+	case hawlStart == nil:
+		assessment.Reason = fmt.Sprintf("Not due: balance %.8f is below the nisab of %.8f", balance, nisab.Amount)
+		return assessment, nil
+	}
+
+	hawlEnd := utils.AddHijriYears(*hawlStart, 1)
+	assessment.HawlStart = hawlStart
+	assessment.HawlStartHijri = utils.ToHijri(*hawlStart).String()
+	assessment.HawlEnd = &hawlEnd
+	assessment.HawlEndHijri = utils.ToHijri(hawlEnd).String()
+
+	if now.Before(hawlEnd) {
+		assessment.Reason = fmt.Sprintf("Not due: balance has been at or above the nisab since %s (%s); a full lunar year completes on %s (%s)",
+			assessment.HawlStartHijri, hawlStart.Format("2006-01-02"), assessment.HawlEndHijri, hawlEnd.Format("2006-01-02"))
+		return assessment, nil
+	}
+
+	if assessment.ZakatableAmount < nisab.Amount {
+		assessment.Reason = fmt.Sprintf("Not due: declared liabilities bring the zakatable amount to %.8f, below the nisab of %.8f",
+			assessment.ZakatableAmount, nisab.Amount)
+		return assessment, nil
+	}
+
+	// Amounts truncated to the ledger's 8 decimal places. The deduction is
+	// spent from the wallet, so it cannot exceed the on-chain balance even
+	// when declared assets raise the zakatable amount above it.
+	assessment.Due = true
+	assessment.SadaqahPercentage = settings.SadaqahPercentage
+	assessment.Amount = math.Min(truncate8(assessment.ZakatableAmount*zs.percentage/100), balance)
+	assessment.SadaqahAmount = math.Min(truncate8(assessment.ZakatableAmount*settings.SadaqahPercentage/100), truncate8(balance-assessment.Amount))
+	assessment.TotalDeduction = assessment.Amount + assessment.SadaqahAmount
+	assessment.Reason = fmt.Sprintf("Due: balance stayed at or above the nisab of %.8f for a full lunar year, from %s to %s",
+		nisab.Amount, assessment.HawlStartHijri, assessment.HawlEndHijri)
+	return assessment, nil
+}
+
+// truncate8 truncates an amount to 8 decimal places
+func truncate8(amount float64) float64 {
+	return math.Floor(amount*1e8) / 1e8
 }
 
-// NewZakatService creates a new zakat service
-func NewZakatService(db *database.Database) *ZakatService {
-	return &ZakatService{db: db}
+// sameTime reports whether two optional times are equal
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
 }
 
-// DeductZakat deducts zakat from a wallet
-func (zs *ZakatService) DeductZakat(ctx context.Context, walletAddress string, balance float64, zakatPercentage float64) error {
-	// Calculate zakat amount
-	zakatAmount := balance * (zakatPercentage / 100)
+// DeductZakat spends the wallet's zakat for monthYear to the pool wallet as a
+// signed system transaction when Assess finds it due. The zakat record is nil
+// when the month was already deducted or nothing is due; the assessment
+// explains why.
+func (zs *ZakatService) DeductZakat(ctx context.Context, walletAddress string, monthYear string) (*database.ZakatTransaction, *ZakatAssessment, error) {
+	assessment, err := zs.Assess(ctx, walletAddress, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	if !assessment.Due || assessment.Amount <= 0 {
+		return nil, assessment, nil
+	}
+	zakatAmount := assessment.TotalDeduction
 
-	// Create zakat transaction
+	// Reserve the month first so a concurrent or repeated run cannot deduct twice
 	zakatTx := &database.ZakatTransaction{
 		WalletAddress:   walletAddress,
-		Amount:          zakatAmount,
-		ZakatPercentage: zakatPercentage,
-		MonthYear:       time.Now().Format("2006-01"),
+		Amount:          assessment.Amount,
+		ZakatPercentage: zs.percentage,
+		SadaqahAmount:   assessment.SadaqahAmount,
+		MonthYear:       monthYear,
+	}
+	reserved, err := zs.db.ReserveZakatTransaction(ctx, zakatTx)
+	if err != nil {
+		return nil, assessment, err
+	}
+	if !reserved {
+		assessment.Reason = "Already deducted for " + monthYear
+		return nil, assessment, nil
 	}
 
-	if err := zs.db.CreateZakatTransaction(ctx, zakatTx); err != nil {
-		return err
+	txHash, err := zs.transfer(ctx, walletAddress, zakatAmount, monthYear)
+	if err != nil {
+		if rerr := zs.db.ReleaseZakatTransaction(ctx, zakatTx.ID); rerr != nil {
+			return nil, assessment, fmt.Errorf("%v; releasing reservation: %w", err, rerr)
+		}
+		return nil, assessment, err
 	}
 
-	// Mark month as processed
-	wallet, err := zs.db.GetWalletByAddress(ctx, walletAddress)
+	if err := zs.db.LinkZakatTransaction(ctx, zakatTx.ID, walletAddress, txHash); err != nil {
+		return nil, assessment, err
+	}
+	zakatTx.TransactionHash = txHash
+
+	// The next hawl starts where this one ended
+	if err := zs.db.SetZakatHawlPaid(ctx, walletAddress, *assessment.HawlEnd); err != nil {
+		return nil, assessment, err
+	}
+
+	_ = zs.audit.Record(ctx, AuditEvent{
+		Type:          database.LogZakatDeducted,
+		Message:       fmt.Sprintf("Zakat %s: %.8f (sadaqah %.8f) deducted from %s in transaction %s", monthYear, assessment.Amount, assessment.SadaqahAmount, walletAddress, txHash),
+		WalletAddress: walletAddress,
+		After: map[string]interface{}{
+			"month_year":       monthYear,
+			"zakat_amount":     assessment.Amount,
+			"sadaqah_amount":   assessment.SadaqahAmount,
+			"transaction_hash": txHash,
+			"hawl_end":         assessment.HawlEnd,
+		},
+	})
+
+	return zakatTx, assessment, nil
+}
+
+// transfer builds, signs and submits the zakat transaction to the pool wallet
+func (zs *ZakatService) transfer(ctx context.Context, walletAddress string, amount float64, monthYear string) (string, error) {
+	tx := blockchain.NewTransaction(walletAddress, zs.poolWallet, amount, 0, zakatNote(monthYear))
+	signature, err := crypto.SignTransaction(tx.SigningData(), zs.systemKey)
 	if err != nil {
-		return err
+		return "", fmt.Errorf("failed to sign zakat transaction: %w", err)
 	}
 
-	if wallet != nil {
-		// Update wallet to mark zakat as deducted
-		newBalance := balance - zakatAmount
-		if err := zs.db.UpdateWalletBalance(ctx, walletAddress, newBalance); err != nil {
-			return err
+	return zs.transactionService.CreateTransaction(ctx, database.Transaction{
+		SenderWallet:    tx.SenderWallet,
+		ReceiverWallet:  tx.ReceiverWallet,
+		Amount:          tx.Amount,
+		Note:            tx.Note,
+		Signature:       signature,
+		TransactionType: "zakat",
+	})
+}
+
+// ProcessMonthlyZakat deducts zakat for monthYear (YYYY-MM) from every wallet
+// whose hawl has completed and that was not yet deducted that month. Running
+// it again for the same month only picks up wallets that were skipped or
+// failed. With dryRun, wallets are only assessed and nothing is changed
+// beyond the hawl tracking that assessment keeps up to date.
+func (zs *ZakatService) ProcessMonthlyZakat(ctx context.Context, monthYear string, dryRun bool) (*ZakatRunResult, error) {
+	if _, err := time.Parse("2006-01", monthYear); err != nil {
+		return nil, fmt.Errorf("invalid month %q: expected YYYY-MM", monthYear)
+	}
+
+	result := &ZakatRunResult{MonthYear: monthYear, DryRun: dryRun, Wallets: []*database.JobRunItem{}}
+
+	if !dryRun {
+		if err := zs.db.ResetZakatDeductedFlags(ctx, monthYear); err != nil {
+			return nil, err
+		}
+		if err := zs.recoverReservations(ctx, monthYear); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	wallets, err := zs.db.GetWalletsPendingZakat(ctx, monthYear, zs.poolWallet)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, wallet := range wallets {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		item := &database.JobRunItem{Subject: wallet.WalletAddress}
+		result.Wallets = append(result.Wallets, item)
+
+		if dryRun {
+			assessment, err := zs.Assess(ctx, wallet.WalletAddress, time.Now())
+			switch {
+			case err != nil:
+				result.Failed++
+				item.Outcome, item.Detail = ZakatOutcomeFailed, err.Error()
+			case !assessment.Due || assessment.Amount <= 0:
+				result.Skipped++
+				item.Outcome, item.Detail = ZakatOutcomeSkipped, assessment.Reason
+			default:
+				result.WouldDeduct++
+				result.Total += assessment.TotalDeduction
+				item.Outcome, item.Amount, item.Detail = ZakatOutcomeWouldDeduct, assessment.TotalDeduction, assessment.Reason
+			}
+			continue
+		}
+
+		zt, assessment, err := zs.DeductZakat(ctx, wallet.WalletAddress, monthYear)
+		switch {
+		case err != nil:
+			result.Failed++
+			item.Outcome, item.Detail = ZakatOutcomeFailed, err.Error()
+			zs.logger.WarnContext(ctx, "Zakat deduction failed", "wallet", wallet.WalletAddress, "month", monthYear, "error", err)
+		case zt == nil:
+			result.Skipped++
+			item.Outcome, item.Detail = ZakatOutcomeSkipped, assessment.Reason
+		default:
+			result.Deducted++
+			result.Total += assessment.TotalDeduction
+			item.Outcome, item.Amount, item.TransactionHash = ZakatOutcomeDeducted, assessment.TotalDeduction, zt.TransactionHash
+		}
+	}
+
+	result.Total = truncate8(result.Total)
+	return result, nil
 }
 
-// ProcessMonthlyZakat processes zakat for all wallets
-func (zs *ZakatService) ProcessMonthlyZakat(ctx context.Context) error {
-	// Get all wallets (you would implement a method in database to get all wallets)
-	// For now, this is a placeholder
-	fmt.Println("Processing monthly zakat deductions...")
+// recoverReservations links reservations left behind by an interrupted run,
+// or releases them if their transfer never happened. Recent ones may belong
+// to a run still in progress and are left alone.
+func (zs *ZakatService) recoverReservations(ctx context.Context, monthYear string) error {
+	unlinked, err := zs.db.GetUnlinkedZakatTransactions(ctx, monthYear, time.Now().Add(-zakatReservationTimeout))
+	if err != nil {
+		return err
+	}
+	for _, zt := range unlinked {
+		txHash, err := zs.db.FindZakatTransferHash(ctx, zt.WalletAddress, zakatNote(monthYear))
+		if err != nil {
+			return err
+		}
+		if txHash != "" {
+			err = zs.db.LinkZakatTransaction(ctx, zt.ID, zt.WalletAddress, txHash)
+		} else {
+			err = zs.db.ReleaseZakatTransaction(ctx, zt.ID)
+		}
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// GetZakatReports returns zakat reports for a wallet
-func (zs *ZakatService) GetZakatReports(ctx context.Context, walletAddress string) ([]*database.ZakatTransaction, error) {
-	// Query database for zakat transactions
-	// This would require adding a method to the database service
-	return []*database.ZakatTransaction{}, nil
+// GetZakatReports returns a wallet's completed zakat deductions, newest
+// first, optionally limited to one year (YYYY)
+func (zs *ZakatService) GetZakatReports(ctx context.Context, walletAddress string, year string) ([]*database.ZakatTransaction, error) {
+	return zs.db.GetZakatTransactionsByWallet(ctx, walletAddress, year)
 }
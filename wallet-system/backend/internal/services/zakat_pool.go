@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"crypto-wallet-backend/internal/blockchain"
+	"crypto-wallet-backend/internal/crypto"
+	"crypto-wallet-backend/internal/database"
+	"crypto-wallet-backend/internal/utils"
+)
+
+// poolDisbursementLimit caps the disbursements listed in a pool report
+const poolDisbursementLimit = 100
+
+// ZakatReceipt documents one completed zakat deduction
+type ZakatReceipt struct {
+	ReceiptNumber   string    `json:"receipt_number"`
+	WalletAddress   string    `json:"wallet_address"`
+	MonthYear       string    `json:"month_year"`
+	Amount          float64   `json:"amount"`
+	Percentage      float64   `json:"percentage"`
+	SadaqahAmount   float64   `json:"sadaqah_amount"`
+	TransactionHash string    `json:"transaction_hash"`
+	TransactionDate time.Time `json:"transaction_date"`
+	HijriDate       string    `json:"hijri_date"`
+	BlockHash       string    `json:"block_hash,omitempty"`
+	PoolWallet      string    `json:"pool_wallet"`
+	Signature       string    `json:"signature"`
+	SignerKeyHash   string    `json:"signer_key_hash"`
+	IssuedAt        time.Time `json:"issued_at"`
+}
+
+// Text renders the receipt as a plain-text document
+func (r *ZakatReceipt) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ZAKAT RECEIPT %s\n\n", r.ReceiptNumber)
+	fmt.Fprintf(&b, "Wallet:           %s\n", r.WalletAddress)
+	fmt.Fprintf(&b, "Period:           %s\n", r.MonthYear)
+	fmt.Fprintf(&b, "Amount:           %.8f (%.2f%%)\n", r.Amount, r.Percentage)
+	if r.SadaqahAmount > 0 {
+		fmt.Fprintf(&b, "Sadaqah:          %.8f\n", r.SadaqahAmount)
+	}
+	fmt.Fprintf(&b, "Date:             %s (%s)\n", r.TransactionDate.UTC().Format("2006-01-02 15:04 MST"), r.HijriDate)
+	fmt.Fprintf(&b, "Paid to pool:     %s\n", r.PoolWallet)
+	fmt.Fprintf(&b, "Transaction:      %s\n", r.TransactionHash)
+	if r.BlockHash != "" {
+		fmt.Fprintf(&b, "Block:            %s\n", r.BlockHash)
+	} else {
+		fmt.Fprintf(&b, "Block:            not yet mined\n")
+	}
+	fmt.Fprintf(&b, "Signer key hash:  %s\n", r.SignerKeyHash)
+	fmt.Fprintf(&b, "Signature:        %s\n\n", r.Signature)
+	fmt.Fprintf(&b, "Issued %s\n", r.IssuedAt.UTC().Format(time.RFC3339))
+	return b.String()
+}
+
+// ZakatPoolReport shows the zakat pool wallet's inflows, disbursements and
+// balance
+type ZakatPoolReport struct {
+	PoolWallet     string                        `json:"pool_wallet"`
+	Balance        float64                       `json:"balance"`
+	TotalInflow    float64                       `json:"total_inflow"`
+	TotalDisbursed float64                       `json:"total_disbursed"`
+	MonthlyInflows []database.ZakatMonthTotal    `json:"monthly_inflows"`
+	Disbursements  []*database.ZakatDisbursement `json:"disbursements"`
+	Recipients     []*database.ZakatRecipient    `json:"recipients"`
+}
+
+// GetReceipt builds the receipt for a completed zakat deduction
+func (zs *ZakatService) GetReceipt(ctx context.Context, id string) (*ZakatReceipt, error) {
+	zt, err := zs.db.GetZakatTransactionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if zt == nil || zt.TransactionHash == "" {
+		return nil, ErrZakatNotFound
+	}
+
+	tx, err := zs.db.GetTransactionByHash(ctx, zt.TransactionHash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, ErrZakatNotFound
+	}
+
+	receipt := &ZakatReceipt{
+		ReceiptNumber:   fmt.Sprintf("ZKT-%s-%s", strings.ReplaceAll(zt.MonthYear, "-", ""), strings.ToUpper(zt.ID[:8])),
+		WalletAddress:   zt.WalletAddress,
+		MonthYear:       zt.MonthYear,
+		Amount:          zt.Amount,
+		Percentage:      zt.ZakatPercentage,
+		SadaqahAmount:   zt.SadaqahAmount,
+		TransactionHash: tx.TransactionHash,
+		TransactionDate: tx.CreatedAt,
+		HijriDate:       utils.ToHijri(tx.CreatedAt).String(),
+		PoolWallet:      tx.ReceiverWallet,
+		Signature:       tx.Signature,
+		SignerKeyHash:   crypto.SHA256Hash(zs.systemPublicKey),
+		IssuedAt:        time.Now(),
+	}
+	if tx.BlockHash != nil {
+		receipt.BlockHash = *tx.BlockHash
+	}
+
+	return receipt, nil
+}
+
+// PoolReport summarises the zakat pool wallet
+func (zs *ZakatService) PoolReport(ctx context.Context) (*ZakatPoolReport, error) {
+	report := &ZakatPoolReport{PoolWallet: zs.poolWallet}
+
+	utxos, err := zs.db.GetUTXOsByWallet(ctx, zs.poolWallet)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range utxos {
+		if !u.IsSpent {
+			report.Balance += u.Amount
+		}
+	}
+
+	if report.MonthlyInflows, err = zs.db.GetZakatMonthTotals(ctx); err != nil {
+		return nil, err
+	}
+	for _, month := range report.MonthlyInflows {
+		report.TotalInflow += month.Amount
+	}
+
+	if report.TotalDisbursed, err = zs.db.GetZakatDisbursedTotal(ctx, zs.poolWallet); err != nil {
+		return nil, err
+	}
+	if report.Disbursements, err = zs.db.GetZakatDisbursements(ctx, zs.poolWallet, poolDisbursementLimit); err != nil {
+		return nil, err
+	}
+	if report.Recipients, err = zs.db.GetZakatRecipients(ctx); err != nil {
+		return nil, err
+	}
+
+	if report.MonthlyInflows == nil {
+		report.MonthlyInflows = []database.ZakatMonthTotal{}
+	}
+	if report.Disbursements == nil {
+		report.Disbursements = []*database.ZakatDisbursement{}
+	}
+	if report.Recipients == nil {
+		report.Recipients = []*database.ZakatRecipient{}
+	}
+	report.Balance = math.Round(report.Balance*1e8) / 1e8
+
+	return report, nil
+}
+
+// RegisterRecipient adds a wallet that may receive pool disbursements
+func (zs *ZakatService) RegisterRecipient(ctx context.Context, name, walletAddress, description string) (*database.ZakatRecipient, error) {
+	if walletAddress == zs.poolWallet {
+		return nil, fmt.Errorf("%w: the pool wallet cannot be a recipient", blockchain.ErrInvalidWallet)
+	}
+
+	recipient := &database.ZakatRecipient{
+		Name:          name,
+		WalletAddress: walletAddress,
+		Description:   description,
+	}
+	if err := zs.db.CreateZakatRecipient(ctx, recipient); err != nil {
+		return nil, err
+	}
+
+	_ = zs.audit.Record(ctx, AuditEvent{
+		Type:          database.LogZakatRecipient,
+		Message:       fmt.Sprintf("Zakat recipient %s (%s) registered", recipient.ID, name),
+		WalletAddress: walletAddress,
+		After:         recipient,
+	})
+	return recipient, nil
+}
+
+// Disburse transfers amount from the pool wallet to a registered recipient
+// as a system-signed transaction
+func (zs *ZakatService) Disburse(ctx context.Context, recipientID string, amount float64, note string) (string, error) {
+	recipient, err := zs.db.GetZakatRecipient(ctx, recipientID)
+	if err != nil {
+		return "", err
+	}
+	if recipient == nil {
+		return "", ErrRecipientNotFound
+	}
+	if !recipient.IsActive {
+		return "", ErrRecipientInactive
+	}
+
+	tx := blockchain.NewTransaction(zs.poolWallet, recipient.WalletAddress, amount, 0, note)
+	signature, err := crypto.SignTransaction(tx.SigningData(), zs.systemKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign disbursement: %w", err)
+	}
+
+	txHash, err := zs.transactionService.CreateTransaction(ctx, database.Transaction{
+		SenderWallet:    tx.SenderWallet,
+		ReceiverWallet:  tx.ReceiverWallet,
+		Amount:          tx.Amount,
+		Note:            tx.Note,
+		Signature:       signature,
+		TransactionType: "zakat_disbursement",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	_ = zs.audit.Record(ctx, AuditEvent{
+		Type:          database.LogZakatDisbursed,
+		Message:       fmt.Sprintf("Disbursed %.8f from the zakat pool to recipient %s in transaction %s", amount, recipient.ID, txHash),
+		WalletAddress: recipient.WalletAddress,
+		After: map[string]interface{}{
+			"recipient_id":     recipient.ID,
+			"amount":           amount,
+			"note":             note,
+			"transaction_hash": txHash,
+		},
+	})
+	return txHash, nil
+}
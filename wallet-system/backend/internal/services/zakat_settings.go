@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"crypto-wallet-backend/internal/database"
+)
+
+// UpdateSettings saves a user's zakat opt-in and voluntary sadaqah percentage
+func (zs *ZakatService) UpdateSettings(ctx context.Context, userID string, optedIn bool, sadaqahPercentage float64) (*database.ZakatSettings, error) {
+	if sadaqahPercentage < 0 || sadaqahPercentage > 100 {
+		return nil, ErrInvalidSadaqah
+	}
+
+	settings := &database.ZakatSettings{
+		UserID:            userID,
+		OptedIn:           optedIn,
+		SadaqahPercentage: sadaqahPercentage,
+	}
+	if err := zs.db.SaveZakatSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// Declare records an off-chain asset or liability against a user's wallet.
+// The caller must already have checked that the user owns the wallet.
+func (zs *ZakatService) Declare(ctx context.Context, declaration *database.ZakatDeclaration) error {
+	if declaration.Amount <= 0 ||
+		(declaration.Kind != database.DeclarationAsset && declaration.Kind != database.DeclarationLiability) {
+		return ErrInvalidDeclaration
+	}
+	return zs.db.CreateZakatDeclaration(ctx, declaration)
+}
+
+// Exempt excludes a user's wallets from zakat on an admin's authority
+func (zs *ZakatService) Exempt(ctx context.Context, userID, reason, adminID string) (*database.ZakatExemption, error) {
+	user, err := zs.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	exemption := &database.ZakatExemption{
+		UserID:    userID,
+		Reason:    reason,
+		GrantedBy: adminID,
+	}
+	if err := zs.db.SaveZakatExemption(ctx, exemption); err != nil {
+		return nil, err
+	}
+
+	_ = zs.audit.Record(ctx, AuditEvent{
+		Type:          database.LogZakatExempted,
+		Message:       fmt.Sprintf("User %s exempted from zakat by %s: %s", userID, adminID, reason),
+		ActorID:       adminID,
+		WalletAddress: user.WalletID,
+		After:         exemption,
+	})
+	return exemption, nil
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidCNIC       = errors.New("CNIC must be 13 digits in the form XXXXX-XXXXXXX-X")
+	ErrUnknownCNICRegion = errors.New("CNIC region or division code is not assigned")
+	ErrCNICGender        = errors.New("CNIC gender digit does not match the declared gender")
+)
+
+// cnicRegion is a region that issues CNICs and the number of divisions it
+// numbers from 1 in a CNIC's second digit
+type cnicRegion struct {
+	name      string
+	divisions byte
+}
+
+// cnicRegions maps the first digit of a CNIC to the region that issued it
+var cnicRegions = map[byte]cnicRegion{
+	'1': {"Khyber Pakhtunkhwa", 7},
+	'2': {"Federally Administered Tribal Areas", 7},
+	'3': {"Punjab", 8},
+	'4': {"Sindh", 5},
+	'5': {"Balochistan", 6},
+	'6': {"Islamabad", 1},
+	'7': {"Gilgit-Baltistan", 1},
+	'8': {"Azad Jammu and Kashmir", 2},
+}
+
+// CNIC genders, encoded by the parity of the last digit
+const (
+	GenderMale   = "male"
+	GenderFemale = "female"
+)
+
+// CNIC is a decoded Pakistani CNIC number
+type CNIC struct {
+	Number string `json:"number"`
+	Region string `json:"region"`
+	Gender string `json:"gender"`
+}
+
+// ParseCNIC validates a CNIC, given with or without dashes, and decodes it.
+// NADRA publishes no check-digit algorithm; the last digit encodes gender
+// (odd male, even female). What can be checked is the locality code: the
+// first digit must be an issuing region and the second one of its divisions.
+// Numbers of one repeated digit or with an all-zero serial are rejected.
+func ParseCNIC(cnic string) (*CNIC, error) {
+	digits := strings.ReplaceAll(strings.TrimSpace(cnic), "-", "")
+	if len(digits) != 13 {
+		return nil, ErrInvalidCNIC
+	}
+	if strings.Contains(cnic, "-") && !ValidateCNIC(strings.TrimSpace(cnic)) {
+		return nil, ErrInvalidCNIC
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return nil, ErrInvalidCNIC
+		}
+	}
+	if strings.Count(digits, digits[:1]) == len(digits) || digits[5:12] == "0000000" {
+		return nil, ErrInvalidCNIC
+	}
+
+	region, ok := cnicRegions[digits[0]]
+	if !ok || digits[1] < '1' || digits[1]-'0' > region.divisions {
+		return nil, ErrUnknownCNICRegion
+	}
+
+	gender := GenderFemale
+	if (digits[12]-'0')%2 == 1 {
+		gender = GenderMale
+	}
+
+	return &CNIC{
+		Number: digits[:5] + "-" + digits[5:12] + "-" + digits[12:],
+		Region: region.name,
+		Gender: gender,
+	}, nil
+}
+
+// ValidateCNICGender checks a CNIC's gender digit against a declared gender.
+// An empty declaration is not checked.
+func ValidateCNICGender(cnic *CNIC, gender string) error {
+	if gender == "" || strings.EqualFold(gender, cnic.Gender) {
+		return nil
+	}
+	return ErrCNICGender
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// Julian day numbers of 1970-01-01 and of 1 Muharram 1 AH in the civil
+// tabular Islamic calendar
+const (
+	unixEpochJDN  = 2440588
+	hijriEpochJDN = 1948440
+)
+
+var hijriMonthNames = [12]string{
+	"Muharram", "Safar", "Rabi al-Awwal", "Rabi al-Thani", "Jumada al-Ula", "Jumada al-Thani",
+	"Rajab", "Shaban", "Ramadan", "Shawwal", "Dhu al-Qadah", "Dhu al-Hijjah",
+}
+
+// HijriDate is a date in the arithmetical (tabular) Islamic calendar. It can
+// differ by a day or two from sighting-based calendars such as Umm al-Qura.
+type HijriDate struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Day   int `json:"day"`
+}
+
+// ToHijri converts the UTC calendar date of t to a Hijri date
+func ToHijri(t time.Time) HijriDate {
+	jdn := int(floorDiv(t.UTC().Unix(), 86400)) + unixEpochJDN
+
+	year := (30*(jdn-hijriEpochJDN) + 10646) / 10631
+	month := 1
+	if n := jdn - (29 + hijriToJDN(year, 1, 1)); n > 0 {
+		month = (2*n+58)/59 + 1
+	}
+	if month > 12 {
+		month = 12
+	}
+	day := jdn - hijriToJDN(year, month, 1) + 1
+
+	return HijriDate{Year: year, Month: month, Day: day}
+}
+
+// ToGregorian returns midnight UTC of the Hijri date
+func (h HijriDate) ToGregorian() time.Time {
+	days := int64(hijriToJDN(h.Year, h.Month, h.Day) - unixEpochJDN)
+	return time.Unix(days*86400, 0).UTC()
+}
+
+// String formats the date as e.g. "1 Ramadan 1444 AH"
+func (h HijriDate) String() string {
+	if h.Month < 1 || h.Month > 12 {
+		return fmt.Sprintf("%d-%02d-%02d AH", h.Year, h.Month, h.Day)
+	}
+	return fmt.Sprintf("%d %s %d AH", h.Day, hijriMonthNames[h.Month-1], h.Year)
+}
+
+// HijriMonthLength returns the number of days in a tabular Hijri month
+func HijriMonthLength(year, month int) int {
+	if month == 12 && isHijriLeapYear(year) {
+		return 30
+	}
+	if month%2 == 1 {
+		return 30
+	}
+	return 29
+}
+
+// AddHijriYears returns t moved forward by years lunar years, keeping the
+// time of day. Day 30 of a month that is shorter in the target year becomes
+// its last day.
+func AddHijriYears(t time.Time, years int) time.Time {
+	t = t.UTC()
+	h := ToHijri(t)
+	h.Year += years
+	if max := HijriMonthLength(h.Year, h.Month); h.Day > max {
+		h.Day = max
+	}
+
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return h.ToGregorian().Add(t.Sub(midnight))
+}
+
+// hijriToJDN converts a tabular Hijri date to a Julian day number
+func hijriToJDN(year, month, day int) int {
+	return day + (59*(month-1)+1)/2 + (year-1)*354 + (3+11*year)/30 + hijriEpochJDN - 1
+}
+
+// isHijriLeapYear reports whether Dhu al-Hijjah has 30 days in year
+func isHijriLeapYear(year int) bool {
+	return (14+11*year)%30 < 11
+}
+
+// floorDiv divides rounding towards negative infinity
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
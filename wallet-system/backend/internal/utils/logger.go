@@ -1,44 +1,107 @@
 package utils
 
 import (
-	"log"
-	"os"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
 )
 
-// Logger handles logging
-type Logger struct {
-	level string
+// redacted replaces the value of sensitive log attributes
+const redacted = "[REDACTED]"
+
+// sensitiveKeys name attributes whose values are never logged. A key matches
+// if it is one of these or starts or ends with one joined by an underscore,
+// such as public_key or refresh_token.
+var sensitiveKeys = []string{
+	"signature", "signatures", "key", "private_key", "secret", "password",
+	"token", "otp", "authorization", "cnic",
+}
+
+// NewLogger creates a structured logger writing records at level and above
+// (debug, info, warn or error) to w, as JSON lines or, with format "text",
+// as key=value lines. Records logged with a request context carry its
+// request ID, user and wallet, and sensitive attributes are redacted.
+func NewLogger(w io.Writer, level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLogLevel(level), ReplaceAttr: redactAttr}
+
+	var handler slog.Handler
+	if strings.EqualFold(format, "text") {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// ParseLogLevel maps a configured level name to a slog level, defaulting
+// to info
+func ParseLogLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+	return slog.LevelInfo
 }
 
-// NewLogger creates a new logger
-func NewLogger(level string) *Logger {
-	return &Logger{level: level}
+// redactAttr hides the values of sensitive attributes
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() != slog.KindGroup && isSensitiveKey(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	return a
 }
 
-// Info logs info level messages
-func (l *Logger) Info(msg string, args ...interface{}) {
-	log.Printf("[INFO] "+msg+"\n", args...)
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if key == s || strings.HasSuffix(key, "_"+s) || strings.HasPrefix(key, s+"_") {
+			return true
+		}
+	}
+	return false
 }
 
-// Error logs error level messages
-func (l *Logger) Error(msg string, args ...interface{}) {
-	log.Printf("[ERROR] "+msg+"\n", args...)
+// contextHandler adds the request details carried by a record's context,
+// unless the record already sets them
+type contextHandler struct {
+	slog.Handler
 }
 
-// Debug logs debug level messages
-func (l *Logger) Debug(msg string, args ...interface{}) {
-	if l.level == "debug" {
-		log.Printf("[DEBUG] "+msg+"\n", args...)
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	info := RequestInfoFrom(ctx)
+	fields := []slog.Attr{
+		slog.String("request_id", info.RequestID),
+		slog.String("user_id", info.ActorID),
+		slog.String("wallet", info.WalletAddress),
 	}
+
+	for _, field := range fields {
+		if field.Value.String() == "" || hasAttr(r, field.Key) {
+			continue
+		}
+		r.AddAttrs(field)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
 }
 
-// Warn logs warning level messages
-func (l *Logger) Warn(msg string, args ...interface{}) {
-	log.Printf("[WARN] "+msg+"\n", args...)
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
 }
 
-// Fatal logs fatal level messages and exits
-func (l *Logger) Fatal(msg string, args ...interface{}) {
-	log.Fatalf("[FATAL] "+msg+"\n", args...)
-	os.Exit(1)
+func hasAttr(r slog.Record, key string) bool {
+	found := false
+	r.Attrs(func(a slog.Attr) bool {
+		found = a.Key == key
+		return !found
+	})
+	return found
 }
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mailer sends plain-text email
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// SMTPMailer sends mail through an SMTP server, authenticating with PLAIN
+// auth when a username is set. net/smtp upgrades to TLS when the server
+// offers STARTTLS.
+type SMTPMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPMailer creates a mailer for the server at host:port
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{host: host, port: port, username: username, password: password, from: from}
+}
+
+// Send delivers one message. net/smtp takes no context, so ctx only stops a
+// send that has not started.
+func (m *SMTPMailer) Send(ctx context.Context, to, subject, body string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+	return smtp.SendMail(net.JoinHostPort(m.host, m.port), auth, m.from, []string{to}, formatMessage(m.from, to, subject, body))
+}
+
+// FileMailer appends messages to a file instead of sending them, or logs
+// them when no file is set. It stands in for SMTP in local development and
+// tests, where the codes it records can be read back.
+type FileMailer struct {
+	path   string
+	from   string
+	logger *slog.Logger
+	mu     sync.Mutex
+}
+
+// NewFileMailer creates a mailer that writes to path, or to logger if empty
+func NewFileMailer(path, from string, logger *slog.Logger) *FileMailer {
+	return &FileMailer{path: path, from: from, logger: logger}
+}
+
+// Send records one message
+func (m *FileMailer) Send(ctx context.Context, to, subject, body string) error {
+	if m.path == "" {
+		m.logger.InfoContext(ctx, "Mail written to log", "to", to, "subject", subject, "body", body)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(formatMessage(m.from, to, subject, body), "\r\n"...)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// formatMessage builds an RFC 5322 message. Header values are stripped of
+// line breaks so they cannot inject headers.
+func formatMessage(from, to, subject, body string) []byte {
+	clean := strings.NewReplacer("\r", "", "\n", "")
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", clean.Replace(from))
+	fmt.Fprintf(&b, "To: %s\r\n", clean.Replace(to))
+	fmt.Fprintf(&b, "Subject: %s\r\n", clean.Replace(subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,26 @@
+package utils
+
+import "context"
+
+// RequestInfo describes the request a piece of work is done for
+type RequestInfo struct {
+	RequestID     string
+	ActorID       string
+	WalletAddress string
+	IPAddress     string
+	UserAgent     string
+}
+
+type requestInfoKey struct{}
+
+// WithRequestInfo returns a context carrying info
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFrom returns the request info carried by ctx. Work not started
+// by a request, such as background jobs, has none.
+func RequestInfoFrom(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info
+}
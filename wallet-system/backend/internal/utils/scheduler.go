@@ -1,58 +1,284 @@
 package utils
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"sync"
 	"time"
 )
 
-// Scheduler manages scheduled tasks
-type Scheduler struct {
-	tasks []Task
+// Job run statuses
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// JobStore persists job runs and provides a lock shared by all instances,
+// so replicas never run the same job at once and restarts know what ran
+type JobStore interface {
+	// LastJobRun returns the latest scheduled time recorded for a job
+	LastJobRun(ctx context.Context, name string) (scheduledFor time.Time, ok bool, err error)
+	StartJobRun(ctx context.Context, name string, scheduledFor time.Time) (id string, err error)
+	FinishJobRun(ctx context.Context, id, status, errMsg string) error
+	// TryLock acquires a lock on key without waiting. release must be
+	// called once the lock is no longer needed.
+	TryLock(ctx context.Context, key string) (release func(), ok bool, err error)
+}
+
+// JobFunc runs one occurrence of a job. scheduledFor is the occurrence's
+// scheduled time, which may be in the past when a missed run is caught up.
+type JobFunc func(ctx context.Context, scheduledFor time.Time) error
+
+type jobRunIDKey struct{}
+
+// JobRunID returns the id under which the job run executing with ctx is
+// recorded, or "" when runs are not persisted
+func JobRunID(ctx context.Context) string {
+	id, _ := ctx.Value(jobRunIDKey{}).(string)
+	return id
 }
 
-// Task represents a scheduled task
-type Task struct {
+// Job is a named task run on a schedule
+type Job struct {
 	Name     string
-	Interval time.Duration
-	Function func()
-	LastRun  time.Time
+	Schedule Schedule
+	Run      JobFunc
+
+	// CatchUp runs the most recent occurrence missed while no instance was
+	// running, once, at startup
+	CatchUp bool
+
+	next    time.Time
+	running bool
+}
+
+// clock is the time source of a Scheduler, replaced in tests so schedules
+// can be driven without waiting on the wall clock
+type clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) timer
+}
+
+// timer is the part of *time.Timer the scheduler uses
+type timer interface {
+	C() <-chan time.Time
+	Reset(d time.Duration) bool
+	Stop() bool
 }
 
-// NewScheduler creates a new scheduler
-func NewScheduler() *Scheduler {
+// wallClock is the real clock
+type wallClock struct{}
+
+func (wallClock) Now() time.Time { return time.Now() }
+
+func (wallClock) NewTimer(d time.Duration) timer { return wallTimer{time.NewTimer(d)} }
+
+type wallTimer struct{ *time.Timer }
+
+func (t wallTimer) C() <-chan time.Time { return t.Timer.C }
+
+// Scheduler runs jobs on cron or interval schedules
+type Scheduler struct {
+	mu       sync.Mutex
+	jobs     []*Job
+	store    JobStore
+	location *time.Location
+	logger   *slog.Logger
+	clock    clock
+	wg       sync.WaitGroup
+
+	// heartbeat is when Run last checked for due jobs
+	heartbeat time.Time
+}
+
+// NewScheduler creates a new scheduler. Cron expressions are evaluated in
+// location unless they name their own zone. store may be nil, in which case
+// runs are neither persisted nor locked.
+func NewScheduler(store JobStore, location *time.Location, logger *slog.Logger) *Scheduler {
+	if location == nil {
+		location = time.UTC
+	}
 	return &Scheduler{
-		tasks: make([]Task, 0),
+		jobs:     make([]*Job, 0),
+		store:    store,
+		location: location,
+		logger:   logger,
+		clock:    wallClock{},
 	}
 }
 
-// AddTask adds a new task to the scheduler
-func (s *Scheduler) AddTask(name string, interval time.Duration, fn func()) {
-	s.tasks = append(s.tasks, Task{
-		Name:     name,
-		Interval: interval,
-		Function: fn,
-		LastRun:  time.Now(),
-	})
+// AddJob adds a job running on spec, a cron expression or "@every <duration>"
+// (see ParseSchedule)
+func (s *Scheduler) AddJob(name, spec string, catchUp bool, fn JobFunc) error {
+	schedule, err := ParseSchedule(spec, s.location)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, &Job{Name: name, Schedule: schedule, Run: fn, CatchUp: catchUp})
+	return nil
 }
 
-// Run starts the scheduler
-func (s *Scheduler) Run() {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
+// Run starts the scheduler and blocks until ctx is cancelled and every
+// running job has returned. Jobs receive ctx and should stop when it is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	now := s.clock.Now()
+	s.mu.Lock()
+	for _, job := range s.jobs {
+		job.next = s.firstRun(ctx, job, now)
+	}
+	s.mu.Unlock()
+
+	timer := s.clock.NewTimer(0)
+	defer timer.Stop()
 
-	for range ticker.C {
-		for i, task := range s.tasks {
-			if time.Since(task.LastRun) >= task.Interval {
-				fmt.Printf("Running scheduled task: %s\n", task.Name)
-				go task.Function()
-				s.tasks[i].LastRun = time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			s.mu.Lock()
+			s.heartbeat = time.Time{}
+			s.mu.Unlock()
+			s.wg.Wait()
+			return
+		case <-timer.C():
+		}
+
+		now := s.clock.Now()
+		wake := now.Add(time.Minute)
+
+		s.mu.Lock()
+		s.heartbeat = now
+		for _, job := range s.jobs {
+			if !job.next.IsZero() && !job.next.After(now) {
+				if job.running {
+					// The previous run overran this occurrence; skip it
+					// rather than wake continually until that run returns
+					s.logger.WarnContext(ctx, "Scheduler skipped run of job still running", "job", job.Name, "scheduled_for", job.next)
+					job.next = job.Schedule.Next(now)
+				} else {
+					scheduledFor := job.next
+					job.next = job.Schedule.Next(now)
+					job.running = true
+					s.wg.Add(1)
+					go s.execute(ctx, job, scheduledFor)
+				}
+			}
+			if !job.next.IsZero() && job.next.Before(wake) {
+				wake = job.next
 			}
 		}
+		s.mu.Unlock()
+
+		timer.Reset(wake.Sub(s.clock.Now()))
+	}
+}
+
+// Heartbeat returns when the scheduler last checked for due jobs, or the
+// zero time if it is not running. A running scheduler checks at least once
+// a minute.
+func (s *Scheduler) Heartbeat() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.heartbeat
+}
+
+// firstRun works out a job's first occurrence. With CatchUp, an occurrence
+// missed since the last recorded run is returned so it runs immediately.
+func (s *Scheduler) firstRun(ctx context.Context, job *Job, now time.Time) time.Time {
+	next := job.Schedule.Next(now)
+	if !job.CatchUp || s.store == nil {
+		return next
+	}
+
+	last, ok, err := s.store.LastJobRun(ctx, job.Name)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Scheduler failed to load last run", "job", job.Name, "error", err)
+		return next
 	}
+	if !ok {
+		return next
+	}
+
+	// Find the latest occurrence after the last run that is not in the future
+	var missed time.Time
+	for t := job.Schedule.Next(last); !t.IsZero() && !t.After(now); t = job.Schedule.Next(t) {
+		missed = t
+	}
+	if missed.IsZero() {
+		return next
+	}
+
+	s.logger.InfoContext(ctx, "Scheduler catching up missed run", "job", job.Name, "scheduled_for", missed)
+	return missed
 }
 
-// CheckMonthlyZakat checks if zakat should be deducted (runs on 1st of month)
-func CheckMonthlyZakat() bool {
-	now := time.Now()
-	return now.Day() == 1 && now.Hour() == 0 && now.Minute() == 0
+// execute runs one occurrence under the job's lock and records the outcome
+func (s *Scheduler) execute(ctx context.Context, job *Job, scheduledFor time.Time) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		job.running = false
+		s.mu.Unlock()
+	}()
+
+	runID := ""
+	if s.store != nil {
+		release, ok, err := s.store.TryLock(ctx, "job:"+job.Name)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Scheduler failed to lock job", "job", job.Name, "error", err)
+			return
+		}
+		if !ok {
+			s.logger.DebugContext(ctx, "Scheduler skipped job running on another instance", "job", job.Name)
+			return
+		}
+		defer release()
+
+		// Another instance may have completed this occurrence already
+		last, ok, err := s.store.LastJobRun(ctx, job.Name)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "Scheduler failed to load last run", "job", job.Name, "error", err)
+			return
+		}
+		if ok && !last.Before(scheduledFor) {
+			return
+		}
+
+		if runID, err = s.store.StartJobRun(ctx, job.Name, scheduledFor); err != nil {
+			s.logger.ErrorContext(ctx, "Scheduler failed to record run", "job", job.Name, "error", err)
+			return
+		}
+	}
+
+	s.logger.DebugContext(ctx, "Scheduler running job", "job", job.Name, "scheduled_for", scheduledFor)
+	err := runJob(context.WithValue(ctx, jobRunIDKey{}, runID), job, scheduledFor)
+
+	status, errMsg := JobSucceeded, ""
+	if err != nil {
+		status, errMsg = JobFailed, err.Error()
+		s.logger.ErrorContext(ctx, "Scheduled job failed", "job", job.Name, "run_id", runID, "error", err)
+	}
+
+	if s.store != nil {
+		// Record the outcome even when shutdown cancelled ctx
+		finishCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if ferr := s.store.FinishJobRun(finishCtx, runID, status, errMsg); ferr != nil {
+			s.logger.ErrorContext(ctx, "Scheduler failed to record outcome", "job", job.Name, "run_id", runID, "error", ferr)
+		}
+	}
+}
+
+// runJob calls the job function, turning a panic into an error
+func runJob(ctx context.Context, job *Job, scheduledFor time.Time) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run(ctx, scheduledFor)
 }
@@ -1,52 +1,477 @@
 package config
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
 )
 
-// Config holds application configuration
+// Config holds application configuration. It is read from the defaults
+// below, then the file named by CONFIG_FILE if set, then the environment,
+// each overriding the one before.
 type Config struct {
-	Port                    string
-	NodeEnv                 string
-	DatabaseURL             string
-	JWTSecret               string
-	ZakatPoolWallet         string
-	ZakatPercentage         float64
-	CORSAllowedOrigins      []string
-	LogLevel                string
-	LogFormat               string
-	SupabaseURL             string
-	SupabaseAnonKey         string
-	SupabaseServiceRoleKey  string
-}
-
-// LoadConfig loads configuration from environment variables
-func LoadConfig() *Config {
-	_ = godotenv.Load()
+	Server    ServerConfig    `json:"server" yaml:"server" toml:"server"`
+	Database  DatabaseConfig  `json:"database" yaml:"database" toml:"database"`
+	Chain     ChainConfig     `json:"chain" yaml:"chain" toml:"chain"`
+	Mining    MiningConfig    `json:"mining" yaml:"mining" toml:"mining"`
+	Zakat     ZakatConfig     `json:"zakat" yaml:"zakat" toml:"zakat"`
+	Auth      AuthConfig      `json:"auth" yaml:"auth" toml:"auth"`
+	Mail      MailConfig      `json:"mail" yaml:"mail" toml:"mail"`
+	CORS      CORSConfig      `json:"cors" yaml:"cors" toml:"cors"`
+	RateLimit RateLimitConfig `json:"rate_limit" yaml:"rate_limit" toml:"rate_limit"`
+	Log       LogConfig       `json:"log" yaml:"log" toml:"log"`
+	Metrics   MetricsConfig   `json:"metrics" yaml:"metrics" toml:"metrics"`
+	Supabase  SupabaseConfig  `json:"supabase" yaml:"supabase" toml:"supabase"`
+}
+
+// ServerConfig configures the HTTP server and the process around it
+type ServerConfig struct {
+	Port              string   `json:"port" yaml:"port" toml:"port"`
+	Env               string   `json:"env" yaml:"env" toml:"env"` // development or production
+	ReadTimeout       Duration `json:"read_timeout" yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout      Duration `json:"write_timeout" yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       Duration `json:"idle_timeout" yaml:"idle_timeout" toml:"idle_timeout"`
+	ShutdownTimeout   Duration `json:"shutdown_timeout" yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	TrustedProxies    []string `json:"trusted_proxies" yaml:"trusted_proxies" toml:"trusted_proxies"`
+	SchedulerTimezone string   `json:"scheduler_timezone" yaml:"scheduler_timezone" toml:"scheduler_timezone"`
+	KYCUploadDir      string   `json:"kyc_upload_dir" yaml:"kyc_upload_dir" toml:"kyc_upload_dir"`
+}
 
+// DatabaseConfig configures the database connection pool
+type DatabaseConfig struct {
+	URL             string   `json:"url" yaml:"url" toml:"url"`
+	MaxOpenConns    int      `json:"max_open_conns" yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int      `json:"max_idle_conns" yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime Duration `json:"conn_max_lifetime" yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	ConnMaxIdleTime Duration `json:"conn_max_idle_time" yaml:"conn_max_idle_time" toml:"conn_max_idle_time"`
+	AutoMigrate     bool     `json:"auto_migrate" yaml:"auto_migrate" toml:"auto_migrate"` // apply pending migrations on start
+}
+
+// ChainConfig holds the chain's consensus parameters
+type ChainConfig struct {
+	Difficulty      int      `json:"difficulty" yaml:"difficulty" toml:"difficulty"` // leading zero hex digits of block hashes
+	TargetBlockTime Duration `json:"target_block_time" yaml:"target_block_time" toml:"target_block_time"`
+}
+
+// MiningConfig configures block mining
+type MiningConfig struct {
+	Reward               float64  `json:"reward" yaml:"reward" toml:"reward"`
+	MaxBlockTransactions int      `json:"max_block_transactions" yaml:"max_block_transactions" toml:"max_block_transactions"`
+	Timeout              Duration `json:"timeout" yaml:"timeout" toml:"timeout"`
+}
+
+// ZakatConfig configures the zakat runs
+type ZakatConfig struct {
+	PoolWallet  string  `json:"pool_wallet" yaml:"pool_wallet" toml:"pool_wallet"`
+	Percentage  float64 `json:"percentage" yaml:"percentage" toml:"percentage"`
+	SigningKey  string  `json:"signing_key" yaml:"signing_key" toml:"signing_key"`
+	NisabSource string  `json:"nisab_source" yaml:"nisab_source" toml:"nisab_source"`
+	NisabAmount float64 `json:"nisab_amount" yaml:"nisab_amount" toml:"nisab_amount"`
+	PriceFile   string  `json:"price_file" yaml:"price_file" toml:"price_file"`
+	Schedule    string  `json:"schedule" yaml:"schedule" toml:"schedule"`
+}
+
+// AuthConfig configures tokens, two-factor authentication and login lockout
+type AuthConfig struct {
+	JWTSecret          string            `json:"jwt_secret" yaml:"jwt_secret" toml:"jwt_secret"`
+	JWTKeys            map[string]string `json:"jwt_keys" yaml:"jwt_keys" toml:"jwt_keys"`
+	JWTActiveKeyID     string            `json:"jwt_active_kid" yaml:"jwt_active_kid" toml:"jwt_active_kid"`
+	AccessTokenTTL     Duration          `json:"access_token_ttl" yaml:"access_token_ttl" toml:"access_token_ttl"`
+	RefreshTokenTTL    Duration          `json:"refresh_token_ttl" yaml:"refresh_token_ttl" toml:"refresh_token_ttl"`
+	InitialAdminEmail  string            `json:"initial_admin_email" yaml:"initial_admin_email" toml:"initial_admin_email"`
+	TwoFactorKey       string            `json:"two_factor_key" yaml:"two_factor_key" toml:"two_factor_key"`
+	TwoFactorIssuer    string            `json:"two_factor_issuer" yaml:"two_factor_issuer" toml:"two_factor_issuer"`
+	TwoFactorForLogin  bool              `json:"two_factor_login_required" yaml:"two_factor_login_required" toml:"two_factor_login_required"`
+	TwoFactorThreshold float64           `json:"two_factor_transfer_threshold" yaml:"two_factor_transfer_threshold" toml:"two_factor_transfer_threshold"`
+	LoginMaxFailures   int               `json:"login_max_failures" yaml:"login_max_failures" toml:"login_max_failures"`
+	LoginLockoutBase   Duration          `json:"login_lockout_base" yaml:"login_lockout_base" toml:"login_lockout_base"`
+	LoginLockoutMax    Duration          `json:"login_lockout_max" yaml:"login_lockout_max" toml:"login_lockout_max"`
+}
+
+// MailConfig configures outgoing email. Without an SMTP host, emails are
+// written to File or the log.
+type MailConfig struct {
+	SMTPHost     string `json:"smtp_host" yaml:"smtp_host" toml:"smtp_host"`
+	SMTPPort     string `json:"smtp_port" yaml:"smtp_port" toml:"smtp_port"`
+	SMTPUsername string `json:"smtp_username" yaml:"smtp_username" toml:"smtp_username"`
+	SMTPPassword string `json:"smtp_password" yaml:"smtp_password" toml:"smtp_password"`
+	From         string `json:"from" yaml:"from" toml:"from"`
+	File         string `json:"file" yaml:"file" toml:"file"`
+}
+
+// CORSConfig lists the origins allowed to call the API from a browser
+type CORSConfig struct {
+	AllowedOrigins []string `json:"allowed_origins" yaml:"allowed_origins" toml:"allowed_origins"`
+}
+
+// RateLimitConfig configures the request rate limits
+type RateLimitConfig struct {
+	RPS             float64 `json:"rps" yaml:"rps" toml:"rps"`
+	Burst           int     `json:"burst" yaml:"burst" toml:"burst"`
+	AuthPerMinute   float64 `json:"auth_per_minute" yaml:"auth_per_minute" toml:"auth_per_minute"`
+	CostlyPerMinute float64 `json:"costly_per_minute" yaml:"costly_per_minute" toml:"costly_per_minute"`
+	UserPerMinute   float64 `json:"user_per_minute" yaml:"user_per_minute" toml:"user_per_minute"`
+}
+
+// LogConfig configures the structured logger
+type LogConfig struct {
+	Level  string `json:"level" yaml:"level" toml:"level"`
+	Format string `json:"format" yaml:"format" toml:"format"`
+}
+
+// MetricsConfig configures the metrics endpoint
+type MetricsConfig struct {
+	Token string `json:"token" yaml:"token" toml:"token"`
+}
+
+// SupabaseConfig holds the Supabase project credentials
+type SupabaseConfig struct {
+	URL            string `json:"url" yaml:"url" toml:"url"`
+	AnonKey        string `json:"anon_key" yaml:"anon_key" toml:"anon_key"`
+	ServiceRoleKey string `json:"service_role_key" yaml:"service_role_key" toml:"service_role_key"`
+}
+
+// Duration is a time.Duration written as a string such as "15m" in
+// configuration files and dumps
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalText formats the duration like time.Duration.String
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText parses a duration such as "90s" or "1h30m"
+func (d *Duration) UnmarshalText(text []byte) error {
+	value, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(value)
+	return nil
+}
+
+// DevJWTSecret signs tokens when no secret is configured. Validate refuses
+// it in production.
+const DevJWTSecret = "your-jwt-secret-key"
+
+// Default returns the configuration used for anything not set
+func Default() *Config {
 	return &Config{
-		Port:                   getEnv("PORT", "8080"),
-		NodeEnv:               getEnv("NODE_ENV", "development"),
-		DatabaseURL:           getEnv("DATABASE_URL", ""),
-		JWTSecret:             getEnv("JWT_SECRET", "your-jwt-secret-key"),
-		ZakatPoolWallet:       getEnv("ZAKAT_POOL_WALLET", "zakat_pool"),
-		ZakatPercentage:       2.5,
-		CORSAllowedOrigins:    []string{"http://localhost:5173", "http://localhost:3000"},
-		LogLevel:              getEnv("LOG_LEVEL", "info"),
-		LogFormat:             getEnv("LOG_FORMAT", "json"),
-		SupabaseURL:           getEnv("SUPABASE_URL", ""),
-		SupabaseAnonKey:       getEnv("SUPABASE_ANON_KEY", ""),
-		SupabaseServiceRoleKey: getEnv("SUPABASE_SERVICE_ROLE_KEY", ""),
-	}
-}
-
-// getEnv gets an environment variable with a default value
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
-	}
-	return value
+		Server: ServerConfig{
+			Port:              "8080",
+			Env:               "development",
+			ReadTimeout:       Duration(15 * time.Second),
+			WriteTimeout:      Duration(150 * time.Second),
+			IdleTimeout:       Duration(60 * time.Second),
+			ShutdownTimeout:   Duration(30 * time.Second),
+			SchedulerTimezone: "UTC",
+			KYCUploadDir:      "uploads/kyc",
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: Duration(5 * time.Minute),
+			AutoMigrate:     true,
+		},
+		Chain: ChainConfig{
+			Difficulty:      4,
+			TargetBlockTime: Duration(time.Minute),
+		},
+		Mining: MiningConfig{
+			Reward:               5,
+			MaxBlockTransactions: 10,
+			Timeout:              Duration(2 * time.Minute),
+		},
+		Zakat: ZakatConfig{
+			PoolWallet:  "zakat_pool",
+			Percentage:  2.5,
+			NisabSource: "fixed",
+			Schedule:    "0 0 1 * *",
+		},
+		Auth: AuthConfig{
+			JWTSecret:          DevJWTSecret,
+			AccessTokenTTL:     Duration(15 * time.Minute),
+			RefreshTokenTTL:    Duration(30 * 24 * time.Hour),
+			TwoFactorIssuer:    "Crypto Wallet",
+			TwoFactorThreshold: 500,
+			LoginMaxFailures:   5,
+			LoginLockoutBase:   Duration(time.Minute),
+			LoginLockoutMax:    Duration(time.Hour),
+		},
+		Mail: MailConfig{
+			SMTPPort: "587",
+			From:     "no-reply@localhost",
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"http://localhost:5173", "http://localhost:3000"},
+		},
+		RateLimit: RateLimitConfig{
+			RPS:             10,
+			Burst:           40,
+			AuthPerMinute:   10,
+			CostlyPerMinute: 3,
+			UserPerMinute:   120,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+	}
+}
+
+// LoadConfig loads configuration from the defaults, the optional file named
+// by CONFIG_FILE and environment variables, and validates it
+func LoadConfig() (*Config, error) {
+	_ = godotenv.Load()
+
+	cfg := Default()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+
+	// Without signing keys, tokens are signed with the JWT secret
+	if len(cfg.Auth.JWTKeys) == 0 {
+		cfg.Auth.JWTKeys = map[string]string{"default": cfg.Auth.JWTSecret}
+		cfg.Auth.JWTActiveKeyID = "default"
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// IsProduction reports whether the server runs in production
+func (c *Config) IsProduction() bool {
+	return c.Server.Env == "production"
+}
+
+// loadFile reads a YAML (.yaml, .yml) or TOML (.toml) file over c. Keys it
+// does not set keep their values; unknown keys are an error.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(c)
+		if errors.Is(err, io.EOF) {
+			err = nil // empty file
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(c)
+	default:
+		err = fmt.Errorf("unsupported format %q; use .yaml, .yml or .toml", filepath.Ext(path))
+	}
+	return err
+}
+
+// loadEnv overrides c with the environment variables that are set
+func (c *Config) loadEnv() error {
+	e := &envReader{}
+
+	e.string("PORT", &c.Server.Port)
+	e.string("NODE_ENV", &c.Server.Env)
+	e.duration("HTTP_READ_TIMEOUT", &c.Server.ReadTimeout)
+	e.duration("HTTP_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	e.duration("HTTP_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	e.duration("SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	e.list("TRUSTED_PROXIES", &c.Server.TrustedProxies)
+	e.string("SCHEDULER_TIMEZONE", &c.Server.SchedulerTimezone)
+	e.string("KYC_UPLOAD_DIR", &c.Server.KYCUploadDir)
+
+	e.string("DATABASE_URL", &c.Database.URL)
+	e.int("DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	e.int("DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+	e.duration("DB_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime)
+	e.duration("DB_CONN_MAX_IDLE_TIME", &c.Database.ConnMaxIdleTime)
+	e.bool("DB_AUTO_MIGRATE", &c.Database.AutoMigrate)
+
+	e.int("CHAIN_DIFFICULTY", &c.Chain.Difficulty)
+	e.duration("CHAIN_TARGET_BLOCK_TIME", &c.Chain.TargetBlockTime)
+
+	e.float("MINING_REWARD", &c.Mining.Reward)
+	e.int("MINING_MAX_BLOCK_TRANSACTIONS", &c.Mining.MaxBlockTransactions)
+	e.duration("MINING_TIMEOUT", &c.Mining.Timeout)
+
+	e.string("ZAKAT_POOL_WALLET", &c.Zakat.PoolWallet)
+	e.float("ZAKAT_PERCENTAGE", &c.Zakat.Percentage)
+	e.string("ZAKAT_SIGNING_KEY", &c.Zakat.SigningKey)
+	e.string("ZAKAT_NISAB_SOURCE", &c.Zakat.NisabSource)
+	e.float("ZAKAT_NISAB_AMOUNT", &c.Zakat.NisabAmount)
+	e.string("ZAKAT_PRICE_FILE", &c.Zakat.PriceFile)
+	e.string("ZAKAT_SCHEDULE", &c.Zakat.Schedule)
+
+	e.string("JWT_SECRET", &c.Auth.JWTSecret)
+	e.keys("JWT_KEYS", &c.Auth.JWTKeys, &c.Auth.JWTActiveKeyID)
+	e.string("JWT_ACTIVE_KID", &c.Auth.JWTActiveKeyID)
+	e.duration("ACCESS_TOKEN_TTL", &c.Auth.AccessTokenTTL)
+	e.duration("REFRESH_TOKEN_TTL", &c.Auth.RefreshTokenTTL)
+	e.string("INITIAL_ADMIN_EMAIL", &c.Auth.InitialAdminEmail)
+	e.string("TWO_FACTOR_KEY", &c.Auth.TwoFactorKey)
+	e.string("TWO_FACTOR_ISSUER", &c.Auth.TwoFactorIssuer)
+	e.bool("TWO_FACTOR_LOGIN_REQUIRED", &c.Auth.TwoFactorForLogin)
+	e.float("TWO_FACTOR_TRANSFER_THRESHOLD", &c.Auth.TwoFactorThreshold)
+	e.int("LOGIN_MAX_FAILURES", &c.Auth.LoginMaxFailures)
+	e.duration("LOGIN_LOCKOUT_BASE", &c.Auth.LoginLockoutBase)
+	e.duration("LOGIN_LOCKOUT_MAX", &c.Auth.LoginLockoutMax)
+
+	e.string("SMTP_HOST", &c.Mail.SMTPHost)
+	e.string("SMTP_PORT", &c.Mail.SMTPPort)
+	e.string("SMTP_USERNAME", &c.Mail.SMTPUsername)
+	e.string("SMTP_PASSWORD", &c.Mail.SMTPPassword)
+	e.string("MAIL_FROM", &c.Mail.From)
+	e.string("MAIL_FILE", &c.Mail.File)
+
+	e.list("CORS_ALLOWED_ORIGINS", &c.CORS.AllowedOrigins)
+
+	e.float("RATE_LIMIT_RPS", &c.RateLimit.RPS)
+	e.int("RATE_LIMIT_BURST", &c.RateLimit.Burst)
+	e.float("AUTH_RATE_LIMIT_PER_MINUTE", &c.RateLimit.AuthPerMinute)
+	e.float("COSTLY_RATE_LIMIT_PER_MINUTE", &c.RateLimit.CostlyPerMinute)
+	e.float("USER_RATE_LIMIT_PER_MINUTE", &c.RateLimit.UserPerMinute)
+
+	e.string("LOG_LEVEL", &c.Log.Level)
+	e.string("LOG_FORMAT", &c.Log.Format)
+	e.string("METRICS_TOKEN", &c.Metrics.Token)
+
+	e.string("SUPABASE_URL", &c.Supabase.URL)
+	e.string("SUPABASE_ANON_KEY", &c.Supabase.AnonKey)
+	e.string("SUPABASE_SERVICE_ROLE_KEY", &c.Supabase.ServiceRoleKey)
+
+	return e.err()
+}
+
+// envReader sets fields from environment variables, leaving a field alone
+// if its variable is unset or empty and collecting parse errors
+type envReader struct {
+	errs []string
+}
+
+func (e *envReader) lookup(key string) (string, bool) {
+	value := strings.TrimSpace(os.Getenv(key))
+	return value, value != ""
+}
+
+func (e *envReader) fail(key, value, want string) {
+	e.errs = append(e.errs, fmt.Sprintf("%s=%q is not %s", key, value, want))
+}
+
+func (e *envReader) err() error {
+	if len(e.errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid environment: %s", strings.Join(e.errs, "; "))
+}
+
+func (e *envReader) string(key string, dst *string) {
+	if value, ok := e.lookup(key); ok {
+		*dst = value
+	}
+}
+
+func (e *envReader) int(key string, dst *int) {
+	if value, ok := e.lookup(key); ok {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			e.fail(key, value, "an integer")
+			return
+		}
+		*dst = n
+	}
+}
+
+func (e *envReader) float(key string, dst *float64) {
+	if value, ok := e.lookup(key); ok {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			e.fail(key, value, "a number")
+			return
+		}
+		*dst = f
+	}
+}
+
+func (e *envReader) bool(key string, dst *bool) {
+	if value, ok := e.lookup(key); ok {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			e.fail(key, value, "true or false")
+			return
+		}
+		*dst = b
+	}
+}
+
+// duration reads a duration such as "15m"
+func (e *envReader) duration(key string, dst *Duration) {
+	if value, ok := e.lookup(key); ok {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			e.fail(key, value, `a duration such as "15m"`)
+			return
+		}
+		*dst = Duration(d)
+	}
+}
+
+// list reads a comma-separated list
+func (e *envReader) list(key string, dst *[]string) {
+	if value, ok := e.lookup(key); ok {
+		var values []string
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		*dst = values
+	}
+}
+
+// keys reads signing keys given as "kid:secret,kid:secret", making the
+// first kid the active one
+func (e *envReader) keys(key string, dst *map[string]string, activeKeyID *string) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	keys := make(map[string]string)
+	first := ""
+	for _, pair := range strings.Split(value, ",") {
+		kid, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || kid == "" || secret == "" {
+			e.errs = append(e.errs, fmt.Sprintf("%s entries must be kid:secret", key))
+			return
+		}
+		keys[kid] = secret
+		if first == "" {
+			first = kid
+		}
+	}
+	*dst, *activeKeyID = keys, first
 }
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// minSecretLength is the shortest signing secret accepted in production
+const minSecretLength = 32
+
+// placeholderSecrets are the example secrets shipped in .env.example and
+// fly.toml, refused in production like the built-in default
+var placeholderSecrets = []string{
+	DevJWTSecret,
+	"your-jwt-secret-key-change-in-production",
+	"your-jwt-secret-change-in-production",
+	"change-me",
+	"changeme",
+	"secret",
+}
+
+// redacted replaces secrets in configuration dumps
+const redacted = "[REDACTED]"
+
+// Validate checks that the configuration is usable, returning every problem
+// found. In production it also refuses default or weak secrets.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	s := c.Server
+	check(s.Env == "development" || s.Env == "production", "server.env (NODE_ENV) must be development or production, got %q", s.Env)
+	check(s.Port != "", "server.port (PORT) is required")
+	check(s.ReadTimeout > 0 && s.WriteTimeout > 0 && s.IdleTimeout > 0 && s.ShutdownTimeout > 0,
+		"server timeouts must be positive")
+	check(s.WriteTimeout > c.Mining.Timeout,
+		"server.write_timeout (%s) must exceed mining.timeout (%s) so mined blocks can be returned", s.WriteTimeout, c.Mining.Timeout)
+	_, err := time.LoadLocation(s.SchedulerTimezone)
+	check(err == nil, "server.scheduler_timezone (SCHEDULER_TIMEZONE) %q is not a time zone", s.SchedulerTimezone)
+
+	d := c.Database
+	check(d.URL != "", "database.url (DATABASE_URL) is required")
+	check(d.MaxOpenConns > 0, "database.max_open_conns must be positive")
+	check(d.MaxIdleConns >= 0 && d.MaxIdleConns <= d.MaxOpenConns, "database.max_idle_conns must be between 0 and max_open_conns")
+	check(d.ConnMaxLifetime >= 0 && d.ConnMaxIdleTime >= 0, "database connection lifetimes must not be negative")
+
+	check(c.Chain.Difficulty >= 1 && c.Chain.Difficulty <= 16, "chain.difficulty must be between 1 and 16")
+	check(c.Chain.TargetBlockTime > 0, "chain.target_block_time must be positive")
+
+	check(c.Mining.Reward >= 0, "mining.reward must not be negative")
+	check(c.Mining.MaxBlockTransactions > 0, "mining.max_block_transactions must be positive")
+	check(c.Mining.Timeout > 0, "mining.timeout must be positive")
+
+	z := c.Zakat
+	check(z.PoolWallet != "", "zakat.pool_wallet is required")
+	check(z.Percentage > 0 && z.Percentage <= 100, "zakat.percentage must be above 0 and at most 100")
+	check(z.NisabAmount >= 0, "zakat.nisab_amount must not be negative")
+
+	a := c.Auth
+	check(a.AccessTokenTTL > 0 && a.RefreshTokenTTL > a.AccessTokenTTL,
+		"auth token lifetimes must be positive, refresh longer than access")
+	_, ok := a.JWTKeys[a.JWTActiveKeyID]
+	check(ok, "auth.jwt_active_kid %q is not one of auth.jwt_keys", a.JWTActiveKeyID)
+	for kid, secret := range a.JWTKeys {
+		check(secret != "", "auth: JWT signing key %q is empty", kid)
+	}
+	check(a.TwoFactorThreshold >= 0, "auth.two_factor_transfer_threshold must not be negative")
+	check(a.LoginMaxFailures > 0, "auth.login_max_failures must be positive")
+	check(a.LoginLockoutBase > 0 && a.LoginLockoutMax >= a.LoginLockoutBase,
+		"auth lockouts must be positive, the maximum at least the base")
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		check(isOrigin(origin), "cors.allowed_origins: %q is not an origin such as https://app.example.com", origin)
+	}
+
+	r := c.RateLimit
+	check(r.RPS >= 0 && r.Burst >= 0 && r.AuthPerMinute >= 0 && r.CostlyPerMinute >= 0 && r.UserPerMinute >= 0,
+		"rate limits must not be negative; zero disables a limit")
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		errs = append(errs, fmt.Errorf("log.level (LOG_LEVEL) must be debug, info, warn or error, got %q", c.Log.Level))
+	}
+	format := strings.ToLower(c.Log.Format)
+	check(format == "json" || format == "text", "log.format (LOG_FORMAT) must be json or text, got %q", c.Log.Format)
+
+	if c.IsProduction() {
+		errs = append(errs, c.validateProductionSecrets()...)
+	}
+	return errors.Join(errs...)
+}
+
+// validateProductionSecrets refuses the default, example and short signing
+// secrets, which would let anyone forge tokens
+func (c *Config) validateProductionSecrets() []error {
+	var errs []error
+	for kid, secret := range c.Auth.JWTKeys {
+		if reason := weakSecret(secret); reason != "" {
+			errs = append(errs, fmt.Errorf("production: JWT signing key %q %s; set JWT_SECRET or JWT_KEYS", kid, reason))
+		}
+	}
+	if c.Auth.TwoFactorKey != "" {
+		if reason := weakSecret(c.Auth.TwoFactorKey); reason != "" {
+			errs = append(errs, fmt.Errorf("production: TWO_FACTOR_KEY %s", reason))
+		}
+	}
+	return errs
+}
+
+// weakSecret says why secret is unfit for production, or returns ""
+func weakSecret(secret string) string {
+	for _, placeholder := range placeholderSecrets {
+		if strings.EqualFold(secret, placeholder) {
+			return "is a default or example value"
+		}
+	}
+	if len(secret) < minSecretLength {
+		return fmt.Sprintf("is shorter than %d characters", minSecretLength)
+	}
+	return ""
+}
+
+// isOrigin reports whether s is a scheme and host with no path
+func isOrigin(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+		u.Path == "" && u.RawQuery == "" && u.User == nil
+}
+
+// Redacted returns a copy of the configuration with every secret replaced,
+// fit to show to administrators. Unset secrets stay empty, so it still shows
+// which are missing.
+func (c *Config) Redacted() *Config {
+	r := *c
+	hide := func(s *string) {
+		if *s != "" {
+			*s = redacted
+		}
+	}
+
+	hide(&r.Auth.JWTSecret)
+	hide(&r.Auth.TwoFactorKey)
+	hide(&r.Zakat.SigningKey)
+	hide(&r.Mail.SMTPPassword)
+	hide(&r.Metrics.Token)
+	hide(&r.Supabase.AnonKey)
+	hide(&r.Supabase.ServiceRoleKey)
+
+	r.Auth.JWTKeys = make(map[string]string, len(c.Auth.JWTKeys))
+	for kid := range c.Auth.JWTKeys {
+		r.Auth.JWTKeys[kid] = redacted
+	}
+	r.Database.URL = redactURL(c.Database.URL)
+	return &r
+}
+
+// redactURL hides the password of a connection URL, or the whole value if
+// it is not a URL, such as a key=value connection string
+func redactURL(s string) string {
+	if s == "" {
+		return ""
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" {
+		return redacted
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	q := u.Query()
+	if q.Has("password") {
+		q.Set("password", "xxxxx")
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
@@ -8,6 +8,7 @@ interface SendMoneyFormData {
   amount: number
   fee: number
   note?: string
+  password: string
 }
 
 const SendMoney: React.FC = () => {
@@ -45,16 +46,14 @@ const SendMoney: React.FC = () => {
         return
       }
 
-      // In production, this would sign the transaction with the private key
-      const signature = 'demo-signature'
-
+      // The backend unlocks the encrypted key with the password and signs the transaction
       const response = await apiClient.sendTransaction(
         (wallet as any).wallet_address || wallet.walletAddress,
         data.recipientAddress,
         Number(data.amount),
         Number(data.fee),
         data.note || '',
-        signature
+        data.password
       )
 
       if (response.data.status === 'success') {
@@ -209,6 +208,19 @@ const SendMoney: React.FC = () => {
             />
           </div>
 
+          <div>
+            <label className="block text-gray-700 dark:text-gray-300 font-semibold mb-2">
+              Password
+            </label>
+            <input
+              {...register('password', { required: 'Password is required to sign the transaction' })}
+              type="password"
+              className="input-field"
+              placeholder="Your account password"
+            />
+            {errors.password && <span className="error-text">{errors.password.message}</span>}
+          </div>
+
           <button
             type="submit"
             disabled={loading || !wallet}
@@ -78,17 +78,21 @@ class ApiClient {
     })
   }
 
-  async sendTransaction(senderWallet: string, receiverWallet: string, amount: number, fee: number, note: string, signature: string) {
+  async sendTransaction(senderWallet: string, receiverWallet: string, amount: number, fee: number, note: string, password: string) {
     return this.client.post<ApiResponse<any>>('/transaction/send', {
       sender_wallet: senderWallet,
       receiver_wallet: receiverWallet,
       amount,
       fee,
       note,
-      signature,
+      password,
     })
   }
 
+  async unlockWallet(password: string) {
+    return this.client.post<ApiResponse<any>>('/wallet/unlock', { password })
+  }
+
   async getBlocks(limit = 10, offset = 0) {
     return this.client.get<ApiResponse<any>>('/blockchain/blocks', {
       params: { limit, offset },
@@ -52,9 +52,9 @@ cd ..
 # Database setup
 echo "\n💾 Database setup instructions:"
 echo "1. Create a Supabase project at https://supabase.com"
-echo "2. Run the SQL schema in database/schema.sql via Supabase SQL editor"
-echo "3. Get your DATABASE_URL from Project Settings → Database"
-echo "4. Update backend/.env with your DATABASE_URL"
+echo "2. Get your DATABASE_URL from Project Settings → Database"
+echo "3. Update backend/.env with your DATABASE_URL"
+echo "4. Start the backend; it applies the schema migrations on start"
 
 echo "\n✅ Setup complete!"
 echo "\n📝 Next steps:"
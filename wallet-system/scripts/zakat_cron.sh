@@ -3,7 +3,8 @@
 # Zakat Scheduler Script
 # This script processes zakat deductions on the 1st of each month
 
-# Set variables
+# Set variables. JWT_TOKEN must be a current admin access token; access
+# tokens expire after ACCESS_TOKEN_TTL (15 minutes by default).
 BACKEND_URL="http://localhost:8080/api"
 ADMIN_TOKEN="${JWT_TOKEN}"
 
@@ -12,9 +13,10 @@ process_zakat() {
     echo "Processing monthly zakat deductions..."
     
     # Call backend endpoint to process zakat
-    curl -X POST "${BACKEND_URL}/zakat/process" \
+    curl -X POST "${BACKEND_URL}/admin/zakat/process" \
         -H "Authorization: Bearer ${ADMIN_TOKEN}" \
-        -H "Content-Type: application/json"
+        -H "Content-Type: application/json" \
+        -d "{\"month_year\": \"$(date +%Y-%m)\"}"
     
     echo "Zakat processing completed"
 }
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSystemLogHashSurvivesStorage(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 0, 0, 123456000, time.UTC)
+	written := &SystemLog{
+		Sequence:  7,
+		PrevHash:  GenesisAuditHash,
+		LogType:   LogLimitsChanged,
+		Message:   "Limits changed",
+		ActorID:   "3F2504E0-4F89-11D3-9A0C-0305E82C3301",
+		IPAddress: "::ffff:10.0.0.1",
+		After:     json.RawMessage(`{"tier": "verified", "daily": 500}`),
+		CreatedAt: created,
+	}
+
+	// As read back: lowercase UUID, plain IPv4, JSONB key order and spacing,
+	// and the timestamp in another location
+	read := *written
+	read.ActorID = "3f2504e0-4f89-11d3-9a0c-0305e82c3301"
+	read.IPAddress = "10.0.0.1"
+	read.After = json.RawMessage(`{"daily":500,"tier":"verified"}`)
+	read.CreatedAt = created.In(time.FixedZone("", 5*3600))
+
+	want, err := written.CalculateHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := read.CalculateHash(); got != want {
+		t.Fatalf("stored entry hashes to %s, written as %s", got, want)
+	}
+
+	tampered := read
+	tampered.Message = "Limits unchanged"
+	if got, _ := tampered.CalculateHash(); got == want {
+		t.Fatal("changing the message did not change the hash")
+	}
+
+	relinked := read
+	relinked.PrevHash = "1" + GenesisAuditHash[1:]
+	if got, _ := relinked.CalculateHash(); got == want {
+		t.Fatal("changing the previous hash did not change the hash")
+	}
+}
@@ -1,6 +1,8 @@
 package blockchain
 
 import (
+	"context"
+	"errors"
 	"testing"
 )
 
@@ -139,3 +141,42 @@ func TestValidateChain(t *testing.T) {
 		t.Errorf("Valid chain should pass validation")
 	}
 }
+
+func TestValidateRecent(t *testing.T) {
+	bc := NewBlockchain()
+	for i := 1; i <= 3; i++ {
+		block := NewBlock(int64(i), []Transaction{}, bc.GetLatestBlock().Hash, 2)
+		NewProofOfWork(block).Mine()
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatalf("AddBlock: %v", err)
+		}
+	}
+
+	if !bc.ValidateRecent(2) || !bc.ValidateRecent(10) {
+		t.Fatalf("Valid chain should pass recent validation")
+	}
+
+	// Break a link outside the window: only a wider window sees it
+	bc.Chain[1].PreviousHash = "tampered"
+	if !bc.ValidateRecent(2) {
+		t.Errorf("Tampering outside the window should not be checked")
+	}
+	if bc.ValidateRecent(3) {
+		t.Errorf("Tampering inside the window should fail validation")
+	}
+}
+
+func TestMineContextCancelled(t *testing.T) {
+	// A difficulty no nonce meets in time, so only cancellation ends mining
+	block := NewBlock(1, []Transaction{}, "0", 32)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := NewProofOfWork(block).MineContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+	if block.Hash != "" {
+		t.Errorf("Abandoned block should have no hash, got %s", block.Hash)
+	}
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseCNICDecodesRegionAndGender(t *testing.T) {
+	cases := []struct {
+		cnic   string
+		region string
+		gender string
+	}{
+		{"35202-1234567-1", "Punjab", GenderMale},
+		{"4210112345678", "Sindh", GenderFemale},
+		{"61101-7654321-4", "Islamabad", GenderFemale},
+	}
+
+	for _, tc := range cases {
+		got, err := ParseCNIC(tc.cnic)
+		if err != nil {
+			t.Fatalf("ParseCNIC(%s): %v", tc.cnic, err)
+		}
+		if got.Region != tc.region || got.Gender != tc.gender {
+			t.Errorf("ParseCNIC(%s) = %s/%s, want %s/%s", tc.cnic, got.Region, got.Gender, tc.region, tc.gender)
+		}
+		if !ValidateCNIC(got.Number) {
+			t.Errorf("ParseCNIC(%s) number %s is not dashed", tc.cnic, got.Number)
+		}
+	}
+}
+
+func TestParseCNICRejectsInvalid(t *testing.T) {
+	cases := map[string]error{
+		"35202-1234567":   ErrInvalidCNIC,
+		"352021-234567-1": ErrInvalidCNIC,
+		"3520a1234567-1":  ErrInvalidCNIC,
+		"1111111111111":   ErrInvalidCNIC,
+		"35202-0000000-1": ErrInvalidCNIC,
+		"95202-1234567-1": ErrUnknownCNICRegion,
+		"05202-1234567-1": ErrUnknownCNICRegion,
+		"30202-1234567-1": ErrUnknownCNICRegion,
+		"39202-1234567-1": ErrUnknownCNICRegion,
+		"46101-1234567-1": ErrUnknownCNICRegion,
+		"62101-1234567-1": ErrUnknownCNICRegion,
+	}
+
+	for cnic, want := range cases {
+		if _, err := ParseCNIC(cnic); !errors.Is(err, want) {
+			t.Errorf("ParseCNIC(%s) = %v, want %v", cnic, err, want)
+		}
+	}
+}
+
+func TestValidateCNICGender(t *testing.T) {
+	cnic, _ := ParseCNIC("35202-1234567-1")
+	if err := ValidateCNICGender(cnic, "Male"); err != nil {
+		t.Errorf("matching gender rejected: %v", err)
+	}
+	if err := ValidateCNICGender(cnic, GenderFemale); !errors.Is(err, ErrCNICGender) {
+		t.Errorf("mismatched gender = %v, want ErrCNICGender", err)
+	}
+}
@@ -0,0 +1,246 @@
+package utils
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	karachi, err := time.LoadLocation("Asia/Karachi")
+	if err != nil {
+		t.Skip("time zone data unavailable")
+	}
+
+	cases := []struct {
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"0 0 1 * *", time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"*/15 9-17 * * 1-5", time.Date(2024, 3, 8, 17, 50, 0, 0, time.UTC), time.Date(2024, 3, 11, 9, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"TZ=Asia/Karachi 0 0 1 * *", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, karachi)},
+	}
+
+	for _, tc := range cases {
+		schedule, err := ParseSchedule(tc.spec, time.UTC)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q): %v", tc.spec, err)
+		}
+		if got := schedule.Next(tc.from); !got.Equal(tc.want) {
+			t.Errorf("%q after %v = %v, want %v", tc.spec, tc.from, got, tc.want)
+		}
+	}
+}
+
+func TestParseScheduleRejectsInvalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "@every 0s", "TZ=Nowhere/City * * * * *"} {
+		if _, err := ParseSchedule(spec, time.UTC); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestImpossibleCronTerminates(t *testing.T) {
+	schedule, err := ParseSchedule("0 0 30 2 *", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := schedule.Next(time.Now()); !next.IsZero() {
+		t.Errorf("expected no next run, got %v", next)
+	}
+}
+
+// fakeClock drives a Scheduler's timer by hand. armed receives the wake time
+// each time the scheduler sets its timer for a later moment.
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	timer *fakeTimer
+	armed chan time.Time
+}
+
+type fakeTimer struct {
+	clock  *fakeClock
+	c      chan time.Time
+	at     time.Time
+	active bool
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, armed: make(chan time.Time, 8)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) timer {
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1)}
+	c.mu.Lock()
+	c.timer = t
+	c.mu.Unlock()
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the clock on, firing the timer if it is now due
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	t := c.timer
+	fire := t != nil && t.active && !t.at.After(c.now)
+	if fire {
+		t.active = false
+	}
+	c.mu.Unlock()
+	if fire {
+		t.c <- t.at
+	}
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	t.at = t.clock.now.Add(d)
+	fire := d <= 0
+	t.active = !fire
+	t.clock.mu.Unlock()
+	if fire {
+		t.c <- t.at
+	} else {
+		t.clock.armed <- t.at
+	}
+	return true
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.active
+	t.active = false
+	return active
+}
+
+// skipRecorder is a log handler collecting the occurrences the scheduler
+// reports skipping
+type skipRecorder struct {
+	mu      sync.Mutex
+	skipped []time.Time
+}
+
+func (h *skipRecorder) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *skipRecorder) Handle(_ context.Context, r slog.Record) error {
+	if r.Message != "Scheduler skipped run of job still running" {
+		return nil
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "scheduled_for" {
+			h.mu.Lock()
+			h.skipped = append(h.skipped, a.Value.Time())
+			h.mu.Unlock()
+		}
+		return true
+	})
+	return nil
+}
+
+func (h *skipRecorder) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *skipRecorder) WithGroup(string) slog.Handler { return h }
+
+// receive returns the next value from ch, failing the test if the scheduler
+// stalls
+func receive(t *testing.T, ch <-chan time.Time, what string) time.Time {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+		return time.Time{}
+	}
+}
+
+func TestSchedulerSkipsOccurrencesWhileJobOverruns(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := newFakeClock(start)
+	recorder := &skipRecorder{}
+	s := NewScheduler(nil, time.UTC, slog.New(recorder))
+	s.clock = clk
+
+	runs := make(chan time.Time, 4)
+	release := make(chan struct{})
+	err := s.AddJob("slow", "@every 1s", false, func(ctx context.Context, scheduledFor time.Time) error {
+		runs <- scheduledFor
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	second := func(n int) time.Time { return start.Add(time.Duration(n) * time.Second) }
+
+	if wake := receive(t, clk.armed, "first wake"); !wake.Equal(second(1)) {
+		t.Fatalf("first wake at %v, want %v", wake, second(1))
+	}
+
+	// The job starts at 1s and overruns the occurrences at 2s and 3s, each of
+	// which is skipped with the timer set for the next one
+	clk.Advance(time.Second)
+	if got := receive(t, runs, "first run"); !got.Equal(second(1)) {
+		t.Fatalf("first run scheduled for %v, want %v", got, second(1))
+	}
+	for n := 2; n <= 4; n++ {
+		if wake := receive(t, clk.armed, "next wake"); !wake.Equal(second(n)) {
+			t.Fatalf("wake at %v, want %v", wake, second(n))
+		}
+		if n < 4 {
+			clk.Advance(time.Second)
+		}
+	}
+
+	// Once the job returns, the occurrence at 4s runs
+	close(release)
+	for idle := false; !idle; {
+		s.mu.Lock()
+		idle = !s.jobs[0].running
+		s.mu.Unlock()
+		runtime.Gosched()
+	}
+	clk.Advance(time.Second)
+	if got := receive(t, runs, "second run"); !got.Equal(second(4)) {
+		t.Fatalf("second run scheduled for %v, want %v", got, second(4))
+	}
+	receive(t, clk.armed, "wake after second run")
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.skipped) != 2 || !recorder.skipped[0].Equal(second(2)) || !recorder.skipped[1].Equal(second(3)) {
+		t.Errorf("skipped %v, want [%v %v]", recorder.skipped, second(2), second(3))
+	}
+}
@@ -2,6 +2,7 @@ package crypto
 
 import (
 	"testing"
+	"time"
 )
 
 func TestGenerateKeyPair(t *testing.T) {
@@ -99,3 +100,56 @@ func TestSignAndVerifyTransaction(t *testing.T) {
 		t.Errorf("Should fail verification with different data")
 	}
 }
+
+func TestGenerateOTPIsSixDigits(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		otp, err := GenerateOTP()
+		if err != nil {
+			t.Fatalf("GenerateOTP: %v", err)
+		}
+		if len(otp) != 6 {
+			t.Fatalf("GenerateOTP() = %q, want 6 digits", otp)
+		}
+		for _, r := range otp {
+			if r < '0' || r > '9' {
+				t.Fatalf("GenerateOTP() = %q, want digits only", otp)
+			}
+		}
+	}
+}
+
+func TestTOTPMatchesRFC6238Vectors(t *testing.T) {
+	// RFC 6238 appendix B SHA-1 secret "12345678901234567890", truncated to
+	// six digits
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for unix, want := range cases {
+		got, err := TOTPCode(secret, TOTPStep(time.Unix(unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode: %v", err)
+		}
+		if got != want {
+			t.Errorf("TOTPCode at %d = %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestValidateTOTPAllowsSkew(t *testing.T) {
+	secret, _ := GenerateTOTPSecret()
+	now := time.Unix(1700000000, 0)
+	code, _ := TOTPCode(secret, TOTPStep(now)-1)
+
+	step, ok := ValidateTOTP(secret, code, now, 1)
+	if !ok || step != TOTPStep(now)-1 {
+		t.Errorf("previous step's code not accepted with skew 1")
+	}
+	if _, ok := ValidateTOTP(secret, code, now, 0); ok {
+		t.Errorf("previous step's code accepted with skew 0")
+	}
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestToHijriKnownDates(t *testing.T) {
+	cases := []struct {
+		date string
+		want HijriDate
+	}{
+		{"2023-03-23", HijriDate{Year: 1444, Month: 9, Day: 1}},
+		{"2024-03-11", HijriDate{Year: 1445, Month: 9, Day: 1}},
+		{"1970-01-01", HijriDate{Year: 1389, Month: 10, Day: 22}},
+	}
+
+	for _, tc := range cases {
+		date, _ := time.Parse("2006-01-02", tc.date)
+		got := ToHijri(date)
+		if got != tc.want {
+			t.Errorf("ToHijri(%s) = %v, want %v", tc.date, got, tc.want)
+		}
+		if !got.ToGregorian().Equal(date) {
+			t.Errorf("ToGregorian(%v) = %v, want %s", got, got.ToGregorian(), tc.date)
+		}
+	}
+}
+
+func TestAddHijriYearsIsOneLunarYear(t *testing.T) {
+	start := time.Date(2023, 3, 23, 10, 30, 0, 0, time.UTC)
+	end := AddHijriYears(start, 1)
+
+	if days := end.Sub(start).Hours() / 24; days < 354 || days > 355 {
+		t.Errorf("lunar year lasted %.1f days", days)
+	}
+	if end.Hour() != 10 || end.Minute() != 30 {
+		t.Errorf("time of day not kept: %v", end)
+	}
+}
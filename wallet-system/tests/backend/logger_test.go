@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestLoggerAddsRequestFieldsAndRedacts(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, "info", "json")
+
+	ctx := WithRequestInfo(context.Background(), RequestInfo{RequestID: "req-1", ActorID: "user-1"})
+	logger.InfoContext(ctx, "Transfer signed", "wallet", "abc", "signature", "c2lnbmF0dXJl", "public_key", "MIIB")
+	logger.DebugContext(ctx, "below the level")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("want one JSON record, got %q: %v", buf.String(), err)
+	}
+	if record["request_id"] != "req-1" || record["user_id"] != "user-1" || record["wallet"] != "abc" {
+		t.Fatalf("request fields missing: %v", record)
+	}
+	if record["signature"] != "[REDACTED]" || record["public_key"] != "[REDACTED]" {
+		t.Fatalf("sensitive values logged: %v", record)
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	for level, want := range map[string]string{"debug": "DEBUG", "WARN": "WARN", "error": "ERROR", "": "INFO", "bogus": "INFO"} {
+		if got := ParseLogLevel(level).String(); got != want {
+			t.Errorf("ParseLogLevel(%q) = %s, want %s", level, got, want)
+		}
+	}
+}
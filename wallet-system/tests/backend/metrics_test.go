@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWritesExpositionFormat(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounter("test_requests_total", "Requests.", "route", "status")
+	latency := r.NewHistogram("test_latency_seconds", "Latency.", []float64{0.1, 1})
+	r.NewGaugeFunc("test_height", "Height.", func(context.Context) float64 { return 42 })
+
+	requests.Inc("/api/x", "200")
+	requests.Add(2, "/api/x", "200")
+	requests.Inc(`/a"b`, "500")
+	latency.Observe(0.05)
+	latency.Observe(0.5)
+	latency.Observe(3)
+
+	var buf bytes.Buffer
+	if err := r.Write(context.Background(), &buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# TYPE test_requests_total counter\n",
+		`test_requests_total{route="/api/x",status="200"} 3` + "\n",
+		`test_requests_total{route="/a\"b",status="500"} 1` + "\n",
+		"# TYPE test_latency_seconds histogram\n",
+		`test_latency_seconds_bucket{le="0.1"} 1` + "\n",
+		`test_latency_seconds_bucket{le="1"} 2` + "\n",
+		`test_latency_seconds_bucket{le="+Inf"} 3` + "\n",
+		"test_latency_seconds_sum 3.55\n",
+		"test_latency_seconds_count 3\n",
+		"test_height 42\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
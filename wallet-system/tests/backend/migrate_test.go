@@ -0,0 +1,258 @@
+package database
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrationsEmbedded(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) < 2 {
+		t.Fatalf("got %d migrations, want at least 2", len(migrations))
+	}
+
+	for i, m := range migrations {
+		if i > 0 && m.Version <= migrations[i-1].Version {
+			t.Errorf("migration %d_%s is out of order", m.Version, m.Name)
+		}
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			t.Errorf("migration %d_%s is missing statements", m.Version, m.Name)
+		}
+		if len(m.Checksum) != 64 {
+			t.Errorf("migration %d_%s checksum = %q", m.Version, m.Name, m.Checksum)
+		}
+	}
+
+	if first := migrations[0]; first.Version != 1 || first.Name != "initial" {
+		t.Errorf("first migration = %d_%s, want 1_initial", first.Version, first.Name)
+	}
+	if !strings.Contains(migrations[1].Up, "updated_at") {
+		t.Error("migration 2 does not add transactions.updated_at")
+	}
+}
+
+// TestInitialMigrationUpgradesBeforeIndexing checks that 0001 adds the columns
+// missing from databases built by the former schema.sql before creating any
+// index, as an index on such a column would abort the migration
+func TestInitialMigrationUpgradesBeforeIndexing(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up := migrations[0].Up
+	lastUpgrade := strings.LastIndex(up, "ADD COLUMN IF NOT EXISTS")
+	firstIndex := strings.Index(up, "CREATE INDEX")
+	if unique := strings.Index(up, "CREATE UNIQUE INDEX"); unique >= 0 && unique < firstIndex {
+		firstIndex = unique
+	}
+	if lastUpgrade < 0 || firstIndex < 0 {
+		t.Fatal("0001 has no upgrades or no indexes")
+	}
+	if firstIndex < lastUpgrade {
+		t.Error("0001 creates indexes before adding the columns existing databases lack")
+	}
+}
+
+// TestMigrateBaselineDatabase applies the migrations over a database built by
+// the former schema.sql, holding a duplicated monthly zakat deduction, then
+// rolls them all back. It needs an empty, disposable database in
+// TEST_DATABASE_URL.
+func TestMigrateBaselineDatabase(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	d, err := NewDatabase(url, PoolOptions{MaxOpenConns: 2, MaxIdleConns: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	if _, err := d.db.ExecContext(ctx, baselineSchema); err != nil {
+		t.Fatalf("baseline schema: %v", err)
+	}
+	defer d.db.ExecContext(context.Background(), `DROP TABLE IF EXISTS schema_migrations`)
+
+	if _, err := d.db.ExecContext(ctx, `
+		INSERT INTO zakat_transactions (wallet_address, amount, month_year, created_at)
+		VALUES ('w1', 1, '2024-01', NOW() - INTERVAL '1 hour'), ('w1', 1, '2024-01', NOW())
+	`); err != nil {
+		t.Fatalf("seed zakat deductions: %v", err)
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	applied, err := d.Migrate(ctx)
+	if err != nil {
+		t.Fatalf("migrate baseline database: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Errorf("applied %d migrations, want %d", len(applied), len(migrations))
+	}
+
+	var kept, setAside int
+	if err := d.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM zakat_transactions`).Scan(&kept); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM zakat_transaction_duplicates`).Scan(&setAside); err != nil {
+		t.Fatal(err)
+	}
+	if kept != 1 || setAside != 1 {
+		t.Errorf("kept %d zakat deductions and set aside %d, want 1 and 1", kept, setAside)
+	}
+
+	again, err := d.Migrate(ctx)
+	if err != nil || len(again) != 0 {
+		t.Errorf("second Migrate applied %d migrations, err %v", len(again), err)
+	}
+
+	statuses, err := d.MigrationStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if !s.Applied || s.Modified || s.Missing {
+			t.Errorf("migration %d_%s status %+v", s.Version, s.Name, s)
+		}
+	}
+
+	rolledBack, err := d.Rollback(ctx, len(migrations))
+	if err != nil {
+		t.Fatalf("roll back: %v", err)
+	}
+	if len(rolledBack) != len(migrations) {
+		t.Errorf("rolled back %d migrations, want %d", len(rolledBack), len(migrations))
+	}
+}
+
+// baselineSchema is schema.sql as of the baseline release
+const baselineSchema = `
+-- Create tables for the crypto wallet system
+
+-- Users table
+CREATE TABLE IF NOT EXISTS users (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email VARCHAR(255) UNIQUE NOT NULL,
+    full_name VARCHAR(255),
+    cnic VARCHAR(20) UNIQUE,
+    wallet_id VARCHAR(64) UNIQUE NOT NULL,
+    public_key TEXT NOT NULL,
+    encrypted_private_key TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    is_verified BOOLEAN DEFAULT FALSE
+);
+
+-- Wallets table
+CREATE TABLE IF NOT EXISTS wallets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    wallet_address VARCHAR(64) UNIQUE NOT NULL,
+    balance_cache DECIMAL(20,8) DEFAULT 0,
+    last_updated TIMESTAMP DEFAULT NOW(),
+    zakat_deducted_this_month BOOLEAN DEFAULT FALSE
+);
+
+-- UTXO table (Unspent Transaction Outputs)
+CREATE TABLE IF NOT EXISTS utxos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_hash VARCHAR(64) NOT NULL,
+    output_index INTEGER NOT NULL,
+    wallet_address VARCHAR(64) NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    is_spent BOOLEAN DEFAULT FALSE,
+    spent_in_transaction VARCHAR(64),
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(transaction_hash, output_index)
+);
+
+-- Blocks table
+CREATE TABLE IF NOT EXISTS blocks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    block_index INTEGER UNIQUE NOT NULL,
+    timestamp BIGINT NOT NULL,
+    previous_hash VARCHAR(64) NOT NULL,
+    hash VARCHAR(64) UNIQUE NOT NULL,
+    nonce BIGINT NOT NULL,
+    merkle_root VARCHAR(64),
+    difficulty INTEGER DEFAULT 4,
+    mined_by VARCHAR(64),
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Transactions table
+CREATE TABLE IF NOT EXISTS transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_hash VARCHAR(64) UNIQUE NOT NULL,
+    block_hash VARCHAR(64) REFERENCES blocks(hash),
+    sender_wallet VARCHAR(64) NOT NULL,
+    receiver_wallet VARCHAR(64) NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    fee DECIMAL(20,8) DEFAULT 0,
+    note TEXT,
+    signature TEXT NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending',
+    created_at TIMESTAMP DEFAULT NOW(),
+    transaction_type VARCHAR(20) DEFAULT 'transfer'
+);
+
+-- Zakat Transactions table
+CREATE TABLE IF NOT EXISTS zakat_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wallet_address VARCHAR(64) NOT NULL,
+    amount DECIMAL(20,8) NOT NULL,
+    zakat_percentage DECIMAL(5,2) DEFAULT 2.5,
+    transaction_hash VARCHAR(64) REFERENCES transactions(transaction_hash),
+    month_year VARCHAR(7) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- System Logs table
+CREATE TABLE IF NOT EXISTS system_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    log_type VARCHAR(50) NOT NULL,
+    message TEXT NOT NULL,
+    wallet_address VARCHAR(64),
+    ip_address INET,
+    user_agent TEXT,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Beneficiaries table
+CREATE TABLE IF NOT EXISTS beneficiaries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    beneficiary_wallet_id VARCHAR(64) NOT NULL,
+    nickname VARCHAR(100),
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(user_id, beneficiary_wallet_id)
+);
+
+-- Create indexes for faster queries
+CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+CREATE INDEX IF NOT EXISTS idx_users_wallet_id ON users(wallet_id);
+CREATE INDEX IF NOT EXISTS idx_wallets_user_id ON wallets(user_id);
+CREATE INDEX IF NOT EXISTS idx_wallets_address ON wallets(wallet_address);
+CREATE INDEX IF NOT EXISTS idx_transactions_sender ON transactions(sender_wallet);
+CREATE INDEX IF NOT EXISTS idx_transactions_receiver ON transactions(receiver_wallet);
+CREATE INDEX IF NOT EXISTS idx_transactions_hash ON transactions(transaction_hash);
+CREATE INDEX IF NOT EXISTS idx_blocks_index ON blocks(block_index);
+CREATE INDEX IF NOT EXISTS idx_blocks_hash ON blocks(hash);
+CREATE INDEX IF NOT EXISTS idx_utxos_wallet ON utxos(wallet_address);
+CREATE INDEX IF NOT EXISTS idx_utxos_spent ON utxos(is_spent);
+CREATE INDEX IF NOT EXISTS idx_zakat_wallet ON zakat_transactions(wallet_address);
+CREATE INDEX IF NOT EXISTS idx_beneficiaries_user ON beneficiaries(user_id);
+`
@@ -0,0 +1,136 @@
+package blockchain
+
+import (
+	"testing"
+
+	"crypto-wallet-backend/internal/crypto"
+)
+
+func TestMultisigAddressIsOrderIndependent(t *testing.T) {
+	p1, err := NewMultisigPolicy(2, []string{"keyA", "keyB", "keyC"})
+	if err != nil {
+		t.Fatalf("Failed to create policy: %v", err)
+	}
+
+	p2, err := NewMultisigPolicy(2, []string{"keyC", "keyA", "keyB"})
+	if err != nil {
+		t.Fatalf("Failed to create policy: %v", err)
+	}
+
+	if p1.Address() != p2.Address() {
+		t.Errorf("Same key set should produce the same address")
+	}
+
+	if len(p1.Address()) != 64 {
+		t.Errorf("Expected address length 64, got %d", len(p1.Address()))
+	}
+}
+
+func TestMultisigPolicyValidation(t *testing.T) {
+	if _, err := NewMultisigPolicy(3, []string{"keyA", "keyB"}); err == nil {
+		t.Errorf("Threshold above key count should be rejected")
+	}
+
+	if _, err := NewMultisigPolicy(1, []string{"keyA", "keyA"}); err == nil {
+		t.Errorf("Duplicate keys should be rejected")
+	}
+}
+
+func TestVerifyMultisig(t *testing.T) {
+	var keyPairs []*crypto.KeyPair
+	var publicKeys []string
+	for i := 0; i < 3; i++ {
+		kp, err := crypto.GenerateKeyPair()
+		if err != nil {
+			t.Fatalf("Failed to generate key pair: %v", err)
+		}
+		keyPairs = append(keyPairs, kp)
+		publicKeys = append(publicKeys, kp.PublicKey)
+	}
+
+	policy, err := NewMultisigPolicy(2, publicKeys)
+	if err != nil {
+		t.Fatalf("Failed to create policy: %v", err)
+	}
+
+	tx := NewTransaction(policy.Address(), "receiver", 10, 0.1, "")
+	sign := func(kp *crypto.KeyPair) TxSignature {
+		sig, err := crypto.SignTransaction(tx.SigningData(), kp.PrivateKey)
+		if err != nil {
+			t.Fatalf("Failed to sign: %v", err)
+		}
+		return TxSignature{PublicKey: kp.PublicKey, Signature: sig}
+	}
+
+	// The same co-signer twice does not meet a 2-of-3 threshold
+	tx.Signatures = []TxSignature{sign(keyPairs[0]), sign(keyPairs[0])}
+	if err := tx.VerifyMultisig(policy); err != ErrInsufficientSignatures {
+		t.Errorf("Expected ErrInsufficientSignatures, got %v", err)
+	}
+
+	tx.Signatures = append(tx.Signatures, sign(keyPairs[2]))
+	if err := tx.VerifyMultisig(policy); err != nil {
+		t.Errorf("Expected valid multisig transaction, got %v", err)
+	}
+}
+
+func TestAddBlockVerifiesMultisig(t *testing.T) {
+	var keyPairs []*crypto.KeyPair
+	var publicKeys []string
+	for i := 0; i < 3; i++ {
+		kp, err := crypto.GenerateKeyPair()
+		if err != nil {
+			t.Fatalf("Failed to generate key pair: %v", err)
+		}
+		keyPairs = append(keyPairs, kp)
+		publicKeys = append(publicKeys, kp.PublicKey)
+	}
+
+	policy, err := NewMultisigPolicy(2, publicKeys)
+	if err != nil {
+		t.Fatalf("Failed to create policy: %v", err)
+	}
+
+	tx := NewTransaction(policy.Address(), "receiver", 10, 0.1, "")
+	for _, kp := range keyPairs[:2] {
+		sig, err := crypto.SignTransaction(tx.SigningData(), kp.PrivateKey)
+		if err != nil {
+			t.Fatalf("Failed to sign: %v", err)
+		}
+		tx.Signatures = append(tx.Signatures, TxSignature{PublicKey: kp.PublicKey, Signature: sig})
+	}
+
+	bc := NewBlockchainWithParams(Params{Difficulty: 1})
+	mine := func(tx Transaction) *Block {
+		block := NewBlock(1, []Transaction{tx}, bc.GetLatestBlock().Hash, 1)
+		NewProofOfWork(block).Mine()
+		return block
+	}
+
+	// Signatures without the policy deriving the sender cannot be checked
+	if err := bc.AddBlock(mine(*tx)); err != ErrInsufficientSignatures {
+		t.Errorf("Expected ErrInsufficientSignatures without a policy, got %v", err)
+	}
+
+	// One co-signer does not meet a 2-of-3 threshold
+	short := *tx
+	short.Multisig = policy
+	short.Signatures = tx.Signatures[:1]
+	if err := bc.AddBlock(mine(short)); err != ErrInsufficientSignatures {
+		t.Errorf("Expected ErrInsufficientSignatures for one co-signer, got %v", err)
+	}
+
+	tx.Multisig = policy
+	if err := bc.AddBlock(mine(*tx)); err != nil {
+		t.Fatalf("Expected block with a valid multisig spend to be added, got %v", err)
+	}
+	if !bc.ValidateChain() {
+		t.Error("Chain with a valid multisig spend should validate")
+	}
+
+	// Stripping a signature from a mined block invalidates the chain
+	bc.Chain[1].Transactions[0].Signatures = tx.Signatures[:1]
+	if bc.ValidateChain() {
+		t.Error("Chain whose multisig spend lost a signature should not validate")
+	}
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterBurstAndRetry(t *testing.T) {
+	limiter := NewRateLimiter(1, 3)
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := limiter.Allow("ip:1.2.3.4"); !ok {
+			t.Fatalf("request %d within the burst was refused", i+1)
+		}
+	}
+
+	ok, wait := limiter.Allow("ip:1.2.3.4")
+	if ok {
+		t.Fatal("request beyond the burst was allowed")
+	}
+	if wait <= 0 || wait > time.Second {
+		t.Errorf("retry after %v, want (0, 1s]", wait)
+	}
+
+	// Keys have separate buckets
+	if ok, _ := limiter.Allow("ip:5.6.7.8"); !ok {
+		t.Error("another key was refused")
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	limiter := NewRateLimiter(0, 0)
+	for i := 0; i < 100; i++ {
+		if ok, _ := limiter.Allow("key"); !ok {
+			t.Fatal("disabled limiter refused a request")
+		}
+	}
+}